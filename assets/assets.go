@@ -0,0 +1,10 @@
+// assets.go embeds the page templates, the OpenAPI document and the static files, so the binary
+// serves them from any working directory.
+package assets
+
+import "embed"
+
+// FS holds every asset under the paths used in this directory, e.g. "tot.html" and "static/style.css".
+//
+//go:embed *.html openapi.json static
+var FS embed.FS
@@ -1,10 +1,12 @@
-// main.go is the entry point for the application. It starts the web server and background tasks.
+// main.go is the entry point for the application. Without a command it loads the configuration
+// and starts the web server and background tasks; see internal/admin for the other commands.
 package main
 
-import totWeb "tot-tally/internal/web"
+import (
+	"os"
+	totAdmin "tot-tally/internal/admin"
+)
 
 func main() {
-	// Start the web server and all associated background tasks.
-	// All configuration and initialization logic is encapsulated within the web package.
-	totWeb.Start()
+	os.Exit(totAdmin.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.LookupEnv))
 }
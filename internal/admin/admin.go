@@ -0,0 +1,166 @@
+// admin.go runs the tot-tally command line: the server, and the subcommands operators use to
+// inspect and maintain its data with the same configuration and storage code as the server.
+package admin
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+	totWeb "tot-tally/internal/web"
+)
+
+// command is a subcommand such as "tot show".
+type command struct {
+	name  string
+	args  []string // Names of the positional arguments, e.g. "<id>".
+	help  string
+	flags func(fs *flag.FlagSet) // Registers the command's own flags; nil if it has none.
+	run   func(a *app) error
+}
+
+// commands lists every subcommand in usage order; the first is the default.
+var commands = []command{
+	{name: "serve", help: "run the web server and background workers (the default)", flags: serveFlags, run: serve},
+	{name: "limit show", args: []string{"<ip>"}, help: "show how many tots an IP address has created", run: limitShow},
+	{name: "limit reset", args: []string{"<ip>"}, help: "let an IP address create tots again", run: limitReset},
+	{name: "tot show", args: []string{"<id>"}, help: "summarize a tot's settings and tallies", run: totShow},
+	{name: "tot export", args: []string{"<id>"}, help: "write a tot as JSON to standard output", run: totExport},
+	{name: "tot import", args: []string{"<file>"}, help: "save a tot from an export, or - for standard input", flags: importFlags, run: totImport},
+	{name: "tot delete", args: []string{"<id>"}, help: "delete a tot and its links", run: totDelete},
+	{name: "cleanup", help: "remove expired tots and IP limits now, quarantining damaged ones", flags: dryRunFlag, run: cleanup},
+	{name: "verify", help: "report damaged or inconsistent data files", run: verify},
+	{name: "repair", help: "fix what verify reports where it is safe, quarantining unreadable files", run: repair},
+	{name: "quarantine list", help: "list the files moved aside by cleanup and repair", run: quarantineList},
+	{name: "quarantine show", args: []string{"<name>"}, help: "print a quarantined file and why it was moved", run: quarantineShow},
+	{name: "quarantine restore", args: []string{"<name>"}, help: "move a quarantined file back", flags: restoreFlags, run: quarantineRestore},
+	{name: "backup create", help: "snapshot the data directories now and apply retention", run: backupCreate},
+	{name: "backup list", help: "list the snapshots in BackupDirectory", run: backupList},
+	{name: "backup verify", args: []string{"<archive>"}, help: "check that a snapshot can be read in full", run: backupVerify},
+	{name: "backup restore", args: []string{"<archive>"}, help: "write a snapshot's files back into the data directories", flags: restoreBackupFlags, run: backupRestore},
+	{name: "migrate", help: "rewrite tot files in the current format", flags: dryRunFlag, run: migrate},
+}
+
+// app is what a command runs with.
+type app struct {
+	config *totConfig.Config
+	store  *totStorage.Repository
+	pool   *totShards.Pool
+	core   *totCore.Service
+	stats  *totStats.Engine
+	flags  *flag.FlagSet
+	in     io.Reader
+	out    io.Writer
+}
+
+// arg returns the i-th positional argument.
+func (a *app) arg(i int) string {
+	return a.flags.Arg(i)
+}
+
+// bool returns the value of one of the command's boolean flags.
+func (a *app) bool(name string) bool {
+	v, _ := strconv.ParseBool(a.flags.Lookup(name).Value.String())
+	return v
+}
+
+// Main runs the command named at the start of args, e.g. "tot show -tot-directory data <id>",
+// and returns the exit status. Without a command, or with only flags, it serves, as tot-tally
+// always has.
+func Main(args []string, stdin io.Reader, stdout, stderr io.Writer, lookupEnv func(string) (string, bool)) int {
+	if len(args) > 0 && args[0] == "help" {
+		printUsage(stdout)
+		return 0
+	}
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(stderr, "tot-tally: unknown command %q\n\n", strings.Join(args[:min(2, len(args))], " "))
+		printUsage(stderr)
+		return 2
+	}
+
+	fs := totConfig.NewFlagSet("tot-tally " + cmd.name)
+	fs.SetOutput(stderr)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: tot-tally %s [flags] %s\n\n%s.\n\nFlags:\n", cmd.name, strings.Join(cmd.args, " "), cmd.help)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != len(cmd.args) {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := totConfig.Load(fs, lookupEnv)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	pool := totShards.NewPool(cfg.NumShards)
+	store := totStorage.NewRepository(cfg, pool)
+	engine := totStats.NewEngine(cfg)
+	a := &app{
+		config: cfg, store: store, pool: pool, core: totCore.NewService(cfg, store, engine), stats: engine,
+		flags: fs, in: stdin, out: stdout,
+	}
+	if err := cmd.run(a); err != nil {
+		fmt.Fprintf(stderr, "tot-tally %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+// findCommand matches the one or two words starting args to a command, and returns the rest.
+func findCommand(args []string) (command, []string, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commands[0], args, true
+	}
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tot-tally [command] [flags] [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-26s %s\n", strings.TrimSpace(cmd.name+" "+strings.Join(cmd.args, " ")), cmd.help)
+	}
+	fmt.Fprintln(w, "\nEvery command takes the server's configuration flags; run a command with -h to list them.")
+}
+
+func serveFlags(fs *flag.FlagSet) {
+	fs.Bool("print-config", false, "print the effective configuration and exit")
+}
+
+func dryRunFlag(fs *flag.FlagSet) {
+	fs.Bool("dry-run", false, "report what would change without changing anything")
+}
+
+func serve(a *app) error {
+	if a.bool("print-config") {
+		return a.config.Print(a.out)
+	}
+	totWeb.Start(a.config)
+	return nil
+}
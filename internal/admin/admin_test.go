@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+// testEnv points every data directory at a new temporary directory.
+type testEnv struct {
+	t    *testing.T
+	cfg  *totConfig.Config
+	core *totCore.Service
+	repo *totStorage.Repository
+	env  map[string]string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	dir := t.TempDir()
+	cfg := totConfig.NewDefaultConfig()
+	cfg.TotDirectory = filepath.Join(dir, "tots")
+	cfg.LimitDirectory = filepath.Join(dir, "limits")
+	cfg.LinkDirectory = filepath.Join(dir, "links")
+	cfg.QuarantineDirectory = filepath.Join(dir, "quarantine")
+	cfg.BackupDirectory = filepath.Join(dir, "backups")
+	for _, d := range []string{cfg.TotDirectory, cfg.LimitDirectory, cfg.LinkDirectory} {
+		_ = os.MkdirAll(d, 0755)
+	}
+	repo := totStorage.NewRepository(cfg, totShards.NewPool(4))
+	return &testEnv{
+		t: t, cfg: cfg, repo: repo,
+		core: totCore.NewService(cfg, repo, totStats.NewEngine(cfg)),
+		env: map[string]string{
+			"TOT_TALLY_TOT_DIRECTORY": cfg.TotDirectory, "TOT_TALLY_LIMIT_DIRECTORY": cfg.LimitDirectory,
+			"TOT_TALLY_LINK_DIRECTORY": cfg.LinkDirectory, "TOT_TALLY_QUARANTINE_DIRECTORY": cfg.QuarantineDirectory,
+			"TOT_TALLY_BACKUP_DIRECTORY": cfg.BackupDirectory,
+		},
+	}
+}
+
+// run runs the command line args with stdin, returning its output and exit status.
+func (e *testEnv) run(stdin string, args ...string) (string, string, int) {
+	e.t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Main(args, strings.NewReader(stdin), &stdout, &stderr, func(key string) (string, bool) {
+		v, ok := e.env[key]
+		return v, ok
+	})
+	return stdout.String(), stderr.String(), code
+}
+
+func TestMain_Usage(t *testing.T) {
+	e := newTestEnv(t)
+
+	if out, _, code := e.run("", "help"); code != 0 || !strings.Contains(out, "limit reset <ip>") {
+		t.Errorf("expected usage listing the commands, got %d %q", code, out)
+	}
+	if _, errOut, code := e.run("", "frobnicate"); code != 2 || !strings.Contains(errOut, `unknown command "frobnicate"`) {
+		t.Errorf("expected an unknown command error, got %d %q", code, errOut)
+	}
+	if _, errOut, code := e.run("", "tot", "show"); code != 2 || !strings.Contains(errOut, "Usage: tot-tally tot show [flags] <id>") {
+		t.Errorf("expected usage for a missing argument, got %d %q", code, errOut)
+	}
+	if _, errOut, code := e.run("", "verify", "-h"); code != 0 || !strings.Contains(errOut, "-tot-directory") {
+		t.Errorf("expected -h to list the config flags, got %d %q", code, errOut)
+	}
+	if _, errOut, code := e.run("", "verify", "-max-tallies", "0"); code != 2 || !strings.Contains(errOut, "MaxTallies") {
+		t.Errorf("expected invalid config to be reported, got %d %q", code, errOut)
+	}
+}
+
+func TestMain_DefaultsToServe(t *testing.T) {
+	e := newTestEnv(t)
+	for _, args := range [][]string{{"-print-config"}, {"serve", "-print-config"}} {
+		out, errOut, code := e.run("", args...)
+		if code != 0 || !strings.Contains(out, `"TotDirectory": "`+e.cfg.TotDirectory+`"`) {
+			t.Errorf("%v: expected the config, got %d %q %q", args, code, out, errOut)
+		}
+	}
+}
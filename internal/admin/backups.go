@@ -0,0 +1,97 @@
+// backups.go takes, lists, verifies and restores snapshots of the data directories.
+package admin
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+	totBackup "tot-tally/internal/backup"
+)
+
+// backups returns the backup worker, failing when there is nowhere to keep snapshots.
+func (a *app) backups() (*totBackup.Worker, error) {
+	if a.config.BackupDirectory == "" {
+		return nil, errors.New("BackupDirectory is not set")
+	}
+	return totBackup.NewWorker(a.config, a.pool), nil
+}
+
+// archivePath finds an archive given as a path, or by name in BackupDirectory.
+func (a *app) archivePath(name string) string {
+	if _, err := os.Stat(name); err != nil && a.config.BackupDirectory != "" && filepath.Base(name) == name {
+		return filepath.Join(a.config.BackupDirectory, name)
+	}
+	return name
+}
+
+func backupCreate(a *app) error {
+	w, err := a.backups()
+	if err != nil {
+		return err
+	}
+	archive, contents, err := w.Snapshot(time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "wrote %s (%d files, %d bytes)\n", archive.Path, len(contents), archive.Size)
+	pruned, err := w.Prune()
+	for _, p := range pruned {
+		fmt.Fprintf(a.out, "deleted %s\n", p.Name)
+	}
+	return err
+}
+
+func backupList(a *app) error {
+	w, err := a.backups()
+	if err != nil {
+		return err
+	}
+	archives, err := w.List()
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		fmt.Fprintln(a.out, "no backups")
+		return nil
+	}
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTAKEN\tSIZE")
+	for _, archive := range archives {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", archive.Name, archive.At.Format(time.RFC3339), archive.Size)
+	}
+	return tw.Flush()
+}
+
+func backupVerify(a *app) error {
+	path := a.archivePath(a.arg(0))
+	contents, err := totBackup.Verify(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "%s: %d files ok\n", path, len(contents))
+	return nil
+}
+
+func restoreBackupFlags(fs *flag.FlagSet) {
+	fs.Bool("replace", false, "overwrite files that already exist")
+}
+
+// backupRestore writes files back without BackupDirectory being set, so a fresh server can be
+// restored from an archive copied onto it.
+func backupRestore(a *app) error {
+	path := a.archivePath(a.arg(0))
+	result, err := totBackup.NewWorker(a.config, a.pool).Restore(path, a.bool("replace"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "restored %d files from %s", result.Files, path)
+	if result.Skipped > 0 {
+		fmt.Fprintf(a.out, ", kept %d existing files (use -replace to overwrite them)", result.Skipped)
+	}
+	fmt.Fprintln(a.out)
+	return nil
+}
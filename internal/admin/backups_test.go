@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackups(t *testing.T) {
+	e := newTestEnv(t)
+	if out, _, _ := e.run("", "backup", "list"); out != "no backups\n" {
+		t.Errorf("expected no backups, got %q", out)
+	}
+
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+	e.repo.CheckAndIncrementIPLimit("203.0.113.7")
+	out, errOut, code := e.run("", "backup", "create")
+	if code != 0 || !strings.HasPrefix(out, "wrote "+e.cfg.BackupDirectory+"/tot-tally-") || !strings.Contains(out, "(2 files, ") {
+		t.Fatalf("unexpected backup output %d %q %q", code, out, errOut)
+	}
+	path := strings.Fields(out)[1]
+	name := filepath.Base(path)
+
+	if out, _, _ := e.run("", "backup", "list"); !strings.Contains(out, name) {
+		t.Errorf("expected the archive listed, got %q", out)
+	}
+	if out, _, code := e.run("", "backup", "verify", name); code != 0 || out != path+": 2 files ok\n" {
+		t.Errorf("expected the archive verified by name, got %d %q", code, out)
+	}
+
+	e.run("", "tot", "delete", id)
+	if out, errOut, code := e.run("", "backup", "restore", path); code != 0 || out != "restored 1 files from "+path+", kept 1 existing files (use -replace to overwrite them)\n" {
+		t.Fatalf("unexpected restore output %d %q %q", code, out, errOut)
+	}
+	if _, err := e.repo.LoadTot(id); err != nil {
+		t.Errorf("expected the deleted tot back: %v", err)
+	}
+
+	os.WriteFile(path, []byte("not an archive"), 0644)
+	if _, errOut, code := e.run("", "backup", "verify", path); code != 1 || !strings.Contains(errOut, "failed to read archive") {
+		t.Errorf("expected a damaged archive to fail, got %d %q", code, errOut)
+	}
+
+	delete(e.env, "TOT_TALLY_BACKUP_DIRECTORY")
+	if _, errOut, code := e.run("", "backup", "create"); code != 1 || !strings.Contains(errOut, "BackupDirectory is not set") {
+		t.Errorf("expected backups to need a directory, got %d %q", code, errOut)
+	}
+}
@@ -0,0 +1,33 @@
+// limits.go inspects and resets the per-IP tot creation limits.
+package admin
+
+import (
+	"fmt"
+	"time"
+)
+
+func limitShow(a *app) error {
+	ip := a.arg(0)
+	limit := a.store.LoadIPLimit(ip)
+	if limit.Count == 0 {
+		fmt.Fprintf(a.out, "%s: no tots created (limit file %s)\n", ip, limit.Hash)
+		return nil
+	}
+	fmt.Fprintf(a.out, "%s: %d of %d tots created, last at %s (limit file %s)\n",
+		ip, limit.Count, a.config.MaxTotsPerIP, limit.LastCreated.UTC().Format(time.RFC3339), limit.Hash)
+	return nil
+}
+
+func limitReset(a *app) error {
+	ip := a.arg(0)
+	reset, err := a.store.ResetIPLimit(ip)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		fmt.Fprintf(a.out, "%s: no limit to reset\n", ip)
+		return nil
+	}
+	fmt.Fprintf(a.out, "%s: limit reset\n", ip)
+	return nil
+}
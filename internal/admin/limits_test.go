@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitShowAndReset(t *testing.T) {
+	e := newTestEnv(t)
+	ip := "203.0.113.7"
+
+	if out, _, code := e.run("", "limit", "show", ip); code != 0 || !strings.Contains(out, ip+": no tots created") {
+		t.Errorf("expected no tots created, got %d %q", code, out)
+	}
+	if out, _, _ := e.run("", "limit", "reset", ip); !strings.Contains(out, "no limit to reset") {
+		t.Errorf("expected nothing to reset, got %q", out)
+	}
+
+	for range 2 {
+		if err := e.repo.CheckAndIncrementIPLimit(ip); err != nil {
+			t.Fatalf("CheckAndIncrementIPLimit failed: %v", err)
+		}
+	}
+	if out, _, _ := e.run("", "limit", "show", ip); !strings.Contains(out, ip+": 2 of 10 tots created, last at ") {
+		t.Errorf("expected the count, got %q", out)
+	}
+	if out, _, code := e.run("", "limit", "reset", ip); code != 0 || out != ip+": limit reset\n" {
+		t.Errorf("expected the limit reset, got %d %q", code, out)
+	}
+	if limit := e.repo.LoadIPLimit(ip); limit.Count != 0 {
+		t.Errorf("expected the count to be cleared, got %d", limit.Count)
+	}
+}
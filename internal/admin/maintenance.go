@@ -0,0 +1,123 @@
+// maintenance.go runs cleanup on demand, checks and repairs data files, and migrates tot files to
+// the current format.
+package admin
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	totIntegrity "tot-tally/internal/integrity"
+	totWeb "tot-tally/internal/web"
+)
+
+func cleanup(a *app) error {
+	dryRun := a.bool("dry-run")
+	verb, summary := "removed", "%d files removed, %d quarantined\n"
+	if dryRun {
+		verb, summary = "would remove", "%d files would be removed, %d quarantined\n"
+	}
+	removed, quarantined := 0, 0
+	for _, r := range totWeb.NewCleaner(a.config, a.store, a.pool, a.core).Run(dryRun) {
+		switch {
+		case r.Reason == "expired":
+			removed++
+			fmt.Fprintf(a.out, "%s %s (%s)\n", verb, r.Path, r.Reason)
+		case dryRun:
+			quarantined++
+			fmt.Fprintf(a.out, "would quarantine %s (%s)\n", r.Path, r.Reason)
+		default:
+			quarantined++
+			fmt.Fprintf(a.out, "quarantined %s (%s) as %s\n", r.Path, r.Reason, filepath.Base(r.Quarantine))
+		}
+	}
+	fmt.Fprintf(a.out, summary, removed, quarantined)
+	return nil
+}
+
+// verify reports every problem the integrity checker finds, failing if there are any.
+func verify(a *app) error {
+	report, err := totIntegrity.NewChecker(a.config, a.store, a.pool, a.stats).Run(false)
+	for _, issue := range report.Issues {
+		fmt.Fprintf(a.out, "%s: %s (%s)\n", issue.Path, issue.Problem, issue.Detail)
+	}
+	fmt.Fprintf(a.out, "checked %d tots, %d problems\n", report.Tots, len(report.Issues))
+	if err != nil {
+		return err
+	}
+	if n := len(report.Issues); n > 0 {
+		return fmt.Errorf("found %d problems", n)
+	}
+	return nil
+}
+
+// repair fixes what it safely can and reports the rest, failing if anything is left.
+func repair(a *app) error {
+	report, err := totIntegrity.NewChecker(a.config, a.store, a.pool, a.stats).Run(true)
+	for _, issue := range report.Issues {
+		fmt.Fprintf(a.out, "%s: %s (%s): %s\n", issue.Path, issue.Problem, issue.Detail, cmp.Or(issue.Fix, "left alone"))
+	}
+	unfixed := report.Unfixed()
+	fmt.Fprintf(a.out, "checked %d tots, fixed %d of %d problems\n", report.Tots, len(report.Issues)-unfixed, len(report.Issues))
+	if err != nil {
+		return err
+	}
+	if unfixed > 0 {
+		return fmt.Errorf("%d problems need fixing by hand", unfixed)
+	}
+	return nil
+}
+
+// migrate rewrites every tot file whose contents differ from what the current code would save,
+// e.g. to fill in settings added since it was written. UpdatedAt is kept, so cleanup is unchanged.
+func migrate(a *app) error {
+	ids, err := a.store.ListTotIDs()
+	if err != nil {
+		return err
+	}
+	dryRun := a.bool("dry-run")
+	var errs []error
+	migrated := 0
+	for _, id := range ids {
+		changed, err := a.migrateTot(id, dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		if changed {
+			migrated++
+			fmt.Fprintf(a.out, "migrated %s\n", id)
+		}
+	}
+	if dryRun {
+		fmt.Fprintf(a.out, "%d of %d tots would be migrated\n", migrated, len(ids))
+	} else {
+		fmt.Fprintf(a.out, "%d of %d tots migrated\n", migrated, len(ids))
+	}
+	return errors.Join(errs...)
+}
+
+func (a *app) migrateTot(id string, dryRun bool) (bool, error) {
+	original, err := os.ReadFile(filepath.Join(a.config.TotDirectory, id+".json"))
+	if err != nil {
+		return false, err
+	}
+	tot, err := a.store.LoadTot(id)
+	if err != nil {
+		return false, err
+	}
+	var current bytes.Buffer
+	if err := json.NewEncoder(&current).Encode(tot); err != nil {
+		return false, err
+	}
+	if bytes.Equal(original, current.Bytes()) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, a.store.SaveTotWithoutActivity(tot)
+}
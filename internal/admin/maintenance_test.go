@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanup(t *testing.T) {
+	e := newTestEnv(t)
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+	tot, _ := e.repo.LoadTot(id)
+	tot.UpdatedAt = time.Now().Add(-e.cfg.CleanupAge - time.Hour)
+	e.repo.SaveTotWithoutActivity(tot)
+	path := filepath.Join(e.cfg.TotDirectory, id+".json")
+
+	out, _, code := e.run("", "cleanup", "-dry-run")
+	if code != 0 || out != "would remove "+path+" (expired)\n1 files would be removed, 0 quarantined\n" {
+		t.Errorf("unexpected dry run output %d %q", code, out)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected a dry run to keep the file: %v", err)
+	}
+
+	if out, _, _ := e.run("", "cleanup"); !strings.HasPrefix(out, "removed "+path+" (expired)\n") {
+		t.Errorf("unexpected cleanup output %q", out)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the expired tot removed, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	e := newTestEnv(t)
+	e.core.CreateTot("👶", "UTC", "both")
+
+	if out, _, code := e.run("", "verify"); code != 0 || out != "checked 1 tots, 0 problems\n" {
+		t.Errorf("expected no problems, got %d %q", code, out)
+	}
+
+	os.WriteFile(filepath.Join(e.cfg.TotDirectory, "broken.json"), []byte("{"), 0644)
+	out, errOut, code := e.run("", "verify")
+	if code != 1 || !strings.Contains(out, "broken.json: undecodable (storage: failed to decode tot") || !strings.Contains(out, "checked 2 tots, 1 problems") {
+		t.Errorf("expected the broken file reported, got %d %q", code, out)
+	}
+	if !strings.Contains(errOut, "found 1 problems") {
+		t.Errorf("expected verify to fail, got %q", errOut)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	e := newTestEnv(t)
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+	tot, _ := e.repo.LoadTot(id)
+	e.core.AddTally(tot, "11")
+	tot.Stats.LastPee = nil
+	e.repo.SaveTotWithoutActivity(tot)
+	broken := filepath.Join(e.cfg.TotDirectory, "broken.json")
+	os.WriteFile(broken, []byte("{"), 0644)
+
+	out, _, code := e.run("", "repair")
+	if code != 0 || !strings.Contains(out, id+".json: stale stats (latest activity is older than the tallies): recomputed") ||
+		!strings.Contains(out, "broken.json: undecodable (storage: failed to decode tot: unexpected EOF): moved to "+e.cfg.QuarantineDirectory) ||
+		!strings.HasSuffix(out, "checked 2 tots, fixed 2 of 2 problems\n") {
+		t.Errorf("unexpected repair output %d %q", code, out)
+	}
+	if out, _, code := e.run("", "verify"); code != 0 {
+		t.Errorf("expected nothing left after repair, got %q", out)
+	}
+
+	tot, _ = e.repo.LoadTot(id)
+	tot.Tallies[0].Kind = "🦕"
+	e.repo.SaveTotWithoutActivity(tot)
+	if out, errOut, code := e.run("", "repair"); code != 1 || !strings.Contains(out, "unknown kind (🦕): left alone") || !strings.Contains(errOut, "1 problems need fixing by hand") {
+		t.Errorf("expected unknown kinds left alone, got %d %q %q", code, out, errOut)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	e := newTestEnv(t)
+	e.core.CreateTot("👶", "UTC", "both")
+	old, _ := e.core.CreateTot("👧", "UTC", "both")
+
+	// Files written before milk settings existed lack the field.
+	path := filepath.Join(e.cfg.TotDirectory, old+".json")
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"milkSetting":"both"`, `"milkSetting":""`, 1)), 0644)
+
+	if out, _, code := e.run("", "migrate", "-dry-run"); code != 0 || out != "migrated "+old+"\n1 of 2 tots would be migrated\n" {
+		t.Errorf("unexpected dry run output %d %q", code, out)
+	}
+	if after, _ := os.ReadFile(path); strings.Contains(string(after), `"milkSetting":"both"`) {
+		t.Error("expected a dry run to leave the file alone")
+	}
+
+	if out, _, _ := e.run("", "migrate"); out != "migrated "+old+"\n1 of 2 tots migrated\n" {
+		t.Errorf("unexpected migrate output %q", out)
+	}
+	if after, _ := os.ReadFile(path); !strings.Contains(string(after), `"milkSetting":"both"`) {
+		t.Error("expected the milk setting filled in")
+	}
+	if out, _, _ := e.run("", "migrate"); out != "0 of 2 tots migrated\n" {
+		t.Errorf("expected nothing left to migrate, got %q", out)
+	}
+}
@@ -0,0 +1,85 @@
+// quarantine.go lists, shows and restores the damaged files that cleanup and repair set aside.
+package admin
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+func quarantineList(a *app) error {
+	files, err := a.store.ListQuarantined()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(a.out, "nothing quarantined")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tQUARANTINED\tREASON\tFROM")
+	for _, q := range files {
+		at := "?"
+		if !q.At.IsZero() {
+			at = q.At.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", q.Name, at, cmp.Or(q.Reason, "?"), cmp.Or(q.Path, "?"))
+	}
+	return w.Flush()
+}
+
+func quarantineShow(a *app) error {
+	q, err := a.store.LoadQuarantined(a.arg(0))
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(a.config.QuarantineDirectory, q.Name))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "From:        %s\nReason:      %s\nQuarantined: %s\nSize:        %d bytes\n\n",
+		q.Path, q.Reason, q.At.UTC().Format(time.RFC3339), len(data))
+	a.out.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		fmt.Fprintln(a.out)
+	}
+	return nil
+}
+
+func restoreFlags(fs *flag.FlagSet) {
+	fs.String("to", "", "restore to this path instead of where the file came from")
+	fs.Bool("replace", false, "overwrite a file already at the destination")
+}
+
+// quarantineRestore moves a file back once it has been fixed, e.g. by editing it in the quarantine
+// directory. A tot file still has to decode, so cleanup doesn't quarantine it again.
+func quarantineRestore(a *app) error {
+	name := a.arg(0)
+	q, err := a.store.LoadQuarantined(name)
+	if err != nil {
+		return err
+	}
+	dest := cmp.Or(a.flags.Lookup("to").Value.String(), q.Path)
+	if filepath.Clean(filepath.Dir(dest)) == filepath.Clean(a.config.TotDirectory) && strings.HasSuffix(dest, ".json") {
+		data, err := os.ReadFile(filepath.Join(a.config.QuarantineDirectory, q.Name))
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &totModels.Tot{}); err != nil {
+			return fmt.Errorf("%s is still not a tot (%v); fix it in the quarantine directory first", q.Name, err)
+		}
+	}
+	dest, err = a.store.RestoreQuarantined(q.Name, dest, a.bool("replace"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "restored %s to %s\n", q.Name, dest)
+	return nil
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	e := newTestEnv(t)
+	if out, _, _ := e.run("", "quarantine", "list"); out != "nothing quarantined\n" {
+		t.Errorf("expected an empty quarantine, got %q", out)
+	}
+
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+	path := filepath.Join(e.cfg.TotDirectory, id+".json")
+	good, _ := os.ReadFile(path)
+	os.WriteFile(path, good[:len(good)/2], 0644)
+
+	out, _, code := e.run("", "cleanup")
+	if code != 0 || !strings.HasPrefix(out, "quarantined "+path+" (unreadable) as ") || !strings.HasSuffix(out, "0 files removed, 1 quarantined\n") {
+		t.Fatalf("unexpected cleanup output %d %q", code, out)
+	}
+	name := strings.TrimSpace(out[strings.Index(out, " as ")+4 : strings.Index(out, "\n")])
+
+	out, _, _ = e.run("", "quarantine", "list")
+	if !strings.HasPrefix(out, "NAME") || !strings.Contains(out, name) || !strings.Contains(out, "unreadable") || !strings.Contains(out, path) {
+		t.Errorf("expected the file listed, got %q", out)
+	}
+	out, _, _ = e.run("", "quarantine", "show", name)
+	if !strings.Contains(out, "From:        "+path+"\n") || !strings.HasSuffix(out, string(good[:len(good)/2])+"\n") {
+		t.Errorf("expected the note and contents, got %q", out)
+	}
+
+	// A tot is only restored once it decodes again.
+	if _, errOut, code := e.run("", "quarantine", "restore", name); code != 1 || !strings.Contains(errOut, "still not a tot") {
+		t.Errorf("expected a broken tot to stay quarantined, got %d %q", code, errOut)
+	}
+	os.WriteFile(filepath.Join(e.cfg.QuarantineDirectory, name), good, 0644)
+	if out, errOut, code := e.run("", "quarantine", "restore", name); code != 0 || out != "restored "+name+" to "+path+"\n" {
+		t.Fatalf("expected the tot restored, got %d %q %q", code, out, errOut)
+	}
+	if tot, err := e.repo.LoadTot(id); err != nil || tot.ID != id {
+		t.Errorf("expected the restored tot to load, got %v", err)
+	}
+	if out, _, _ := e.run("", "quarantine", "list"); out != "nothing quarantined\n" {
+		t.Errorf("expected the quarantine emptied, got %q", out)
+	}
+	if _, errOut, code := e.run("", "quarantine", "show", name); code != 1 || !strings.Contains(errOut, "no quarantined file") {
+		t.Errorf("expected the file gone, got %d %q", code, errOut)
+	}
+}
@@ -0,0 +1,126 @@
+// tots.go shows, exports, imports and deletes single tots.
+package admin
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+func totShow(a *app) error {
+	tot, err := a.store.LoadTot(a.arg(0))
+	if err != nil {
+		return err
+	}
+	tz, err := time.LoadLocation(tot.Timezone)
+	if err != nil {
+		tz = time.UTC
+	}
+	at := func(t time.Time) string { return t.In(tz).Format(time.RFC3339) }
+	orOff := func(s string) string {
+		if s == "" {
+			return "off"
+		}
+		return s
+	}
+
+	tallies := "none"
+	if n := len(tot.Tallies); n > 0 && tot.Tallies[0].Time != nil {
+		tallies = fmt.Sprintf("%d, latest %s at %s", n, tot.Tallies[0].Kind, at(*tot.Tallies[0].Time))
+	}
+	feeds := "off"
+	if tot.ReadToken != "" {
+		feeds = "on"
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"ID", tot.ID},
+		{"Name", tot.Name},
+		{"Timezone", tot.Timezone},
+		{"Language", cmp.Or(tot.Locale, "automatic")},
+		{"Milk", tot.MilkSetting},
+		{"Day starts", fmt.Sprintf("%02d:00", tot.DayStartsAt)},
+		{"Created", at(tot.CreatedAt)},
+		{"Updated", at(tot.UpdatedAt)},
+		{"Tallies", tallies},
+		{"Alerts", fmt.Sprintf("%d, notify %s", len(tot.Alerts.Thresholds), cmp.Or(tot.Alerts.Notifier, "on page only"))},
+		{"Digest", orOff(tot.Digest.Email)},
+		{"Webhooks", fmt.Sprint(len(tot.Webhooks))},
+		{"Quick-log", fmt.Sprintf("%d links", len(tot.QuickLog.Links))},
+		{"Feeds", feeds},
+	} {
+		fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+	}
+	return w.Flush()
+}
+
+func totExport(a *app) error {
+	tot, err := a.store.LoadTot(a.arg(0))
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(a.out).Encode(tot)
+}
+
+func importFlags(fs *flag.FlagSet) {
+	fs.Bool("replace", false, "overwrite a tot with the same ID")
+}
+
+// totImport reads an export from "tot export" or the dashboard's Export Data button.
+func totImport(a *app) error {
+	in := a.in
+	if name := a.arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	tot, err := decodeTot(in)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{a.config.TotDirectory, a.config.LinkDirectory} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := a.core.ImportTot(tot, a.bool("replace")); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "imported %s with %d tallies\n", tot.ID, len(tot.Tallies))
+	return nil
+}
+
+// decodeTot reads one tot, rejecting unknown fields so another kind of JSON file isn't
+// mistaken for an export.
+func decodeTot(r io.Reader) (*totModels.Tot, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	tot := &totModels.Tot{}
+	if err := dec.Decode(tot); err != nil {
+		return nil, fmt.Errorf("admin: failed to decode tot: %w", err)
+	}
+	return tot, nil
+}
+
+func totDelete(a *app) error {
+	tot, err := a.store.LoadTot(a.arg(0))
+	if err != nil {
+		return err
+	}
+	if err := a.core.DeleteTot(tot); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "deleted %s\n", tot.ID)
+	return nil
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTotShowAndExport(t *testing.T) {
+	e := newTestEnv(t)
+	id, _ := e.core.CreateTot("👶", "Europe/Berlin", "both")
+	tot, _ := e.repo.LoadTot(id)
+	e.core.AddTally(tot, "11")
+	e.repo.SaveTot(tot)
+
+	out, _, code := e.run("", "tot", "show", id)
+	for _, want := range []string{"ID          " + id, "Timezone    Europe/Berlin", "Tallies     1, latest 🚽 at ", "Digest      off"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected show output to contain %q, got %q", want, out)
+		}
+	}
+	if code != 0 {
+		t.Errorf("expected success, got %d", code)
+	}
+
+	out, _, code = e.run("", "tot", "export", id)
+	if code != 0 || !strings.HasPrefix(out, `{"id":"`+id+`"`) {
+		t.Errorf("expected the tot as JSON, got %d %q", code, out)
+	}
+
+	if _, errOut, code := e.run("", "tot", "show", "missing"); code != 1 || !strings.Contains(errOut, "tot-tally tot show: ") {
+		t.Errorf("expected a missing tot to fail, got %d %q", code, errOut)
+	}
+}
+
+func TestTotImport(t *testing.T) {
+	e := newTestEnv(t)
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+	tot, _ := e.repo.LoadTot(id)
+	e.core.AddTally(tot, "2")
+	e.repo.SaveTot(tot)
+	export, _, _ := e.run("", "tot", "export", id)
+
+	// Importing into another deployment from a file.
+	other := newTestEnv(t)
+	file := filepath.Join(t.TempDir(), "tot.json")
+	os.WriteFile(file, []byte(export), 0644)
+	if out, errOut, code := other.run("", "tot", "import", file); code != 0 || out != "imported "+id+" with 1 tallies\n" {
+		t.Fatalf("expected the import to succeed, got %d %q %q", code, out, errOut)
+	}
+	imported, err := other.repo.LoadTot(id)
+	if err != nil || len(imported.Tallies) != 1 || imported.Stats.LastMilk == nil {
+		t.Fatalf("expected the imported tot with stats, got %+v (%v)", imported, err)
+	}
+
+	// An existing tot is only overwritten on request.
+	if _, errOut, code := e.run(export, "tot", "import", "-"); code != 1 || !strings.Contains(errOut, "already exists") {
+		t.Errorf("expected an existing tot to be refused, got %d %q", code, errOut)
+	}
+	if _, errOut, code := e.run(export, "tot", "import", "-replace", "-"); code != 0 {
+		t.Errorf("expected -replace to overwrite, got %d %q", code, errOut)
+	}
+
+	if _, errOut, code := e.run(`{"thresholds": []}`, "tot", "import", "-"); code != 1 || !strings.Contains(errOut, "failed to decode tot") {
+		t.Errorf("expected other JSON to be refused, got %d %q", code, errOut)
+	}
+}
+
+func TestTotDelete(t *testing.T) {
+	e := newTestEnv(t)
+	id, _ := e.core.CreateTot("👶", "UTC", "both")
+
+	if out, _, code := e.run("", "tot", "delete", id); code != 0 || out != "deleted "+id+"\n" {
+		t.Errorf("expected the tot deleted, got %d %q", code, out)
+	}
+	if _, err := e.repo.LoadTot(id); err == nil {
+		t.Error("expected the tot file to be gone")
+	}
+	if _, _, code := e.run("", "tot", "delete", id); code != 1 {
+		t.Errorf("expected deleting twice to fail, got %d", code)
+	}
+}
@@ -0,0 +1,252 @@
+// alerts.go detects overdue activities and notifies caregivers in the background.
+package alerts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/mail"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+// Kind is an activity that can become overdue.
+type Kind struct {
+	Name     string
+	Label    string
+	Category totStats.Category
+}
+
+// Kinds lists every alertable activity in display order.
+var Kinds = []Kind{
+	{Name: "feed", Label: "Feed", Category: totStats.FeedCategory},
+	{Name: "diaper", Label: "Diaper", Category: totStats.DiaperCategory},
+	{Name: "pee", Label: "Wet diaper", Category: totStats.PeeCategory},
+	{Name: "poo", Label: "Dirty diaper", Category: totStats.PooCategory},
+}
+
+// Overdue describes an activity that has not happened within its threshold.
+type Overdue struct {
+	Kind      Kind
+	Last      *time.Time // Nil when the activity was never recorded.
+	Since     time.Duration
+	Threshold time.Duration
+}
+
+// Message describes the overdue activity, e.g. "Feed overdue: last 3h 40m ago (alert after 3h 0m)".
+func (o Overdue) Message() string {
+	if o.Last == nil {
+		return fmt.Sprintf("%s overdue: none recorded in %s (alert after %s)", o.Kind.Label, totStats.FormatGap(o.Since), totStats.FormatGap(o.Threshold))
+	}
+	return fmt.Sprintf("%s overdue: last %s ago (alert after %s)", o.Kind.Label, totStats.FormatGap(o.Since), totStats.FormatGap(o.Threshold))
+}
+
+// MessageIn is Message in the given language.
+func (o Overdue) MessageIn(l *totI18n.Locale) string {
+	key := "alert.last"
+	if o.Last == nil {
+		key = "alert.none"
+	}
+	return l.T(key, l.T("alert."+o.Kind.Name), l.Duration(o.Since), l.Duration(o.Threshold))
+}
+
+// TitleIn names the tot and the overdue activity in the given language, e.g. "👶 Feed overdue".
+func (o Overdue) TitleIn(l *totI18n.Locale, name string) string {
+	return l.T("alert.title", name, l.T("alert."+o.Kind.Name))
+}
+
+// Evaluate returns the activities of the tot that are past their configured thresholds.
+// Activities that were never recorded are measured from when the tot was created.
+func Evaluate(tot *totModels.Tot, now time.Time) []Overdue {
+	var overdue []Overdue
+	for _, kind := range Kinds {
+		minutes := tot.Alerts.Thresholds[kind.Name]
+		if minutes <= 0 {
+			continue
+		}
+		threshold := time.Duration(minutes) * time.Minute
+
+		var last *time.Time
+		for i := range tot.Tallies {
+			t := tot.Tallies[i].Time
+			if kind.Category.Match(tot.Tallies[i].Kind) && (last == nil || t.After(*last)) {
+				last = t
+			}
+		}
+
+		since := now.Sub(tot.CreatedAt)
+		if last != nil {
+			since = now.Sub(*last)
+		}
+		if since > threshold {
+			overdue = append(overdue, Overdue{Kind: kind, Last: last, Since: since, Threshold: threshold})
+		}
+	}
+	return overdue
+}
+
+// hasThresholds reports whether any of the tot's alerts is enabled.
+func hasThresholds(tot *totModels.Tot) bool {
+	for _, minutes := range tot.Alerts.Thresholds {
+		if minutes > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTarget checks that a target suits the notifier: an http(s) URL for webhook and ntfy,
+// or an email address for email. URLs must be public unless allowPrivate is set; the notifiers'
+// client checks again when connecting.
+func ValidateTarget(notifier, target string, allowPrivate bool) error {
+	if _, ok := totConfig.AllowedNotifiers[notifier]; !ok {
+		return fmt.Errorf("alerts: unknown notifier %q", notifier)
+	}
+	if notifier == "email" {
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return errors.New("alerts: invalid email address")
+		}
+		return nil
+	}
+	if err := totNotify.CheckURL(target, allowPrivate); err != nil {
+		return fmt.Errorf("alerts: invalid notification URL: %w", err)
+	}
+	return nil
+}
+
+// Evaluator periodically checks every tot for overdue activities.
+type Evaluator struct {
+	config    *totConfig.Config
+	store     *totStorage.Repository
+	pool      *totShards.Pool
+	notifiers map[string]totNotify.Notifier
+
+	// Owned by the background goroutine.
+	idle map[string]totStorage.TotStamp // Tots without thresholds or flags, as of the stamp they were read at.
+}
+
+// NewEvaluator initializes the alert service.
+func NewEvaluator(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool, notifiers map[string]totNotify.Notifier) *Evaluator {
+	return &Evaluator{config: cfg, store: store, pool: pool, notifiers: notifiers, idle: map[string]totStorage.TotStamp{}}
+}
+
+// StartBackgroundEvaluator initiates a goroutine that evaluates alerts every AlertInterval.
+func (e *Evaluator) StartBackgroundEvaluator(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.config.AlertInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.evaluateAll(ctx)
+			case <-ctx.Done():
+				slog.Info("background alert evaluator stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (e *Evaluator) evaluateAll(ctx context.Context) {
+	ids, err := e.store.ListTotIDs()
+	if err != nil {
+		slog.Error("alert evaluation failed", "err", err)
+		return
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		seen[id] = true
+		// Most tots never set a threshold; skip them without locking or decoding until their file changes.
+		if stamp, err := e.store.StatTot(id); err == nil {
+			if idle, ok := e.idle[id]; ok && idle == stamp {
+				continue
+			}
+		}
+		if err := e.evaluateTot(ctx, id, time.Now()); err != nil {
+			slog.Warn("alert evaluation failed for tot", "id", id, "err", err)
+		}
+	}
+	maps.DeleteFunc(e.idle, func(id string, _ totStorage.TotStamp) bool { return !seen[id] })
+}
+
+// evaluateTot records which activities are overdue and notifies about newly overdue ones.
+// Each episode is notified once; it resets when the activity is recorded again.
+func (e *Evaluator) evaluateTot(ctx context.Context, id string, now time.Time) error {
+	mut := e.pool.GetShardMutex(id)
+	mut.Lock()
+
+	// Stamped before reading, so a save in between is noticed on the next pass.
+	stamp, stampErr := e.store.StatTot(id)
+	tot, err := e.store.LoadTot(id)
+	if err != nil {
+		mut.Unlock()
+		return err
+	}
+	delete(e.idle, id)
+	if !hasThresholds(tot) && len(tot.Alerts.Overdue) == 0 {
+		if stampErr == nil {
+			e.idle[id] = stamp
+		}
+		mut.Unlock()
+		return nil
+	}
+
+	var fresh []Overdue
+	flagged := make(map[string]time.Time)
+	for _, o := range Evaluate(tot, now) {
+		if at, ok := tot.Alerts.Overdue[o.Kind.Name]; ok {
+			flagged[o.Kind.Name] = at
+			continue
+		}
+		flagged[o.Kind.Name] = now.UTC()
+		fresh = append(fresh, o)
+	}
+
+	if !maps.EqualFunc(flagged, tot.Alerts.Overdue, time.Time.Equal) {
+		tot.Alerts.Overdue = flagged
+		if err := e.store.SaveTotWithoutActivity(tot); err != nil {
+			mut.Unlock()
+			return err
+		}
+	}
+	name, settings, unsubscribe := tot.Name, tot.Alerts, ""
+	// Notifications follow the tot's chosen language. There is no browser to ask when the tot
+	// follows each browser's, so those use the default.
+	locale := cmp.Or(totI18n.Lookup(tot.Locale), totI18n.Default)
+	if settings.Notifier == "email" {
+		// An address without a signing secret predates confirmation and was never confirmed.
+		if tot.Mail.Secret == "" {
+			settings.Target = ""
+		}
+		unsubscribe = totCore.MailLink(e.config, tot, totCore.MailAlerts, totCore.MailUnsubscribe, settings.Target)
+	}
+	mut.Unlock()
+
+	// Notify outside the lock so a slow target never blocks requests for this tot.
+	notifier, ok := e.notifiers[settings.Notifier]
+	if !ok || settings.Target == "" {
+		return nil
+	}
+	for _, o := range fresh {
+		n := totNotify.Notification{Title: o.TitleIn(locale, name), Message: o.MessageIn(locale), Unsubscribe: unsubscribe}
+		if err := notifier.Notify(ctx, settings.Target, n); err != nil {
+			slog.Warn("alert notification failed", "id", id, "kind", o.Kind.Name, "err", err)
+		}
+	}
+	return nil
+}
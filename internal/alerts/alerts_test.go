@@ -0,0 +1,301 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	totShards "tot-tally/internal/shards"
+	totStorage "tot-tally/internal/storage"
+)
+
+type fakeNotifier struct {
+	mu      sync.Mutex
+	targets []string
+	sent    []totNotify.Notification
+	err     error
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, target string, n totNotify.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets = append(f.targets, target)
+	f.sent = append(f.sent, n)
+	return f.err
+}
+
+func (f *fakeNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+func setupEvaluator(t *testing.T) (*Evaluator, *totStorage.Repository, *fakeNotifier) {
+	cfg := &totConfig.Config{TotDirectory: t.TempDir(), MaxTallies: 10, AlertInterval: 10 * time.Millisecond}
+	pool := totShards.NewPool(4)
+	repo := totStorage.NewRepository(cfg, pool)
+	fake := &fakeNotifier{}
+	return NewEvaluator(cfg, repo, pool, map[string]totNotify.Notifier{"webhook": fake, "email": fake}), repo, fake
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	milk := now.Add(-4 * time.Hour)
+	pee := now.Add(-time.Hour)
+	tot := &totModels.Tot{
+		CreatedAt: now.Add(-48 * time.Hour),
+		Tallies:   []totModels.Tally{{Time: &pee, Kind: "🚽"}, {Time: &milk, Kind: "🍼4"}},
+		Alerts: totModels.AlertSettings{
+			Thresholds: map[string]int{"feed": 180, "diaper": 120, "poo": 24 * 60},
+		},
+	}
+
+	overdue := Evaluate(tot, now)
+	if len(overdue) != 2 {
+		t.Fatalf("expected feed and poo to be overdue, got %+v", overdue)
+	}
+	if overdue[0].Kind.Name != "feed" || !overdue[0].Last.Equal(milk) || overdue[0].Since != 4*time.Hour {
+		t.Errorf("unexpected feed alert: %+v", overdue[0])
+	}
+	if overdue[1].Kind.Name != "poo" || overdue[1].Last != nil || overdue[1].Since != 48*time.Hour {
+		t.Errorf("expected poo alert measured from creation, got %+v", overdue[1])
+	}
+
+	if got := overdue[0].Message(); got != "Feed overdue: last 4h 0m ago (alert after 3h 0m)" {
+		t.Errorf("unexpected message: %s", got)
+	}
+	if got := overdue[1].Message(); got != "Dirty diaper overdue: none recorded in 48h 0m (alert after 24h 0m)" {
+		t.Errorf("unexpected message: %s", got)
+	}
+
+	tot.Alerts.Thresholds = nil
+	if overdue := Evaluate(tot, now); len(overdue) != 0 {
+		t.Errorf("expected no alerts without thresholds, got %+v", overdue)
+	}
+}
+
+func TestOverdue_MessageIn(t *testing.T) {
+	last := time.Now()
+	o := Overdue{Kind: Kinds[0], Last: &last, Since: 3*time.Hour + 5*time.Minute, Threshold: 3 * time.Hour}
+	if got, want := o.MessageIn(totI18n.English), o.Message(); got != want {
+		t.Errorf("expected English alert to match %q, got %q", want, got)
+	}
+	if got := o.MessageIn(totI18n.Spanish); !strings.Contains(got, "hace 3 h 5 min") {
+		t.Errorf("unexpected Spanish alert %q", got)
+	}
+	if got := o.TitleIn(totI18n.German, "👶"); got != "👶: Mahlzeit überfällig" {
+		t.Errorf("unexpected German title %q", got)
+	}
+
+	// Under an hour, only minutes are shown.
+	o = Overdue{Kind: Kinds[1], Since: 40 * time.Minute, Threshold: 30 * time.Minute}
+	if got, want := o.MessageIn(totI18n.English), o.Message(); got != want || !strings.Contains(got, "40m (alert after 30m)") {
+		t.Errorf("expected English alert to match %q, got %q", want, got)
+	}
+	if got := o.MessageIn(totI18n.German); !strings.Contains(got, "40 Min.") {
+		t.Errorf("unexpected German alert %q", got)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	tests := []struct {
+		notifier, target string
+		allowPrivate     bool
+		valid            bool
+	}{
+		{"ntfy", "https://ntfy.sh/tot", false, true},
+		{"webhook", "http://192.168.1.5:8123/api/webhook/tot", true, true},
+		{"webhook", "http://192.168.1.5:8123/api/webhook/tot", false, false},
+		{"webhook", "http://127.0.0.1:8080/hook", false, false},
+		{"ntfy", "http://[::1]/tot", false, false},
+		{"webhook", "http://10.0.0.8/hook", false, false},
+		{"webhook", "http://169.254.169.254/latest/meta-data/", false, false},
+		{"webhook", "http://localhost:9000/hook", false, false},
+		{"webhook", "http://[::ffff:127.0.0.1]/hook", false, false},
+		{"webhook", "ftp://example.com", false, false},
+		{"webhook", "https://", false, false},
+		{"email", "parent@example.com", false, true},
+		{"email", "Parent <parent@example.com>", false, false},
+		{"email", "not-an-email", false, false},
+		{"pager", "https://example.com", false, false},
+	}
+	for _, tc := range tests {
+		if err := ValidateTarget(tc.notifier, tc.target, tc.allowPrivate); (err == nil) != tc.valid {
+			t.Errorf("ValidateTarget(%q, %q, %v) = %v, expected valid=%v", tc.notifier, tc.target, tc.allowPrivate, err, tc.valid)
+		}
+	}
+}
+
+func TestEvaluateTot(t *testing.T) {
+	e, repo, fake := setupEvaluator(t)
+	now := time.Now()
+	updated := now.Add(-2 * time.Hour)
+	milk := now.Add(-4 * time.Hour)
+	tot := &totModels.Tot{
+		ID: "tot", Name: "👶", CreatedAt: now.Add(-24 * time.Hour), UpdatedAt: updated,
+		Tallies: []totModels.Tally{{Time: &milk, Kind: "🍼4"}},
+		Alerts: totModels.AlertSettings{
+			Thresholds: map[string]int{"feed": 180},
+			Notifier:   "webhook",
+			Target:     "https://example.com/hook",
+		},
+	}
+	if err := repo.SaveTotWithoutActivity(tot); err != nil {
+		t.Fatalf("SaveTotWithoutActivity failed: %v", err)
+	}
+
+	// Notifies once per overdue episode.
+	for range 2 {
+		if err := e.evaluateTot(context.Background(), "tot", now); err != nil {
+			t.Fatalf("evaluateTot failed: %v", err)
+		}
+	}
+	if fake.count() != 1 {
+		t.Fatalf("expected 1 notification, got %d", fake.count())
+	}
+	if fake.targets[0] != "https://example.com/hook" || !strings.Contains(fake.sent[0].Title, "Feed overdue") {
+		t.Errorf("unexpected notification: %s %+v", fake.targets[0], fake.sent[0])
+	}
+
+	loaded, _ := repo.LoadTot("tot")
+	if _, ok := loaded.Alerts.Overdue["feed"]; !ok {
+		t.Error("expected feed to be flagged as overdue")
+	}
+	if !loaded.UpdatedAt.Equal(updated) {
+		t.Errorf("expected alerts not to count as activity, UpdatedAt changed to %v", loaded.UpdatedAt)
+	}
+
+	// Recording a feed resolves the alert, so the next episode notifies again.
+	fed := now.Add(-time.Minute)
+	loaded.Tallies = append([]totModels.Tally{{Time: &fed, Kind: "🍼3"}}, loaded.Tallies...)
+	repo.SaveTotWithoutActivity(loaded)
+	if err := e.evaluateTot(context.Background(), "tot", now); err != nil {
+		t.Fatalf("evaluateTot failed: %v", err)
+	}
+	loaded, _ = repo.LoadTot("tot")
+	if len(loaded.Alerts.Overdue) != 0 {
+		t.Errorf("expected alert to be cleared, got %v", loaded.Alerts.Overdue)
+	}
+
+	fake.err = errors.New("unreachable")
+	if err := e.evaluateTot(context.Background(), "tot", now.Add(4*time.Hour)); err != nil {
+		t.Errorf("expected notification failures to be logged, got %v", err)
+	}
+	if fake.count() != 2 {
+		t.Errorf("expected a second notification, got %d", fake.count())
+	}
+
+	if err := e.evaluateTot(context.Background(), "missing", now); err == nil {
+		t.Error("expected error for missing tot, got nil")
+	}
+
+	// Notifications follow the tot's language.
+	fake.err = nil
+	repo.SaveTotWithoutActivity(&totModels.Tot{
+		ID: "es", Name: "👶", Locale: "es", CreatedAt: now.Add(-24 * time.Hour),
+		Alerts: totModels.AlertSettings{Thresholds: map[string]int{"feed": 180}, Notifier: "webhook", Target: "https://example.com/hook"},
+	})
+	if err := e.evaluateTot(context.Background(), "es", now); err != nil {
+		t.Fatalf("evaluateTot failed: %v", err)
+	}
+	if n := fake.sent[len(fake.sent)-1]; n.Title != "👶: Toma atrasado" || !strings.HasPrefix(n.Message, "Toma atrasado: ninguno registrado en 24 h 0 min") {
+		t.Errorf("expected a Spanish notification, got %+v", n)
+	}
+}
+
+func TestEvaluateTot_Email(t *testing.T) {
+	e, repo, fake := setupEvaluator(t)
+	now := time.Now()
+	emailTot := func(id string, mail totModels.MailSettings) {
+		repo.SaveTotWithoutActivity(&totModels.Tot{
+			ID: id, Name: "👶", CreatedAt: now.Add(-24 * time.Hour), Mail: mail,
+			Alerts: totModels.AlertSettings{Thresholds: map[string]int{"feed": 180}, Notifier: "email", Target: "parent@example.com"},
+		})
+		if err := e.evaluateTot(context.Background(), id, now); err != nil {
+			t.Fatalf("evaluateTot failed: %v", err)
+		}
+	}
+
+	// An address saved before confirmation existed has no signing secret, so was never confirmed.
+	emailTot("legacy", totModels.MailSettings{})
+	if fake.count() != 0 {
+		t.Fatalf("expected no mail to an unconfirmed address, got %d", fake.count())
+	}
+
+	emailTot("confirmed", totModels.MailSettings{Ref: "ref", Secret: "secret", BaseURL: "https://tally.example.com"})
+	if fake.count() != 1 {
+		t.Fatalf("expected 1 notification, got %d", fake.count())
+	}
+	if fake.targets[0] != "parent@example.com" || !strings.HasPrefix(fake.sent[0].Unsubscribe, "https://tally.example.com/m/ref/alerts/unsubscribe/") {
+		t.Errorf("expected an unsubscribe link, got %s %+v", fake.targets[0], fake.sent[0])
+	}
+}
+
+func TestEvaluateAll_SkipsIdleTots(t *testing.T) {
+	e, repo, fake := setupEvaluator(t)
+	tot := &totModels.Tot{ID: "tot", CreatedAt: time.Now().Add(-time.Hour)}
+	repo.SaveTotWithoutActivity(tot)
+	repo.SaveTotWithoutActivity(&totModels.Tot{ID: "gone"})
+
+	e.evaluateAll(context.Background())
+	if len(e.idle) != 2 {
+		t.Fatalf("expected both tots to be idle, got %v", e.idle)
+	}
+
+	// An unchanged idle tot is skipped without taking its lock.
+	repo.DeleteTot("gone")
+	mut := e.pool.GetShardMutex("tot")
+	mut.Lock()
+	done := make(chan struct{})
+	go func() {
+		e.evaluateAll(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected an idle tot to be skipped without locking")
+	}
+	mut.Unlock()
+	if _, ok := e.idle["gone"]; ok {
+		t.Error("expected a deleted tot to be forgotten")
+	}
+
+	// Saving thresholds changes the file, so the tot is evaluated again.
+	tot.Alerts = totModels.AlertSettings{Thresholds: map[string]int{"diaper": 30}, Notifier: "webhook", Target: "https://example.com/hook"}
+	repo.SaveTotWithoutActivity(tot)
+	e.evaluateAll(context.Background())
+	if _, ok := e.idle["tot"]; ok || fake.count() != 1 {
+		t.Errorf("expected the tot to be evaluated and notified, got %d notifications", fake.count())
+	}
+}
+
+func TestStartBackgroundEvaluator(t *testing.T) {
+	e, repo, fake := setupEvaluator(t)
+	repo.SaveTot(&totModels.Tot{
+		ID: "tot", CreatedAt: time.Now().Add(-time.Hour),
+		Alerts: totModels.AlertSettings{
+			Thresholds: map[string]int{"diaper": 30},
+			Notifier:   "webhook",
+			Target:     "https://example.com/hook",
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e.StartBackgroundEvaluator(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if fake.count() != 1 {
+		t.Errorf("expected the background evaluator to notify once, got %d", fake.count())
+	}
+}
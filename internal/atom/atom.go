@@ -0,0 +1,90 @@
+// atom.go writes Atom (RFC 4287) feeds of plain-text entries.
+package atom
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Feed is an Atom feed.
+type Feed struct {
+	ID      string
+	Title   string
+	Self    string // Absolute URL of the feed itself.
+	Author  string
+	Entries []Entry
+}
+
+// Entry is a single feed entry with a plain-text summary.
+type Entry struct {
+	ID      string
+	Title   string
+	Updated time.Time
+	Summary string
+}
+
+type xmlFeed struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Link    xmlLink    `xml:"link"`
+	Author  xmlAuthor  `xml:"author"`
+	Entries []xmlEntry `xml:"entry"`
+}
+
+type xmlLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type xmlAuthor struct {
+	Name string `xml:"name"`
+}
+
+type xmlEntry struct {
+	ID      string  `xml:"id"`
+	Title   string  `xml:"title"`
+	Updated string  `xml:"updated"`
+	Summary xmlText `xml:"summary"`
+}
+
+type xmlText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// Encode writes the feed, which is updated as of its newest entry, or fallback when it has none.
+// Timestamps keep their location's offset, so readers that show the raw value see local time.
+func (f Feed) Encode(w io.Writer, fallback time.Time) error {
+	updated := fallback
+	for i, e := range f.Entries {
+		if i == 0 || e.Updated.After(updated) {
+			updated = e.Updated
+		}
+	}
+
+	out := xmlFeed{
+		ID: f.ID, Title: f.Title, Updated: updated.Format(time.RFC3339),
+		Link: xmlLink{Rel: "self", Href: f.Self}, Author: xmlAuthor{Name: f.Author},
+		Entries: make([]xmlEntry, len(f.Entries)),
+	}
+	for i, e := range f.Entries {
+		out.Entries[i] = xmlEntry{
+			ID: e.ID, Title: e.Title, Updated: e.Updated.Format(time.RFC3339),
+			Summary: xmlText{Type: "text", Text: e.Summary},
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("atom: failed to write feed: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("atom: failed to encode feed: %w", err)
+	}
+	return nil
+}
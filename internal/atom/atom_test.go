@@ -0,0 +1,48 @@
+package atom
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	chicago, _ := time.LoadLocation("America/Chicago")
+	fallback := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	feed := Feed{
+		ID: "urn:tot-tally:feed:abc", Title: "Tot-Tally 👶", Self: "https://example.com/x/feed.atom", Author: "Tot-Tally",
+		Entries: []Entry{
+			{ID: "urn:tot-tally:tally:2", Title: "🚽 <b>", Updated: time.Date(2023, 10, 27, 9, 0, 0, 0, chicago), Summary: "a & b"},
+			{ID: "urn:tot-tally:tally:1", Title: "🍼4", Updated: time.Date(2023, 10, 27, 8, 0, 0, 0, chicago)},
+		},
+	}
+
+	var b strings.Builder
+	if err := feed.Encode(&b, fallback); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<updated>2023-10-27T09:00:00-05:00</updated>`,
+		`<link rel="self" href="https://example.com/x/feed.atom"></link>`,
+		`<title>🚽 &lt;b&gt;</title>`,
+		`<summary type="text">a &amp; b</summary>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	var parsed xmlFeed
+	if err := xml.Unmarshal([]byte(out), &parsed); err != nil || len(parsed.Entries) != 2 || parsed.Entries[1].ID != "urn:tot-tally:tally:1" {
+		t.Errorf("feed does not round trip: %v %+v", err, parsed)
+	}
+
+	b.Reset()
+	Feed{ID: "urn:tot-tally:feed:abc", Title: "Empty"}.Encode(&b, fallback)
+	if !strings.Contains(b.String(), "<updated>2023-10-01T00:00:00Z</updated>") {
+		t.Errorf("expected fallback updated time:\n%s", b.String())
+	}
+}
@@ -0,0 +1,387 @@
+// backup.go writes, rotates, verifies and restores tar.gz snapshots of the data directories.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totShards "tot-tally/internal/shards"
+)
+
+// Archive names are "tot-tally-<UTC time>.tar.gz", so they sort oldest first.
+const (
+	namePrefix = "tot-tally-"
+	nameTime   = "20060102T150405Z"
+	nameSuffix = ".tar.gz"
+)
+
+// Archive is a snapshot in BackupDirectory.
+type Archive struct {
+	Name string
+	Path string
+	At   time.Time
+	Size int64
+}
+
+// Contents maps each file in an archive, e.g. "tots/<id>.json", to the SHA-256 of its data.
+type Contents map[string]string
+
+// source is a data directory and where its files go in an archive.
+type source struct {
+	folder string
+	dir    string
+	key    func(name string) string // The shard key guarding a file; nil when writes aren't locked.
+}
+
+// Worker takes scheduled snapshots and restores them.
+type Worker struct {
+	config *totConfig.Config
+	pool   *totShards.Pool
+}
+
+// NewWorker initializes the backup service.
+func NewWorker(cfg *totConfig.Config, pool *totShards.Pool) *Worker {
+	return &Worker{config: cfg, pool: pool}
+}
+
+// sources lists what a snapshot holds. Links are included so restored tots keep their quick-log
+// and feed URLs; their files are replaced whole by rename, so they need no lock.
+func (w *Worker) sources() []source {
+	return []source{
+		{folder: "tots", dir: w.config.TotDirectory, key: func(name string) string { return strings.TrimSuffix(name, ".json") }},
+		{folder: "limits", dir: w.config.LimitDirectory, key: func(name string) string { return name }},
+		{folder: "links", dir: w.config.LinkDirectory},
+	}
+}
+
+// StartBackgroundBackup initiates a goroutine that takes a snapshot every BackupInterval, and at
+// startup when the newest one is older than that, then deletes those past retention.
+func (w *Worker) StartBackgroundBackup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.config.BackupInterval)
+		defer ticker.Stop()
+
+		if archives, err := w.List(); err != nil || len(archives) == 0 || time.Since(archives[0].At) >= w.config.BackupInterval {
+			w.run(time.Now())
+		}
+		for {
+			select {
+			case <-ticker.C:
+				w.run(time.Now())
+			case <-ctx.Done():
+				slog.Info("background backup stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (w *Worker) run(now time.Time) {
+	archive, contents, err := w.Snapshot(now)
+	if err != nil {
+		slog.Error("backup failed", "err", err)
+		return
+	}
+	slog.Info("backup written", "path", archive.Path, "files", len(contents), "bytes", archive.Size)
+	pruned, err := w.Prune()
+	for _, a := range pruned {
+		slog.Info("backup deleted by retention", "path", a.Path)
+	}
+	if err != nil {
+		slog.Error("backup retention failed", "err", err)
+	}
+}
+
+// Snapshot writes an archive of the data directories, reading each file under its shard lock so
+// no write is captured half done. The archive is read back and compared with what was written
+// before it takes its final name, so a listed archive has always been verified.
+func (w *Worker) Snapshot(now time.Time) (Archive, Contents, error) {
+	if err := os.MkdirAll(w.config.BackupDirectory, 0755); err != nil {
+		return Archive{}, nil, fmt.Errorf("backup: failed to create backup directory: %w", err)
+	}
+	name := namePrefix + now.UTC().Format(nameTime) + nameSuffix
+	path := filepath.Join(w.config.BackupDirectory, name)
+	tmpPath := path + ".tmp"
+
+	written, err := w.write(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return Archive{}, nil, err
+	}
+	read, err := Verify(tmpPath)
+	if err == nil && !maps.Equal(written, read) {
+		err = errors.New("backup: archive contents differ from the files written")
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return Archive{}, nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return Archive{}, nil, fmt.Errorf("backup: failed to swap archive: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Archive{}, nil, fmt.Errorf("backup: failed to stat archive: %w", err)
+	}
+	return Archive{Name: name, Path: path, At: now.UTC().Truncate(time.Second), Size: info.Size()}, written, nil
+}
+
+func (w *Worker) write(path string) (Contents, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("backup: failed to create archive: %w", err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	contents := Contents{}
+	for _, src := range w.sources() {
+		entries, err := os.ReadDir(src.dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: failed to read %s: %w", src.dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+				continue
+			}
+			data, modTime, err := w.readFile(src, entry.Name())
+			if os.IsNotExist(err) {
+				continue // Deleted since the directory was listed.
+			}
+			if err != nil {
+				return nil, fmt.Errorf("backup: failed to read %s: %w", entry.Name(), err)
+			}
+			name := src.folder + "/" + entry.Name()
+			hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return nil, fmt.Errorf("backup: failed to write archive: %w", err)
+			}
+			if _, err := tw.Write(data); err != nil {
+				return nil, fmt.Errorf("backup: failed to write archive: %w", err)
+			}
+			contents[name] = hash(data)
+		}
+	}
+
+	if err := errors.Join(tw.Close(), gz.Close(), file.Sync()); err != nil {
+		return nil, fmt.Errorf("backup: failed to finish archive: %w", err)
+	}
+	return contents, nil
+}
+
+func (w *Worker) readFile(src source, name string) ([]byte, time.Time, error) {
+	if src.key != nil {
+		mut := w.pool.GetShardMutex(src.key(name))
+		mut.Lock()
+		defer mut.Unlock()
+	}
+	path := filepath.Join(src.dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	return data, info.ModTime(), err
+}
+
+// Verify reads a whole archive, checking its compression checksums, that it holds only data
+// files, and that every tot in it is valid JSON. It returns what the archive holds.
+func Verify(path string) (Contents, error) {
+	contents := Contents{}
+	err := walk(path, func(folder, name string, data []byte) error {
+		if folder == "tots" && !json.Valid(data) {
+			return fmt.Errorf("tots/%s is not valid JSON", name)
+		}
+		contents[folder+"/"+name] = hash(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// walk calls fn with each file in an archive, rejecting entries outside the data folders.
+func walk(path string, fn func(folder, name string, data []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("backup: failed to open archive: %w", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("backup: failed to read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	folders := []string{"tots", "limits", "links"}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("backup: failed to read archive: %w", err)
+		}
+		folder, name, ok := strings.Cut(hdr.Name, "/")
+		if !ok || !slices.Contains(folders, folder) || hdr.Typeflag != tar.TypeReg || name != filepath.Base(name) || name == "." || name == ".." {
+			return fmt.Errorf("backup: unexpected entry %q in archive", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("backup: failed to read %s: %w", hdr.Name, err)
+		}
+		if err := fn(folder, name, data); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+	}
+	// Reading to the end of the gzip stream checks its CRC.
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return fmt.Errorf("backup: failed to read archive: %w", err)
+	}
+	return nil
+}
+
+// List returns the archives in BackupDirectory, newest first.
+func (w *Worker) List() ([]Archive, error) {
+	entries, err := os.ReadDir(w.config.BackupDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("backup: failed to read backup directory: %w", err)
+	}
+
+	var archives []Archive
+	for _, entry := range slices.Backward(entries) {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(entry.Name(), namePrefix), nameSuffix)
+		at, err := time.Parse(nameTime, stamp)
+		if err != nil || entry.IsDir() {
+			continue // Not an archive, e.g. one still being written.
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		archives = append(archives, Archive{Name: entry.Name(), Path: filepath.Join(w.config.BackupDirectory, entry.Name()), At: at, Size: info.Size()})
+	}
+	return archives, nil
+}
+
+// Prune deletes the archives past retention and returns them. The newest archive of each of the
+// BackupKeepDaily most recent days, and of each of the BackupKeepWeekly most recent ISO weeks, is
+// kept, as is the newest archive overall. Days and weeks are in UTC.
+func (w *Worker) Prune() ([]Archive, error) {
+	archives, err := w.List()
+	if err != nil {
+		return nil, err
+	}
+
+	days, weeks := map[string]bool{}, map[string]bool{}
+	var pruned []Archive
+	var errs []error
+	for i, a := range archives {
+		keep := i == 0
+		if day := a.At.Format(time.DateOnly); !days[day] && len(days) < w.config.BackupKeepDaily {
+			days[day] = true
+			keep = true
+		}
+		year, week := a.At.ISOWeek()
+		if key := fmt.Sprintf("%d-W%02d", year, week); !weeks[key] && len(weeks) < w.config.BackupKeepWeekly {
+			weeks[key] = true
+			keep = true
+		}
+		if keep {
+			continue
+		}
+		if err := os.Remove(a.Path); err != nil {
+			errs = append(errs, fmt.Errorf("backup: failed to delete %s: %w", a.Name, err))
+			continue
+		}
+		pruned = append(pruned, a)
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// Restored counts what a restore did.
+type Restored struct {
+	Files   int
+	Skipped int // Files already present, kept because replace wasn't set.
+}
+
+// Restore verifies an archive, then writes its files back into the data directories, each under
+// its shard lock and atomically. Existing files are only overwritten when replace is set.
+func (w *Worker) Restore(path string, replace bool) (Restored, error) {
+	if _, err := Verify(path); err != nil {
+		return Restored{}, err
+	}
+	sources := map[string]source{}
+	for _, src := range w.sources() {
+		sources[src.folder] = src
+	}
+
+	var result Restored
+	err := walk(path, func(folder, name string, data []byte) error {
+		restored, err := w.restoreFile(sources[folder], name, data, replace)
+		if err != nil {
+			return err
+		}
+		if restored {
+			result.Files++
+		} else {
+			result.Skipped++
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (w *Worker) restoreFile(src source, name string, data []byte, replace bool) (bool, error) {
+	if src.key != nil {
+		mut := w.pool.GetShardMutex(src.key(name))
+		mut.Lock()
+		defer mut.Unlock()
+	}
+	if err := os.MkdirAll(src.dir, 0755); err != nil {
+		return false, err
+	}
+	finalPath := filepath.Join(src.dir, name)
+	if _, err := os.Stat(finalPath); err == nil && !replace {
+		return false, nil
+	}
+
+	tmpPath := finalPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("failed to swap %s: %w", finalPath, err)
+	}
+	return true, nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totShards "tot-tally/internal/shards"
+)
+
+func setupWorker(t *testing.T) (*Worker, *totConfig.Config) {
+	dir := t.TempDir()
+	cfg := totConfig.NewDefaultConfig()
+	cfg.TotDirectory = filepath.Join(dir, "tots")
+	cfg.LimitDirectory = filepath.Join(dir, "limits")
+	cfg.LinkDirectory = filepath.Join(dir, "links")
+	cfg.BackupDirectory = filepath.Join(dir, "backups")
+	for _, d := range []string{cfg.TotDirectory, cfg.LimitDirectory, cfg.LinkDirectory} {
+		os.MkdirAll(d, 0755)
+	}
+	return NewWorker(cfg, totShards.NewPool(4)), cfg
+}
+
+func writeData(cfg *totConfig.Config) {
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "a.json"), []byte(`{"id":"a"}`), 0644)
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "b.json"), []byte(`{"id":"b"}`), 0644)
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "b.json.tmp"), []byte(`{"id":`), 0644)
+	os.WriteFile(filepath.Join(cfg.LimitDirectory, "abc"), []byte("1\n1700000000000"), 0644)
+	os.WriteFile(filepath.Join(cfg.LinkDirectory, "def"), []byte("a"), 0644)
+}
+
+func TestSnapshotAndVerify(t *testing.T) {
+	w, cfg := setupWorker(t)
+	writeData(cfg)
+
+	now := time.Date(2026, 10, 19, 14, 30, 5, 0, time.UTC)
+	archive, contents, err := w.Snapshot(now)
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if archive.Name != "tot-tally-20261019T143005Z.tar.gz" || !archive.At.Equal(now) || archive.Size == 0 {
+		t.Errorf("unexpected archive %+v", archive)
+	}
+	if len(contents) != 4 || contents["tots/b.json"] == "" || contents["limits/abc"] == "" || contents["links/def"] == "" {
+		t.Errorf("expected the data files without the .tmp file, got %v", contents)
+	}
+
+	read, err := Verify(archive.Path)
+	if err != nil || len(read) != 4 || read["tots/a.json"] != contents["tots/a.json"] {
+		t.Errorf("expected the archive to verify, got %v (%v)", read, err)
+	}
+	if entries, _ := os.ReadDir(cfg.BackupDirectory); len(entries) != 1 {
+		t.Errorf("expected only the archive in the backup directory, got %v", entries)
+	}
+}
+
+func TestSnapshot_WaitsForShardLock(t *testing.T) {
+	w, cfg := setupWorker(t)
+	writeData(cfg)
+
+	mut := w.pool.GetShardMutex("a")
+	mut.Lock()
+	done := make(chan error)
+	go func() {
+		_, _, err := w.Snapshot(time.Now())
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the snapshot to wait for a tot being written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	mut.Unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+}
+
+func TestVerify_Damaged(t *testing.T) {
+	w, cfg := setupWorker(t)
+	writeData(cfg)
+	archive, _, _ := w.Snapshot(time.Now())
+
+	data, _ := os.ReadFile(archive.Path)
+	truncated := filepath.Join(t.TempDir(), "truncated.tar.gz")
+	os.WriteFile(truncated, data[:len(data)-10], 0644)
+	if _, err := Verify(truncated); err == nil {
+		t.Error("expected a truncated archive to fail")
+	}
+
+	write := func(name, body string) string {
+		path := filepath.Join(t.TempDir(), "bad.tar.gz")
+		f, _ := os.Create(path)
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(body))})
+		tw.Write([]byte(body))
+		tw.Close()
+		gz.Close()
+		f.Close()
+		return path
+	}
+	for _, name := range []string{"tots/../../escape", "../escape", "other/file", "tots/sub/file"} {
+		if _, err := Verify(write(name, "{}")); err == nil || !strings.Contains(err.Error(), "unexpected entry") {
+			t.Errorf("%s: expected the entry to be rejected, got %v", name, err)
+		}
+	}
+	if _, err := Verify(write("tots/x.json", "{")); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("expected an invalid tot to fail, got %v", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	w, cfg := setupWorker(t)
+	writeData(cfg)
+	archive, _, _ := w.Snapshot(time.Now())
+
+	os.Remove(filepath.Join(cfg.TotDirectory, "a.json"))
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "b.json"), []byte(`{"id":"b","name":"newer"}`), 0644)
+	os.RemoveAll(cfg.LinkDirectory)
+
+	result, err := w.Restore(archive.Path, false)
+	if err != nil || result.Files != 2 || result.Skipped != 2 {
+		t.Fatalf("expected the missing files restored, got %+v (%v)", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.TotDirectory, "a.json")); string(data) != `{"id":"a"}` {
+		t.Errorf("expected the deleted tot back, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.LinkDirectory, "def")); string(data) != "a" {
+		t.Errorf("expected the link back, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.TotDirectory, "b.json")); !strings.Contains(string(data), "newer") {
+		t.Errorf("expected an existing tot kept, got %q", data)
+	}
+
+	if result, err := w.Restore(archive.Path, true); err != nil || result.Files != 4 {
+		t.Fatalf("expected every file restored, got %+v (%v)", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.TotDirectory, "b.json")); string(data) != `{"id":"b"}` {
+		t.Errorf("expected the tot replaced, got %q", data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	w, cfg := setupWorker(t)
+	cfg.BackupKeepDaily = 2
+	cfg.BackupKeepWeekly = 2
+	os.MkdirAll(cfg.BackupDirectory, 0755)
+
+	// Twice a day for three weeks, ending on Monday 2026-10-19.
+	end := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	for at := end; at.After(end.AddDate(0, 0, -21)); at = at.Add(-12 * time.Hour) {
+		os.WriteFile(filepath.Join(cfg.BackupDirectory, "tot-tally-"+at.Format(nameTime)+".tar.gz"), nil, 0644)
+	}
+	os.WriteFile(filepath.Join(cfg.BackupDirectory, "notes.txt"), nil, 0644)
+
+	if _, err := w.Prune(); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	archives, _ := w.List()
+	var kept []string
+	for _, a := range archives {
+		kept = append(kept, a.At.Format("Jan 02 15h"))
+	}
+	// The newest of today and yesterday, and of this week and last week (Sunday evening).
+	if got, want := strings.Join(kept, ", "), "Oct 19 18h, Oct 18 18h"; got != want {
+		t.Errorf("expected %s kept, got %s", want, got)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.BackupDirectory, "notes.txt")); err != nil {
+		t.Error("expected other files left alone")
+	}
+
+	cfg.BackupKeepDaily, cfg.BackupKeepWeekly = 0, 0
+	w.Prune()
+	if archives, _ := w.List(); len(archives) != 1 || !archives[0].At.Equal(end) {
+		t.Errorf("expected the newest archive always kept, got %v", archives)
+	}
+}
@@ -0,0 +1,124 @@
+// charts.go renders small, dependency-free SVG charts for inlining into server-rendered pages.
+package charts
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Chart dimensions in SVG user units. Charts scale to their container via the viewBox.
+const (
+	width         = 300
+	barHeight     = 120
+	timelineH     = 48
+	labelHeight   = 14
+	valueHeight   = 12
+	minBarSpacing = 2
+)
+
+// Series is one set of bar values, drawn with a CSS class so page styles control colors.
+type Series struct {
+	Name   string
+	Class  string
+	Values []int // Indexed like the chart labels.
+}
+
+// Event is a point on a timeline strip.
+type Event struct {
+	At    time.Time
+	Label string
+	Class string
+}
+
+// BarChart renders grouped bars, one group per label, scaled to the largest value.
+func BarChart(title string, labels []string, series []Series) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s" xmlns="http://www.w3.org/2000/svg">`,
+		width, barHeight, template.HTMLEscapeString(title))
+	fmt.Fprintf(&b, `<title>%s</title>`, template.HTMLEscapeString(title))
+
+	maxVal := 0
+	for _, s := range series {
+		for _, v := range s.Values {
+			maxVal = max(maxVal, v)
+		}
+	}
+
+	plotTop, plotBottom := valueHeight, barHeight-labelHeight
+	groupWidth := float64(width) / float64(max(len(labels), 1))
+	barWidth := (groupWidth - 2*minBarSpacing) / float64(max(len(series), 1))
+
+	for i, label := range labels {
+		groupX := float64(i) * groupWidth
+		for j, s := range series {
+			v := 0
+			if i < len(s.Values) {
+				v = s.Values[i]
+			}
+			h := 0.0
+			if maxVal > 0 {
+				h = float64(v) / float64(maxVal) * float64(plotBottom-plotTop)
+			}
+			x := groupX + minBarSpacing + float64(j)*barWidth
+			y := float64(plotBottom) - h
+			fmt.Fprintf(&b, `<rect class="%s" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s %s: %d</title></rect>`,
+				template.HTMLEscapeString(s.Class), x, y, barWidth-1, h,
+				template.HTMLEscapeString(label), template.HTMLEscapeString(s.Name), v)
+			if v > 0 {
+				fmt.Fprintf(&b, `<text class="chart-value" x="%.1f" y="%.1f" text-anchor="middle">%d</text>`,
+					x+(barWidth-1)/2, y-2, v)
+			}
+		}
+		fmt.Fprintf(&b, `<text class="chart-label" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			groupX+groupWidth/2, barHeight-2, template.HTMLEscapeString(label))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// Timeline renders a horizontal strip from start to end with a tick for each event.
+// Hour marks are drawn every six hours in the location of start.
+func Timeline(title string, start, end time.Time, events []Event) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s" xmlns="http://www.w3.org/2000/svg">`,
+		width, timelineH, template.HTMLEscapeString(title))
+	fmt.Fprintf(&b, `<title>%s</title>`, template.HTMLEscapeString(title))
+
+	span := end.Sub(start)
+	xOf := func(t time.Time) float64 {
+		if span <= 0 {
+			return 0
+		}
+		return float64(t.Sub(start)) / float64(span) * width
+	}
+
+	stripBottom := timelineH - labelHeight
+	fmt.Fprintf(&b, `<rect class="chart-strip" x="0" y="0" width="%d" height="%d"></rect>`, width, stripBottom)
+
+	mark := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, start.Location())
+	for mark.Hour()%6 != 0 || mark.Before(start) {
+		mark = mark.Add(time.Hour)
+	}
+	for ; !mark.After(end); mark = mark.Add(6 * time.Hour) {
+		x := xOf(mark)
+		fmt.Fprintf(&b, `<line class="chart-grid" x1="%.1f" y1="0" x2="%.1f" y2="%d"></line>`, x, x, stripBottom)
+		fmt.Fprintf(&b, `<text class="chart-label" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x, timelineH-2, mark.Format("3PM"))
+	}
+
+	for _, e := range events {
+		if e.At.Before(start) || e.At.After(end) {
+			continue
+		}
+		x := xOf(e.At)
+		fmt.Fprintf(&b, `<rect class="%s" x="%.1f" y="2" width="3" height="%d"><title>%s %s</title></rect>`,
+			template.HTMLEscapeString(e.Class), x-1.5, stripBottom-4,
+			template.HTMLEscapeString(e.Label), e.At.Format("3:04PM"))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
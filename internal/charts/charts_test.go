@@ -0,0 +1,60 @@
+package charts
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with current output")
+
+// checkGolden compares output against testdata/<name>.golden.svg.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden.svg")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match %s (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	labels := []string{"Mon", "Tue", "Wed"}
+	series := []Series{
+		{Name: "🚽", Class: "chart-pee", Values: []int{4, 8, 0}},
+		{Name: "💩", Class: "chart-poo", Values: []int{2, 1, 0}},
+	}
+	checkGolden(t, "bar_grouped", string(BarChart("Diapers per day", labels, series)))
+}
+
+func TestBarChart_Empty(t *testing.T) {
+	out := string(BarChart("Milk <oz>", nil, nil))
+	checkGolden(t, "bar_empty", out)
+	if strings.Contains(out, "<oz>") {
+		t.Error("expected title to be escaped")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	tz := time.FixedZone("Test", -5*3600)
+	end := time.Date(2023, 10, 27, 12, 30, 0, 0, tz)
+	start := end.Add(-24 * time.Hour)
+
+	events := []Event{
+		{At: end.Add(-1 * time.Hour), Label: "🍼4", Class: "chart-milk"},
+		{At: end.Add(-12 * time.Hour), Label: "🚽", Class: "chart-pee"},
+		{At: end.Add(-25 * time.Hour), Label: "💩", Class: "chart-poo"}, // Outside the strip.
+	}
+	checkGolden(t, "timeline", string(Timeline("Last 24 hours", start, end, events)))
+}
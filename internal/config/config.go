@@ -9,26 +9,121 @@ type Config struct {
 	NumShards      int
 	TotDirectory   string
 	LimitDirectory string
-	MaxTallies     int
-	MaxTotsPerIP   int
-	TimeFormat     string
-	CleanupAge     time.Duration
+	LinkDirectory  string
+	// QuarantineDirectory keeps the damaged data files cleanup and repair move aside, so none is lost.
+	QuarantineDirectory string
+	MaxTallies          int
+	// MaxJournalEntries is how many sleep, medication, growth and note entries a tot keeps.
+	MaxJournalEntries int
+	MaxTotsPerIP      int
+	TimeFormat        string
+	// AssetsDir serves templates and static files from a directory, e.g. "assets" while developing,
+	// instead of the copies embedded in the binary.
+	AssetsDir     string
+	CleanupAge    time.Duration
+	AlertInterval time.Duration
+	NotifyTimeout time.Duration
+	// AllowPrivateTargets lets alert and webhook URLs reach loopback and private addresses, e.g. an
+	// ntfy server on the LAN. It is off by default, since anyone with a tot's link can set them.
+	AllowPrivateTargets bool
+	SMTPAddr            string // host:port of the mail relay; empty disables email.
+	SMTPFrom            string
+	SMTPUsername        string
+	SMTPPassword        string
+	// PublicURL is the scheme and host used for links in emails, e.g. "https://tally.example.com".
+	// Empty uses the host each address was entered on.
+	PublicURL      string
+	MaxWebhooks    int
+	WebhookLogSize int
+	WebhookRetries int           // Attempts after the first before a delivery is given up.
+	WebhookBackoff time.Duration // Delay before the first retry, doubling for each one after.
+	WebhookQueue   int
+	// QuickLogDebounce ignores a repeated quick-log of the same kind, e.g. from a link preview.
+	QuickLogDebounce time.Duration
+	// CalendarDays is how many days of history the calendar feed includes.
+	CalendarDays int
+	// MaxSummaryDays limits the date range of a visit summary.
+	MaxSummaryDays int
+	// FeedEntries is how many of the most recent tallies the Atom feed lists.
+	FeedEntries int
+	// NurseSessionGap is the longest gap between nursing tallies shown as one calendar session.
+	NurseSessionGap time.Duration
+	// DigestInterval is how often tots are checked for a daily digest to send.
+	DigestInterval time.Duration
+	// DigestHour is the local hour after which the previous day's digest is sent.
+	DigestHour int
+	// MQTTAddr is the host:port of an MQTT broker to publish tot state to; empty disables MQTT.
+	MQTTAddr     string
+	MQTTUsername string
+	MQTTPassword string
+	MQTTClientID string
+	// MQTTTopicPrefix starts every state topic, e.g. "tot-tally/<tot>/last_milk".
+	MQTTTopicPrefix string
+	// MQTTDiscoveryPrefix is where Home Assistant looks for discovery payloads; empty disables them.
+	MQTTDiscoveryPrefix string
+	// MQTTInterval is how often every tot's state is republished, so daily totals reset overnight.
+	MQTTInterval  time.Duration
+	MQTTKeepAlive time.Duration
+	MQTTQueue     int
+	// BackupDirectory is where snapshots of the data directories are written; empty disables backups.
+	BackupDirectory string
+	// BackupInterval is how often a snapshot is taken.
+	BackupInterval time.Duration
+	// BackupKeepDaily and BackupKeepWeekly are how many of the most recent days and weeks keep their
+	// newest snapshot. Older snapshots are deleted, though the newest is always kept.
+	BackupKeepDaily  int
+	BackupKeepWeekly int
 }
 
 // NewDefaultConfig returns a standard configuration for the application.
 func NewDefaultConfig() *Config {
 	return &Config{
-		Port:           ":5000",
-		NumShards:      4096,
-		TotDirectory:   "tots",
-		LimitDirectory: "limits",
-		MaxTallies:     100,
-		MaxTotsPerIP:   10,
-		TimeFormat:     "02 Jan 03:04PM",
-		CleanupAge:     180 * 24 * time.Hour,
+		Port:                ":5000",
+		NumShards:           4096,
+		TotDirectory:        "tots",
+		LimitDirectory:      "limits",
+		LinkDirectory:       "links",
+		QuarantineDirectory: "quarantine",
+		MaxTallies:          100,
+		MaxJournalEntries:   500,
+		MaxTotsPerIP:        10,
+		TimeFormat:          "02 Jan 03:04PM",
+		CleanupAge:          180 * 24 * time.Hour,
+		AlertInterval:       time.Minute,
+		NotifyTimeout:       10 * time.Second,
+		SMTPFrom:            "tot-tally@localhost",
+		MaxWebhooks:         5,
+		WebhookLogSize:      20,
+		WebhookRetries:      4,
+		WebhookBackoff:      5 * time.Second,
+		WebhookQueue:        256,
+		QuickLogDebounce:    30 * time.Second,
+		CalendarDays:        14,
+		FeedEntries:         50,
+		MaxSummaryDays:      92,
+		NurseSessionGap:     30 * time.Minute,
+		DigestInterval:      15 * time.Minute,
+		DigestHour:          7,
+		MQTTClientID:        "tot-tally",
+		MQTTTopicPrefix:     "tot-tally",
+		MQTTDiscoveryPrefix: "homeassistant",
+		MQTTInterval:        5 * time.Minute,
+		MQTTKeepAlive:       time.Minute,
+		MQTTQueue:           256,
+		BackupInterval:      24 * time.Hour,
+		BackupKeepDaily:     7,
+		BackupKeepWeekly:    4,
 	}
 }
 
+// Kinds of journal entry, for what tallies can't hold.
+const (
+	JournalSleep      = "sleep"
+	JournalMedication = "medication"
+	JournalGrowth     = "growth"
+	JournalNote       = "note"
+)
+
 var (
 	// TallyKindMap defines the relationship between form IDs and emoji storage strings.
 	TallyKindMap = map[int64]string{
@@ -47,21 +142,15 @@ var (
 		"bottle": {}, "nursing": {}, "both": {},
 	}
 
-	AllowedTimezones = map[string]struct{}{
-		"Pacific/Honolulu": {}, "America/Anchorage": {}, "America/Los_Angeles": {},
-		"America/Boise": {}, "America/Denver": {}, "America/Phoenix": {},
-		"America/Chicago": {}, "America/Detroit": {}, "America/New_York": {},
+	AllowedNotifiers = map[string]struct{}{
+		"webhook": {}, "ntfy": {}, "email": {},
 	}
 
-	// FlashMessages maps cookie keys to user-visible toast notifications.
-	FlashMessages = map[string]string{
-		"tally":            "Tally Added!",
-		"undo":             "Tally Undone",
-		"updated":          "Settings Updated",
-		"deleted":          "Tot Deleted",
-		"error_limit":      "Error: Too many requests!",
-		"error_limit_ip":   "Error: Tot limit reached for this IP!",
-		"error_not_found":  "Error: Tot not found!",
-		"error_unexpected": "Error: Unexpected error!",
+	// JournalKinds lists the kinds of journal entry in the order they are offered.
+	JournalKinds = []string{JournalSleep, JournalMedication, JournalGrowth, JournalNote}
+
+	// ReportRanges maps report range names to the number of days they cover.
+	ReportRanges = map[string]int{
+		"week": 7, "month": 30,
 	}
 )
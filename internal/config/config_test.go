@@ -20,9 +20,3 @@ func TestTallyKindMap(t *testing.T) {
 		t.Errorf("expected 🍼1, got %s", TallyKindMap[1])
 	}
 }
-
-func TestFlashMessages(t *testing.T) {
-	if FlashMessages["tally"] == "" {
-		t.Error("FlashMessages['tally'] is empty")
-	}
-}
@@ -0,0 +1,95 @@
+//go:build ignore
+
+// gen_timezones.go writes timezones_gen.go from the tz database's zone.tab, which lists one or more
+// zones per country. Run it with go generate after updating the system tzdata.
+//
+// Usage:
+//
+//	go run gen_timezones.go [path/to/zone.tab]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// suggested picks the zone for countries whose first zone.tab row is not their most populous zone.
+var suggested = map[string]string{
+	"AU": "Australia/Sydney",
+	"BR": "America/Sao_Paulo",
+	"CA": "America/Toronto",
+	"KZ": "Asia/Almaty",
+	"MX": "America/Mexico_City",
+	"RU": "Europe/Moscow",
+	"US": "America/Chicago",
+}
+
+func main() {
+	path := "/usr/share/zoneinfo/zone.tab"
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	zones := []string{"UTC"}
+	countries := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) < 3 {
+			log.Fatalf("malformed line %q", line)
+		}
+		code, zone := cols[0], cols[2]
+		zones = append(zones, zone)
+		if _, ok := countries[code]; !ok {
+			countries[code] = zone
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	for code, zone := range suggested {
+		countries[code] = zone
+	}
+	slices.Sort(zones)
+	zones = slices.Compact(zones)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_timezones.go; DO NOT EDIT.\n\npackage config\n\n")
+	b.WriteString("// Timezones lists the IANA timezones offered in the timezone selectors, sorted.\nvar Timezones = []string{\n")
+	for _, z := range zones {
+		fmt.Fprintf(&b, "%q,\n", z)
+	}
+	b.WriteString("}\n\n// CountryTimezones maps ISO 3166 country codes to the timezone suggested for them.\nvar CountryTimezones = map[string]string{\n")
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "%q: %q,\n", code, countries[code])
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("timezones_gen.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
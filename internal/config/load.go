@@ -0,0 +1,256 @@
+// load.go layers settings from a config file, environment variables and command line flags over
+// the defaults. Every Config field is a setting: "MaxTallies" is the file key, TOT_TALLY_MAX_TALLIES
+// the environment variable and -max-tallies the flag.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix starts the environment variable of every setting.
+const EnvPrefix = "TOT_TALLY_"
+
+// ConfigFlag names the flag, and with EnvPrefix the environment variable, holding the config file path.
+const ConfigFlag = "config"
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// secretFields are masked by Print.
+var secretFields = map[string]bool{"SMTPPassword": true, "MQTTPassword": true}
+
+// NewFlagSet returns a flag set with -config and a flag for every setting. Flags left unset do
+// not override other sources, so their defaults are shown in the usage only.
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.String(ConfigFlag, "", "path to a JSON config file (env "+EnvPrefix+"CONFIG)")
+
+	defaults := reflect.ValueOf(NewDefaultConfig()).Elem()
+	for i, field := range reflect.VisibleFields(defaults.Type()) {
+		fs.String(flagName(field.Name), formatValue(defaults.Field(i)), "sets "+field.Name+" (env "+envName(field.Name)+")")
+	}
+	return fs
+}
+
+// Load builds the configuration from the defaults, then the config file, then environment
+// variables, then the flags set in fs, which must already be parsed. The result is validated.
+func Load(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := NewDefaultConfig()
+	v := reflect.ValueOf(cfg).Elem()
+
+	path, _ := lookupEnv(EnvPrefix + "CONFIG")
+	if isSet(fs, ConfigFlag) {
+		path = fs.Lookup(ConfigFlag).Value.String()
+	}
+	if path != "" {
+		if err := loadFile(v, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, field := range reflect.VisibleFields(v.Type()) {
+		if raw, ok := lookupEnv(envName(field.Name)); ok {
+			if err := setValue(v.Field(i), raw); err != nil {
+				return nil, fmt.Errorf("config: invalid %s: %w", envName(field.Name), err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil || f.Name == ConfigFlag {
+			return
+		}
+		for i, field := range reflect.VisibleFields(v.Type()) {
+			if flagName(field.Name) == f.Name {
+				if setErr := setValue(v.Field(i), f.Value.String()); setErr != nil {
+					err = fmt.Errorf("config: invalid -%s: %w", f.Name, setErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile applies a JSON object of settings. Values may be JSON strings, numbers or
+// booleans; durations are strings such as "90s". Unknown keys are rejected to catch typos.
+func loadFile(v reflect.Value, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: failed to read config file: %w", err)
+	}
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("config: failed to parse %s: %w", path, err)
+	}
+
+	for key, raw := range settings {
+		field := v.FieldByName(key)
+		if !field.IsValid() {
+			return fmt.Errorf("config: unknown setting %q in %s", key, path)
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+		}
+		if err := setValue(field, s); err != nil {
+			return fmt.Errorf("config: invalid %s in %s: %w", key, path, err)
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+func formatValue(field reflect.Value) string {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+// Print writes the configuration as a config file, with passwords masked.
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(c).Elem()
+	var b strings.Builder
+	b.WriteString("{\n")
+	fields := reflect.VisibleFields(v.Type())
+	for i, field := range fields {
+		value := formatValue(v.Field(i))
+		if secretFields[field.Name] && value != "" {
+			value = "********"
+		}
+		encoded, _ := json.Marshal(value)
+		if field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Bool {
+			encoded = []byte(value)
+		}
+		fmt.Fprintf(&b, "  %q: %s", field.Name, encoded)
+		if i < len(fields)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: "+format, args...))
+		}
+	}
+
+	check(strings.Contains(c.Port, ":"), "Port %q must be an address such as \":5000\"", c.Port)
+	check(c.NumShards > 0, "NumShards must be positive")
+	check(c.TotDirectory != "" && c.LimitDirectory != "" && c.LinkDirectory != "" && c.QuarantineDirectory != "", "directories must not be empty")
+	check(c.MaxTallies > 0, "MaxTallies must be positive")
+	check(c.MaxJournalEntries > 0, "MaxJournalEntries must be positive")
+	check(c.MaxTotsPerIP > 0, "MaxTotsPerIP must be positive")
+	check(c.TimeFormat != "", "TimeFormat must not be empty")
+	if c.AssetsDir != "" {
+		_, err := os.Stat(filepath.Join(c.AssetsDir, "index.html"))
+		check(err == nil, "AssetsDir %q must hold the templates, e.g. index.html", c.AssetsDir)
+	}
+	check(c.MaxWebhooks >= 0 && c.WebhookLogSize >= 0 && c.WebhookRetries >= 0, "webhook limits must not be negative")
+	check(c.WebhookQueue > 0 && c.MQTTQueue > 0, "queue sizes must be positive")
+	check(c.CalendarDays > 0 && c.FeedEntries > 0 && c.MaxSummaryDays > 0, "feed and summary sizes must be positive")
+	check(c.DigestHour >= 0 && c.DigestHour < 24, "DigestHour must be between 0 and 23")
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"CleanupAge", c.CleanupAge}, {"AlertInterval", c.AlertInterval}, {"NotifyTimeout", c.NotifyTimeout},
+		{"WebhookBackoff", c.WebhookBackoff}, {"DigestInterval", c.DigestInterval}, {"MQTTInterval", c.MQTTInterval},
+		{"BackupInterval", c.BackupInterval},
+	} {
+		check(d.value > 0, "%s must be positive", d.name)
+	}
+	check(c.QuickLogDebounce >= 0 && c.NurseSessionGap >= 0 && c.MQTTKeepAlive >= 0, "durations must not be negative")
+	check(c.SMTPAddr == "" || c.SMTPFrom != "", "SMTPFrom is required with SMTPAddr")
+	if c.PublicURL != "" {
+		u, err := url.Parse(c.PublicURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && strings.Trim(u.Path, "/") == "",
+			"PublicURL %q must be a scheme and host such as \"https://tally.example.com\"", c.PublicURL)
+	}
+	check(c.BackupKeepDaily >= 0 && c.BackupKeepWeekly >= 0, "backup retention must not be negative")
+	check(c.MQTTAddr == "" || (c.MQTTTopicPrefix != "" && c.MQTTClientID != ""), "MQTTTopicPrefix and MQTTClientID are required with MQTTAddr")
+	return errors.Join(errs...)
+}
+
+// words splits a field name such as "MQTTClientID" into "MQTT", "Client" and "ID".
+func words(name string) []string {
+	runes := []rune(name)
+	var out []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower)) {
+			out = append(out, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(out, string(runes[start:]))
+}
+
+func envName(field string) string {
+	return EnvPrefix + strings.ToUpper(strings.Join(words(field), "_"))
+}
+
+func flagName(field string) string {
+	return strings.ToLower(strings.Join(words(field), "-"))
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadWith(t *testing.T, args []string, env map[string]string) (*Config, error) {
+	t.Helper()
+	fs := NewFlagSet("test")
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return Load(fs, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+func TestLoad_Layers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"Port": ":6000", "MaxTallies": 200, "CleanupAge": "720h", "TimeFormat": "15:04"}`), 0644)
+
+	cfg, err := loadWith(t, []string{"-config", path, "-max-tallies", "300"}, map[string]string{
+		"TOT_TALLY_PORT": ":7000", "TOT_TALLY_MAX_TALLIES": "250", "TOT_TALLY_SMTP_ADDR": "mail:25",
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.TimeFormat != "15:04" || cfg.CleanupAge != 720*time.Hour {
+		t.Errorf("expected file settings, got %q %v", cfg.TimeFormat, cfg.CleanupAge)
+	}
+	if cfg.Port != ":7000" || cfg.SMTPAddr != "mail:25" {
+		t.Errorf("expected environment to override the file, got %q %q", cfg.Port, cfg.SMTPAddr)
+	}
+	if cfg.MaxTallies != 300 {
+		t.Errorf("expected flags to override everything, got %d", cfg.MaxTallies)
+	}
+	if cfg.NumShards != 4096 {
+		t.Errorf("expected unset settings to keep defaults, got %d", cfg.NumShards)
+	}
+
+	// The file can also be named by the environment.
+	cfg, _ = loadWith(t, nil, map[string]string{"TOT_TALLY_CONFIG": path})
+	if cfg.Port != ":6000" {
+		t.Errorf("expected TOT_TALLY_CONFIG to be read, got %q", cfg.Port)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	tests := map[string]struct {
+		args []string
+		env  map[string]string
+		want string
+	}{
+		"unknown key":    {args: []string{"-config", write("typo.json", `{"MaxTally": 5}`)}, want: `unknown setting "MaxTally"`},
+		"bad json":       {args: []string{"-config", write("bad.json", `{`)}, want: "failed to parse"},
+		"missing file":   {args: []string{"-config", filepath.Join(dir, "none.json")}, want: "failed to read"},
+		"bad number":     {env: map[string]string{"TOT_TALLY_MAX_TALLIES": "many"}, want: "TOT_TALLY_MAX_TALLIES"},
+		"bad duration":   {args: []string{"-alert-interval", "soon"}, want: "-alert-interval"},
+		"invalid values": {args: []string{"-max-tallies", "0", "-digest-hour", "24"}, want: "MaxTallies must be positive\nconfig: DigestHour"},
+		"no templates":   {args: []string{"--assets-dir", dir}, want: "AssetsDir"},
+		"public path":    {args: []string{"-public-url", "https://example.com/tally"}, want: "PublicURL"},
+	}
+	for name, tc := range tests {
+		if _, err := loadWith(t, tc.args, tc.env); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.MaxTallies = 42
+	cfg.SMTPPassword = "hunter2"
+
+	var b strings.Builder
+	if err := cfg.Print(&b); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if !strings.Contains(b.String(), `"SMTPPassword": "********"`) || strings.Contains(b.String(), "hunter2") {
+		t.Errorf("expected the password to be masked:\n%s", b.String())
+	}
+
+	// The output is a valid config file.
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(b.String()), 0644)
+	loaded, err := loadWith(t, []string{"-config", path}, nil)
+	if err != nil {
+		t.Fatalf("Load of printed config failed: %v", err)
+	}
+	if loaded.MaxTallies != 42 || loaded.CleanupAge != cfg.CleanupAge {
+		t.Errorf("printed config did not round trip: %+v", loaded)
+	}
+}
+
+func TestSettingNames(t *testing.T) {
+	for field, want := range map[string]string{
+		"MaxTotsPerIP": "max-tots-per-ip",
+		"SMTPAddr":     "smtp-addr",
+		"MQTTClientID": "mqtt-client-id",
+		"Port":         "port",
+	} {
+		if got := flagName(field); got != want {
+			t.Errorf("flagName(%q) = %q, expected %q", field, got, want)
+		}
+	}
+	if got := envName("MaxTotsPerIP"); got != "TOT_TALLY_MAX_TOTS_PER_IP" {
+		t.Errorf("unexpected env name %q", got)
+	}
+}
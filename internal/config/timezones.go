@@ -0,0 +1,24 @@
+// timezones.go validates tot timezones. Timezones and CountryTimezones are generated from the
+// tz database, and the tzdata is embedded so every zone loads on hosts without zoneinfo files.
+package config
+
+//go:generate go run gen_timezones.go
+
+import (
+	"time"
+	_ "time/tzdata"
+)
+
+// DefaultTimezone is suggested when nothing is known about the visitor.
+const DefaultTimezone = "America/Chicago"
+
+// ValidTimezone reports whether name is an IANA timezone. Names outside Timezones, such as
+// backward-compatible links like "US/Eastern", are accepted as long as they load. "Local" is not,
+// since it depends on the server.
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
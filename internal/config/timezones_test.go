@@ -0,0 +1,60 @@
+package config
+
+import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimezones_Embedded(t *testing.T) {
+	// time/tzdata embeds the same zoneinfo.zip that ships with Go.
+	zr, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"))
+	if err != nil {
+		t.Skipf("Go's zoneinfo.zip is not available: %v", err)
+	}
+	defer zr.Close()
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	for _, name := range Timezones {
+		f, ok := files[name]
+		if !ok {
+			t.Errorf("%s is not in the embedded tzdata", name)
+			continue
+		}
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if _, err := time.LoadLocationFromTZData(name, data); err != nil {
+			t.Errorf("%s does not load: %v", name, err)
+		}
+		if !ValidTimezone(name) {
+			t.Errorf("%s is offered but not valid", name)
+		}
+	}
+	if !slices.IsSorted(Timezones) || len(Timezones) < 400 {
+		t.Errorf("expected a sorted list of every zone, got %d", len(Timezones))
+	}
+	for code, zone := range CountryTimezones {
+		if !slices.Contains(Timezones, zone) {
+			t.Errorf("%s suggests %s, which is not offered", code, zone)
+		}
+	}
+}
+
+func TestValidTimezone(t *testing.T) {
+	for name, want := range map[string]bool{
+		"America/Chicago": true, "Europe/Oslo": true, "UTC": true, "US/Eastern": true,
+		"": false, "Local": false, "Mars/Olympus_Mons": false, "../etc/passwd": false,
+	} {
+		if got := ValidTimezone(name); got != want {
+			t.Errorf("ValidTimezone(%q) = %v, expected %v", name, got, want)
+		}
+	}
+}
@@ -4,8 +4,10 @@ package core
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,13 +15,16 @@ import (
 	totModels "tot-tally/internal/models"
 	totStats "tot-tally/internal/stats"
 	totStorage "tot-tally/internal/storage"
+
+	"github.com/google/uuid"
 )
 
 // Service coordinates high-level business operations.
 type Service struct {
-	config *totConfig.Config
-	store  *totStorage.Repository
-	stats  *totStats.Engine
+	config    *totConfig.Config
+	store     *totStorage.Repository
+	stats     *totStats.Engine
+	listeners []Listener
 }
 
 // NewService initializes the business logic layer with its requirements.
@@ -79,6 +84,79 @@ func (s *Service) CreateTot(name, timezone, milkSetting string) (string, error)
 	return newID, nil
 }
 
+// DeleteTot removes a tot and retires its quick-log, feed and mail links. The tot is gone once its file
+// is, so a link that fails to delete is only logged; it points nowhere and expires with cleanup.
+func (s *Service) DeleteTot(tot *totModels.Tot) error {
+	if err := s.store.DeleteTot(tot.ID); err != nil {
+		return err
+	}
+	if err := s.RevokeAllQuickLogs(tot); err != nil {
+		slog.Warn("failed to delete quick-log link", "id", tot.ID, "err", err)
+	}
+	if err := s.RevokeReadToken(tot); err != nil {
+		slog.Warn("failed to delete read token", "id", tot.ID, "err", err)
+	}
+	if err := s.revokeMail(tot); err != nil {
+		slog.Warn("failed to delete mail link", "id", tot.ID, "err", err)
+	}
+	return nil
+}
+
+// ImportTot saves a tot read from an export, e.g. to move it between servers. An existing tot with
+// the same ID is only overwritten when replace is set. Links are recreated and stats recomputed, so
+// the import works whatever state the export was in; exported markers newer than the tallies are kept.
+func (s *Service) ImportTot(tot *totModels.Tot, replace bool) error {
+	if _, err := uuid.Parse(tot.ID); err != nil {
+		return fmt.Errorf("core: invalid tot id %q: %w", tot.ID, err)
+	}
+	if strings.TrimSpace(tot.Name) == "" {
+		return errors.New("core: tot has no name")
+	}
+	tzLocation, err := time.LoadLocation(tot.Timezone)
+	if err != nil {
+		return fmt.Errorf("core: invalid timezone %q: %w", tot.Timezone, err)
+	}
+
+	if existing, err := s.store.LoadTot(tot.ID); err == nil {
+		if !replace {
+			return fmt.Errorf("core: tot %s already exists", tot.ID)
+		}
+		if err := errors.Join(s.RevokeAllQuickLogs(existing), s.RevokeReadToken(existing), s.revokeMail(existing)); err != nil {
+			return err
+		}
+	}
+	if tot.QuickLog.Ref != "" {
+		if err := s.store.SaveLink(tot.QuickLog.Ref, tot.ID); err != nil {
+			return fmt.Errorf("core: failed to save quick-log link: %w", err)
+		}
+	}
+	if tot.ReadToken != "" {
+		if err := s.store.SaveLink(tot.ReadToken, tot.ID); err != nil {
+			return fmt.Errorf("core: failed to save read token: %w", err)
+		}
+	}
+	if tot.Mail.Ref != "" {
+		if err := s.store.SaveLink(tot.Mail.Ref, tot.ID); err != nil {
+			return fmt.Errorf("core: failed to save mail link: %w", err)
+		}
+	}
+
+	// An export past MaxTallies has latest activity older than its tallies, so the newer of the
+	// exported and recomputed markers is kept.
+	imported := tot.Stats
+	s.stats.RecalculateStats(tot)
+	tot.Stats = totStats.NewerStats(imported, tot.Stats)
+	generated, err := s.stats.GenerateStats(tot, tzLocation, time.Now())
+	if err != nil {
+		return fmt.Errorf("core: stats failed: %w", err)
+	}
+	tot.GeneratedStats = generated
+	if err := s.store.SaveTotWithoutActivity(tot); err != nil {
+		return fmt.Errorf("core: persistence failed: %w", err)
+	}
+	return nil
+}
+
 // AddTally records a new activity event.
 func (s *Service) AddTally(tot *totModels.Tot, kindKey string) error {
 	kindKeyInt, err := strconv.ParseInt(kindKey, 10, 64)
@@ -104,6 +182,100 @@ func (s *Service) AddTally(tot *totModels.Tot, kindKey string) error {
 	return nil
 }
 
+// UndoTally removes the most recent tally. It reports false when there is nothing to undo.
+func (s *Service) UndoTally(tot *totModels.Tot) (totModels.Tally, bool) {
+	return s.RemoveTally(tot, 0)
+}
+
+// RemoveTally deletes the tally at index i and rebuilds the latest activity markers.
+func (s *Service) RemoveTally(tot *totModels.Tot, i int) (totModels.Tally, bool) {
+	if i < 0 || i >= len(tot.Tallies) {
+		return totModels.Tally{}, false
+	}
+	removed := tot.Tallies[i]
+	tot.Tallies = slices.Delete(tot.Tallies, i, i+1)
+	s.stats.RecalculateStats(tot)
+	return removed, true
+}
+
+// EditTally changes the time and kind of the tally at index i, keeping tallies newest first.
+// The combined pee and poo kind cannot be used, since it is stored as two tallies.
+func (s *Service) EditTally(tot *totModels.Tot, i int, at time.Time, kindKey string) (totModels.Tally, error) {
+	if i < 0 || i >= len(tot.Tallies) {
+		return totModels.Tally{}, errors.New("core: tally does not exist")
+	}
+	kindKeyInt, err := strconv.ParseInt(kindKey, 10, 64)
+	if err != nil {
+		return totModels.Tally{}, fmt.Errorf("core: key format: %w", err)
+	}
+	kind, exists := totConfig.TallyKindMap[kindKeyInt]
+	if !exists || kind == totConfig.TallyKindMap[13] {
+		return totModels.Tally{}, fmt.Errorf("core: unknown kind: %d", kindKeyInt)
+	}
+	if at.IsZero() || at.After(time.Now()) {
+		return totModels.Tally{}, errors.New("core: tally time must not be in the future")
+	}
+
+	at = at.UTC()
+	edited := totModels.Tally{Time: &at, Kind: kind}
+	tot.Tallies[i] = edited
+	slices.SortStableFunc(tot.Tallies, func(a, b totModels.Tally) int { return b.Time.Compare(*a.Time) })
+	s.stats.RecalculateStats(tot)
+	return edited, nil
+}
+
+// ChangeTimezone switches the tot to tz from at onwards, recording the change so tallies before
+// at stay on the days they happened. Changes older than every tally are dropped, since the next
+// change still records the zone they switched to.
+func (s *Service) ChangeTimezone(tot *totModels.Tot, tz string, at time.Time) error {
+	if !totConfig.ValidTimezone(tz) {
+		return fmt.Errorf("core: invalid timezone %q", tz)
+	}
+	if at.IsZero() || at.After(time.Now()) {
+		return errors.New("core: timezone change must not be in the future")
+	}
+	if n := len(tot.TimezoneChanges); n > 0 && at.Before(tot.TimezoneChanges[n-1].At) {
+		return errors.New("core: timezone change must not be before the previous change")
+	}
+	if tz == tot.Timezone {
+		return nil
+	}
+
+	tot.TimezoneChanges = append(tot.TimezoneChanges, totModels.TimezoneChange{At: at.UTC(), From: tot.Timezone, To: tz})
+	tot.Timezone = tz
+
+	var oldest *time.Time
+	for _, t := range tot.Tallies {
+		if t.Time != nil && (oldest == nil || t.Time.Before(*oldest)) {
+			oldest = t.Time
+		}
+	}
+	if oldest != nil {
+		tot.TimezoneChanges = slices.DeleteFunc(tot.TimezoneChanges, func(c totModels.TimezoneChange) bool { return c.At.Before(*oldest) })
+	}
+	return nil
+}
+
+// TallyKey returns the config.TallyKindMap number of a stored kind, or 0 if it is unknown.
+func TallyKey(kind string) int64 {
+	for k, v := range totConfig.TallyKindMap {
+		if v == kind {
+			return k
+		}
+	}
+	return 0
+}
+
+// TallyID identifies a tally by its time and kind key, e.g. "1698400800000000000-11".
+func TallyID(t totModels.Tally) string {
+	return strconv.FormatInt(t.Time.UnixNano(), 10) + "-" + strconv.FormatInt(TallyKey(t.Kind), 10)
+}
+
+// TallyIndex returns the index of the tally with the given ID, or -1.
+func TallyIndex(tot *totModels.Tot, id string) int {
+	return slices.IndexFunc(tot.Tallies, func(t totModels.Tally) bool { return t.Time != nil && TallyID(t) == id })
+}
+
 func (s *Service) updateLatestMarkers(tot *totModels.Tot, kind string, now *time.Time) {
 	if strings.HasPrefix(kind, "🍼") {
 		tot.Stats.LastMilk = now
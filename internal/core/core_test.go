@@ -1,9 +1,11 @@
 package core
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	totConfig "tot-tally/internal/config"
 	totModels "tot-tally/internal/models"
 	totShards "tot-tally/internal/shards"
@@ -151,3 +153,234 @@ func TestAddTally_InvalidKeyFormat(t *testing.T) {
 		t.Error("Expected error for non-numeric kind, got nil")
 	}
 }
+
+func TestUndoTally(t *testing.T) {
+	s := setupCore(t)
+	tot := &totModels.Tot{}
+	if _, ok := s.UndoTally(tot); ok {
+		t.Error("expected nothing to undo on an empty tot")
+	}
+
+	s.AddTally(tot, "11") // 🚽
+	s.AddTally(tot, "12") // 💩
+	removed, ok := s.UndoTally(tot)
+	if !ok || removed.Kind != "💩" {
+		t.Fatalf("expected 💩 to be undone, got %+v", removed)
+	}
+	if len(tot.Tallies) != 1 || tot.Stats.LastPoo != nil || tot.Stats.LastPee == nil {
+		t.Errorf("expected only the pee to remain, got %+v", tot.Stats)
+	}
+}
+
+func TestRemoveTally(t *testing.T) {
+	s := setupCore(t)
+	tot := &totModels.Tot{}
+	s.AddTally(tot, "11") // 🚽
+	s.AddTally(tot, "1")  // 🍼1
+
+	if _, ok := s.RemoveTally(tot, 2); ok {
+		t.Error("expected out of range index to fail")
+	}
+	removed, ok := s.RemoveTally(tot, 1)
+	if !ok || removed.Kind != "🚽" || len(tot.Tallies) != 1 || tot.Stats.LastPee != nil {
+		t.Errorf("expected pee to be removed, got %+v %+v", removed, tot.Stats)
+	}
+}
+
+func TestEditTally(t *testing.T) {
+	s := setupCore(t)
+	tot := &totModels.Tot{}
+	s.AddTally(tot, "11") // 🚽
+	s.AddTally(tot, "1")  // 🍼1
+
+	earlier := time.Now().Add(-time.Hour)
+	edited, err := s.EditTally(tot, 0, earlier, "2")
+	if err != nil {
+		t.Fatalf("EditTally failed: %v", err)
+	}
+	if edited.Kind != "🍼2" || !edited.Time.Equal(earlier) {
+		t.Errorf("unexpected edited tally: %+v", edited)
+	}
+	if tot.Tallies[0].Kind != "🚽" || tot.Tallies[1].Kind != "🍼2" {
+		t.Errorf("expected tallies to stay newest first, got %+v", tot.Tallies)
+	}
+	if !tot.Stats.LastMilk.Equal(earlier) {
+		t.Errorf("expected LastMilk to follow the edit, got %v", tot.Stats.LastMilk)
+	}
+
+	for name, tc := range map[string]struct {
+		i   int
+		at  time.Time
+		key string
+	}{
+		"bad index":   {5, earlier, "1"},
+		"bad key":     {0, earlier, "x"},
+		"unknown key": {0, earlier, "99"},
+		"both":        {0, earlier, "13"},
+		"future":      {0, time.Now().Add(time.Hour), "1"},
+	} {
+		if _, err := s.EditTally(tot, tc.i, tc.at, tc.key); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestChangeTimezone(t *testing.T) {
+	s := setupCore(t)
+	now := time.Now()
+	older, old := now.Add(-72*time.Hour), now.Add(-48*time.Hour)
+	tot := &totModels.Tot{Timezone: "America/Chicago", Tallies: []totModels.Tally{{Time: &old, Kind: "🚽"}}}
+
+	if err := s.ChangeTimezone(tot, "Asia/Tokyo", now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("ChangeTimezone failed: %v", err)
+	}
+	if tot.Timezone != "Asia/Tokyo" || len(tot.TimezoneChanges) != 1 ||
+		tot.TimezoneChanges[0].From != "America/Chicago" || tot.TimezoneChanges[0].To != "Asia/Tokyo" {
+		t.Fatalf("unexpected change: %q %+v", tot.Timezone, tot.TimezoneChanges)
+	}
+
+	// Switching to the current zone records nothing.
+	s.ChangeTimezone(tot, "Asia/Tokyo", now)
+	if len(tot.TimezoneChanges) != 1 {
+		t.Errorf("expected no change to be recorded, got %+v", tot.TimezoneChanges)
+	}
+
+	for name, at := range map[string]time.Time{
+		"future":          now.Add(time.Hour),
+		"before previous": older,
+		"zero":            {},
+	} {
+		if err := s.ChangeTimezone(tot, "Europe/London", at); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+	if err := s.ChangeTimezone(tot, "Mars/Olympus", now); err == nil {
+		t.Error("expected error for an invalid timezone")
+	}
+
+	// Once the tally before the first change is gone, the first change is no longer needed.
+	tot.Tallies[0].Time = &now
+	if err := s.ChangeTimezone(tot, "Europe/London", now); err != nil {
+		t.Fatalf("ChangeTimezone failed: %v", err)
+	}
+	if len(tot.TimezoneChanges) != 1 || tot.TimezoneChanges[0].From != "Asia/Tokyo" {
+		t.Errorf("expected the old change to be pruned, got %+v", tot.TimezoneChanges)
+	}
+}
+
+func TestTallyID(t *testing.T) {
+	at := time.Unix(1698400800, 0)
+	tot := &totModels.Tot{Tallies: []totModels.Tally{{Time: &at, Kind: "🚽"}, {Time: &at, Kind: "💩"}}}
+
+	if id := TallyID(tot.Tallies[1]); id != "1698400800000000000-12" {
+		t.Errorf("unexpected ID: %s", id)
+	}
+	if i := TallyIndex(tot, "1698400800000000000-12"); i != 1 {
+		t.Errorf("expected index 1, got %d", i)
+	}
+	if i := TallyIndex(tot, "1698400800000000000-1"); i != -1 {
+		t.Errorf("expected -1 for unknown ID, got %d", i)
+	}
+}
+
+func TestDeleteTot(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	id, _ := s.CreateTot("Baby", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	s.RotateReadToken(tot)
+	s.RequestMailConfirmation(tot, MailDigest, "parent@example.com", "https://tally.example.com")
+	token, mailRef := tot.ReadToken, tot.Mail.Ref
+
+	if err := s.DeleteTot(tot); err != nil {
+		t.Fatalf("DeleteTot failed: %v", err)
+	}
+	if _, err := s.store.LoadTot(id); err == nil {
+		t.Error("expected the tot to be deleted")
+	}
+	if _, err := s.store.LoadLink(token); err == nil {
+		t.Error("expected the read token to be deleted")
+	}
+	if _, err := s.store.LoadLink(mailRef); err == nil {
+		t.Error("expected the mail link to be deleted")
+	}
+	if err := s.DeleteTot(tot); err == nil {
+		t.Error("expected an error deleting a missing tot")
+	}
+}
+
+func TestImportTot(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	logged := time.Now().Add(-time.Hour)
+	tot := &totModels.Tot{
+		ID: "01890a5d-ac96-774b-bcce-b302099a8057", Name: "Baby", Timezone: "UTC", ReadToken: "token",
+		Mail:    totModels.MailSettings{Ref: "mail-ref", Secret: "secret"},
+		Tallies: []totModels.Tally{{Time: &logged, Kind: "🚽"}},
+	}
+
+	if err := s.ImportTot(tot, false); err != nil {
+		t.Fatalf("ImportTot failed: %v", err)
+	}
+	loaded, err := s.store.LoadTot(tot.ID)
+	if err != nil || loaded.Stats.LastPee == nil || !loaded.Stats.LastPee.Equal(logged) {
+		t.Fatalf("expected the tot with recomputed stats, got %+v %v", loaded, err)
+	}
+	if totID, _ := s.store.LoadLink("token"); totID != tot.ID {
+		t.Errorf("expected the read token to be linked, got %q", totID)
+	}
+	if totID, _ := s.store.LoadLink("mail-ref"); totID != tot.ID {
+		t.Errorf("expected the mail reference to be linked, got %q", totID)
+	}
+
+	if err := s.ImportTot(tot, false); err == nil {
+		t.Error("expected an existing tot to be kept")
+	}
+	tot.ReadToken = "rotated"
+	if err := s.ImportTot(tot, true); err != nil {
+		t.Fatalf("ImportTot with replace failed: %v", err)
+	}
+	if _, err := s.store.LoadLink("token"); err == nil {
+		t.Error("expected the replaced tot's token to be revoked")
+	}
+
+	for _, bad := range []*totModels.Tot{
+		{ID: "not-a-uuid", Name: "Baby", Timezone: "UTC"},
+		{ID: tot.ID, Name: " ", Timezone: "UTC"},
+		{ID: tot.ID, Name: "Baby", Timezone: "Mars/Olympus"},
+	} {
+		if err := s.ImportTot(bad, true); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestImportTot_RoundTrip(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	id, _ := s.CreateTot("Baby", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	s.AddTally(tot, "14")
+	bath := *tot.Stats.LastBath
+	for range s.config.MaxTallies {
+		s.AddTally(tot, "11")
+	}
+	s.store.SaveTot(tot)
+
+	// An export is the saved file, which no longer holds the bath's tally.
+	export, _ := json.Marshal(tot)
+	imported := &totModels.Tot{}
+	if err := json.Unmarshal(export, imported); err != nil {
+		t.Fatalf("failed to decode the export: %v", err)
+	}
+	if err := s.ImportTot(imported, true); err != nil {
+		t.Fatalf("ImportTot failed: %v", err)
+	}
+	loaded, _ := s.store.LoadTot(id)
+	if loaded.Stats.LastBath == nil || !loaded.Stats.LastBath.Equal(bath) {
+		t.Errorf("expected the bath marker to survive, got %v", loaded.Stats.LastBath)
+	}
+	if loaded.Stats.LastPee == nil || !loaded.Stats.LastPee.Equal(*loaded.Tallies[0].Time) {
+		t.Errorf("expected the pee marker from the tallies, got %v", loaded.Stats.LastPee)
+	}
+}
@@ -0,0 +1,63 @@
+// events.go persists tot changes and notifies listeners about them.
+package core
+
+import (
+	"fmt"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+// EventType names a kind of change to a tot.
+type EventType string
+
+const (
+	EventTallyAdded      EventType = "tally.added"
+	EventTallyEdited     EventType = "tally.edited"
+	EventTallyUndone     EventType = "tally.undone" // Also sent when any tally is deleted.
+	EventSettingsUpdated EventType = "settings.updated"
+	EventJournalAdded    EventType = "journal.added"
+	EventJournalRemoved  EventType = "journal.removed"
+)
+
+// Event describes a change that has been saved.
+type Event struct {
+	Type EventType
+	At   time.Time
+	// Tallies holds the added, edited or removed tallies for tally events.
+	Tallies []totModels.Tally
+	// Entries holds the added or removed journal entries for journal events.
+	Entries []totModels.JournalEntry
+	// Setting names the changed setting for settings events, e.g. "timezone".
+	Setting string
+}
+
+// Listener is notified after each change to a tot is saved. It is called while the tot's
+// shard lock is held, so slow work such as network calls must happen in the background.
+type Listener interface {
+	TotChanged(tot *totModels.Tot, event Event)
+}
+
+// AddListener registers a listener. It must be called before the service handles requests.
+func (s *Service) AddListener(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+// Commit regenerates the stats of a changed tot, saves it and notifies listeners.
+func (s *Service) Commit(tot *totModels.Tot, tzLocation *time.Location, event Event) error {
+	now := time.Now()
+	generated, err := s.stats.GenerateStats(tot, tzLocation, now)
+	if err != nil {
+		return fmt.Errorf("core: stats failed: %w", err)
+	}
+	tot.GeneratedStats = generated
+
+	if err := s.store.SaveTot(tot); err != nil {
+		return fmt.Errorf("core: persistence failed: %w", err)
+	}
+
+	event.At = now.UTC()
+	for _, l := range s.listeners {
+		l.TotChanged(tot, event)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+type recordingListener struct {
+	events []Event
+}
+
+func (r *recordingListener) TotChanged(tot *totModels.Tot, event Event) {
+	r.events = append(r.events, event)
+}
+
+func TestCommit(t *testing.T) {
+	s := setupCore(t)
+	listener := &recordingListener{}
+	s.AddListener(listener)
+
+	id, _ := s.CreateTot("Baby", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	s.AddTally(tot, "1")
+
+	err := s.Commit(tot, time.UTC, Event{Type: EventTallyAdded, Tallies: tot.Tallies[:1]})
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	loaded, _ := s.store.LoadTot(id)
+	if len(loaded.Tallies) != 1 || loaded.GeneratedStats.TodayMilk == "---" {
+		t.Errorf("expected tally and stats to be saved, got %+v", loaded.GeneratedStats)
+	}
+	if len(listener.events) != 1 || listener.events[0].Type != EventTallyAdded || listener.events[0].At.IsZero() {
+		t.Errorf("expected one timestamped tally event, got %+v", listener.events)
+	}
+}
+
+func TestCommit_Errors(t *testing.T) {
+	s := setupCore(t)
+	listener := &recordingListener{}
+	s.AddListener(listener)
+
+	now := time.Now()
+	tot := &totModels.Tot{ID: "bad", Tallies: []totModels.Tally{{Time: &now, Kind: "🍼abc"}}}
+	if err := s.Commit(tot, time.UTC, Event{Type: EventSettingsUpdated}); err == nil {
+		t.Error("expected error from GenerateStats, got nil")
+	}
+
+	s.config.TotDirectory = filepath.Join(t.TempDir(), "file")
+	os.WriteFile(s.config.TotDirectory, []byte(""), 0644)
+	if err := s.Commit(&totModels.Tot{ID: "x"}, time.UTC, Event{Type: EventSettingsUpdated}); err == nil {
+		t.Error("expected error from SaveTot, got nil")
+	}
+
+	if len(listener.events) != 0 {
+		t.Errorf("expected no events for failed commits, got %+v", listener.events)
+	}
+}
@@ -0,0 +1,32 @@
+// feeds.go manages the read-only token that grants access to a tot's feeds without its write URL.
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	totModels "tot-tally/internal/models"
+)
+
+// RotateReadToken issues a new read-only token, invalidating the previous one.
+func (s *Service) RotateReadToken(tot *totModels.Tot) error {
+	if err := s.RevokeReadToken(tot); err != nil {
+		return err
+	}
+	token := rand.Text()
+	if err := s.store.SaveLink(token, tot.ID); err != nil {
+		return fmt.Errorf("core: failed to save read token: %w", err)
+	}
+	tot.ReadToken = token
+	return nil
+}
+
+// RevokeReadToken disables read-only access.
+func (s *Service) RevokeReadToken(tot *totModels.Tot) error {
+	if tot.ReadToken != "" {
+		if err := s.store.DeleteLink(tot.ReadToken); err != nil {
+			return fmt.Errorf("core: failed to delete read token: %w", err)
+		}
+	}
+	tot.ReadToken = ""
+	return nil
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"testing"
+	totModels "tot-tally/internal/models"
+)
+
+func TestReadToken(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	tot := &totModels.Tot{ID: "tot-1"}
+
+	if err := s.RotateReadToken(tot); err != nil {
+		t.Fatalf("RotateReadToken failed: %v", err)
+	}
+	first := tot.ReadToken
+	if totID, err := s.store.LoadLink(first); err != nil || totID != "tot-1" {
+		t.Fatalf("expected token to resolve to tot-1, got %q %v", totID, err)
+	}
+
+	s.RotateReadToken(tot)
+	if tot.ReadToken == first {
+		t.Error("expected a new token")
+	}
+	if _, err := s.store.LoadLink(first); err == nil {
+		t.Error("expected the old token to be deleted")
+	}
+
+	second := tot.ReadToken
+	if err := s.RevokeReadToken(tot); err != nil || tot.ReadToken != "" {
+		t.Fatalf("RevokeReadToken failed: %v %q", err, tot.ReadToken)
+	}
+	if _, err := s.store.LoadLink(second); err == nil {
+		t.Error("expected the token to be deleted")
+	}
+}
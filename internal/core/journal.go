@@ -0,0 +1,86 @@
+// journal.go records the sleep, medications, growth measurements and notes shown on the visit
+// summary. Unlike tallies, entries carry details, so each kind is validated on its own.
+package core
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+	"unicode/utf8"
+)
+
+// Limits on what a journal entry may hold.
+const (
+	maxSleep      = 24 * time.Hour
+	maxNameLength = 100 // Medication names and doses.
+	maxNoteLength = 1000
+	maxWeightKg   = 50
+	maxLengthCm   = 200
+	maxHeadCm     = 80
+)
+
+// AddJournalEntry validates entry, keeps only the fields of its kind and saves it in time order.
+// The oldest entries past MaxJournalEntries are dropped.
+func (s *Service) AddJournalEntry(tot *totModels.Tot, entry totModels.JournalEntry) (totModels.JournalEntry, error) {
+	now := time.Now()
+	if entry.Time.IsZero() || entry.Time.After(now) {
+		return totModels.JournalEntry{}, errors.New("core: journal entry time must not be in the future")
+	}
+
+	clean := totModels.JournalEntry{ID: rand.Text()[:10], Kind: entry.Kind, Time: entry.Time.UTC()}
+	switch entry.Kind {
+	case totConfig.JournalSleep:
+		if entry.End == nil || !entry.End.After(entry.Time) || entry.End.After(now) || entry.End.Sub(entry.Time) > maxSleep {
+			return totModels.JournalEntry{}, fmt.Errorf("core: sleep must end after it starts, by now and within %s", maxSleep)
+		}
+		end := entry.End.UTC()
+		clean.End = &end
+	case totConfig.JournalMedication:
+		clean.Name, clean.Dose = strings.TrimSpace(entry.Name), strings.TrimSpace(entry.Dose)
+		if clean.Name == "" || utf8.RuneCountInString(clean.Name) > maxNameLength || utf8.RuneCountInString(clean.Dose) > maxNameLength {
+			return totModels.JournalEntry{}, fmt.Errorf("core: medication needs a name, and name and dose at most %d characters", maxNameLength)
+		}
+	case totConfig.JournalGrowth:
+		for _, m := range []struct {
+			value, limit float64
+		}{{entry.WeightKg, maxWeightKg}, {entry.LengthCm, maxLengthCm}, {entry.HeadCm, maxHeadCm}} {
+			if m.value < 0 || m.value > m.limit {
+				return totModels.JournalEntry{}, fmt.Errorf("core: growth measurement %g out of range", m.value)
+			}
+		}
+		if entry.WeightKg == 0 && entry.LengthCm == 0 && entry.HeadCm == 0 {
+			return totModels.JournalEntry{}, errors.New("core: growth needs at least one measurement")
+		}
+		clean.WeightKg, clean.LengthCm, clean.HeadCm = entry.WeightKg, entry.LengthCm, entry.HeadCm
+	case totConfig.JournalNote:
+		clean.Text = strings.TrimSpace(entry.Text)
+		if clean.Text == "" || utf8.RuneCountInString(clean.Text) > maxNoteLength {
+			return totModels.JournalEntry{}, fmt.Errorf("core: notes must have 1 to %d characters", maxNoteLength)
+		}
+	default:
+		return totModels.JournalEntry{}, fmt.Errorf("core: unknown journal kind %q", entry.Kind)
+	}
+
+	tot.Journal = append(tot.Journal, clean)
+	slices.SortStableFunc(tot.Journal, func(a, b totModels.JournalEntry) int { return b.Time.Compare(a.Time) })
+	if len(tot.Journal) > s.config.MaxJournalEntries {
+		tot.Journal = tot.Journal[:s.config.MaxJournalEntries]
+	}
+	return clean, nil
+}
+
+// RemoveJournalEntry deletes the entry with the given ID. It reports false when there is none.
+func (s *Service) RemoveJournalEntry(tot *totModels.Tot, id string) (totModels.JournalEntry, bool) {
+	i := slices.IndexFunc(tot.Journal, func(e totModels.JournalEntry) bool { return e.ID == id })
+	if i < 0 {
+		return totModels.JournalEntry{}, false
+	}
+	removed := tot.Journal[i]
+	tot.Journal = slices.Delete(tot.Journal, i, i+1)
+	return removed, true
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+func TestAddJournalEntry(t *testing.T) {
+	s := setupCore(t)
+	s.config.MaxJournalEntries = 3
+	tot := &totModels.Tot{}
+	now := time.Now()
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	end := ago(time.Hour)
+
+	added, err := s.AddJournalEntry(tot, totModels.JournalEntry{Kind: totConfig.JournalMedication, Time: ago(2 * time.Hour), Name: " Paracetamol ", Dose: "2.5 ml", Text: "ignored"})
+	if err != nil || added.ID == "" || added.Name != "Paracetamol" || added.Text != "" {
+		t.Fatalf("expected a cleaned medication entry, got %+v %v", added, err)
+	}
+	if _, err := s.AddJournalEntry(tot, totModels.JournalEntry{Kind: totConfig.JournalSleep, Time: ago(3 * time.Hour), End: &end}); err != nil {
+		t.Fatalf("AddJournalEntry failed: %v", err)
+	}
+	if _, err := s.AddJournalEntry(tot, totModels.JournalEntry{Kind: totConfig.JournalNote, Time: ago(time.Minute), Text: "Teething"}); err != nil {
+		t.Fatalf("AddJournalEntry failed: %v", err)
+	}
+	if kinds := []string{tot.Journal[0].Kind, tot.Journal[1].Kind, tot.Journal[2].Kind}; strings.Join(kinds, ",") != "note,medication,sleep" {
+		t.Errorf("expected entries newest first, got %v", kinds)
+	}
+
+	// The oldest entry is dropped past the limit.
+	if _, err := s.AddJournalEntry(tot, totModels.JournalEntry{Kind: totConfig.JournalGrowth, Time: ago(time.Minute), WeightKg: 5.2, HeadCm: 38}); err != nil {
+		t.Fatalf("AddJournalEntry failed: %v", err)
+	}
+	if len(tot.Journal) != 3 || tot.Journal[2].Kind != totConfig.JournalMedication {
+		t.Errorf("expected the sleep entry dropped, got %+v", tot.Journal)
+	}
+
+	if removed, ok := s.RemoveJournalEntry(tot, added.ID); !ok || removed.Name != "Paracetamol" || len(tot.Journal) != 2 {
+		t.Errorf("expected the medication removed, got %+v %v", removed, ok)
+	}
+	if _, ok := s.RemoveJournalEntry(tot, added.ID); ok {
+		t.Error("expected a removed entry not to be found")
+	}
+
+	future, long := now.Add(time.Hour), ago(26*time.Hour)
+	for name, bad := range map[string]totModels.JournalEntry{
+		"future":         {Kind: totConfig.JournalNote, Time: future, Text: "Later"},
+		"no time":        {Kind: totConfig.JournalNote, Text: "When?"},
+		"unknown kind":   {Kind: "feeding", Time: ago(time.Minute)},
+		"sleep no end":   {Kind: totConfig.JournalSleep, Time: ago(time.Hour)},
+		"sleep reversed": {Kind: totConfig.JournalSleep, Time: ago(time.Hour), End: &long},
+		"sleep too long": {Kind: totConfig.JournalSleep, Time: long, End: &end},
+		"sleep ongoing":  {Kind: totConfig.JournalSleep, Time: ago(time.Hour), End: &future},
+		"no medication":  {Kind: totConfig.JournalMedication, Time: ago(time.Minute), Dose: "5 ml"},
+		"no measurement": {Kind: totConfig.JournalGrowth, Time: ago(time.Minute)},
+		"negative":       {Kind: totConfig.JournalGrowth, Time: ago(time.Minute), WeightKg: -1},
+		"too heavy":      {Kind: totConfig.JournalGrowth, Time: ago(time.Minute), WeightKg: 500},
+		"empty note":     {Kind: totConfig.JournalNote, Time: ago(time.Minute), Text: "  "},
+		"long note":      {Kind: totConfig.JournalNote, Time: ago(time.Minute), Text: strings.Repeat("a", 1001)},
+	} {
+		if _, err := s.AddJournalEntry(tot, bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(tot.Journal) != 2 {
+		t.Errorf("expected rejected entries not to be saved, got %d", len(tot.Journal))
+	}
+}
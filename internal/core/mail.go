@@ -0,0 +1,144 @@
+// mail.go issues and verifies the signed links in emails. An address only receives a digest or
+// alerts once the link in its confirmation email is clicked, and every email links to unsubscribe.
+package core
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// What an address receives.
+const (
+	MailDigest = "digest"
+	MailAlerts = "alerts"
+)
+
+// What a link does to the address it was signed for.
+const (
+	MailConfirm     = "confirm"
+	MailUnsubscribe = "unsubscribe"
+)
+
+// RequestMailConfirmation records address as waiting for confirmation before it receives mail for
+// purpose. baseURL is the scheme and host it was entered on, for links when PublicURL isn't set.
+// The first request also creates the tot's public reference and signing secret.
+func (s *Service) RequestMailConfirmation(tot *totModels.Tot, purpose, address, baseURL string) error {
+	if purpose != MailDigest && purpose != MailAlerts {
+		return fmt.Errorf("core: unknown mail purpose %q", purpose)
+	}
+	if tot.Mail.Ref == "" {
+		ref := rand.Text()
+		if err := s.store.SaveLink(ref, tot.ID); err != nil {
+			return fmt.Errorf("core: failed to save mail link: %w", err)
+		}
+		tot.Mail = totModels.MailSettings{Ref: ref, Secret: rand.Text()}
+	}
+	tot.Mail.BaseURL = baseURL
+
+	confirmed, _ := mailAddresses(tot, purpose)
+	setMailAddresses(tot, purpose, confirmed, address)
+	return nil
+}
+
+// revokeMail retires the public reference, invalidating every link sent so far.
+func (s *Service) revokeMail(tot *totModels.Tot) error {
+	if tot.Mail.Ref != "" {
+		if err := s.store.DeleteLink(tot.Mail.Ref); err != nil {
+			return fmt.Errorf("core: failed to delete mail link: %w", err)
+		}
+	}
+	tot.Mail = totModels.MailSettings{}
+	return nil
+}
+
+// MailSignature signs a link that applies action to address, or returns "" if the tot has no
+// signing secret. Changing the address invalidates links sent for the old one.
+func MailSignature(tot *totModels.Tot, purpose, action, address string) string {
+	if tot.Mail.Secret == "" || address == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(tot.Mail.Secret))
+	mac.Write([]byte(tot.ID + ":" + purpose + ":" + action + ":" + address))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// MailLink returns the absolute URL of the link that applies action to address.
+func MailLink(cfg *totConfig.Config, tot *totModels.Tot, purpose, action, address string) string {
+	base := strings.TrimSuffix(cmp.Or(cfg.PublicURL, tot.Mail.BaseURL), "/")
+	return base + "/m/" + tot.Mail.Ref + "/" + purpose + "/" + action + "/" + MailSignature(tot, purpose, action, address)
+}
+
+// MailLinkAddress returns the current address a link was signed for, or "" if it matches none,
+// e.g. because the address changed after the link was sent.
+func MailLinkAddress(tot *totModels.Tot, purpose, action, sig string) string {
+	confirmed, pending := mailAddresses(tot, purpose)
+	candidates := []string{pending}
+	if action == MailUnsubscribe {
+		candidates = append(candidates, confirmed)
+	} else if action != MailConfirm {
+		return ""
+	}
+	for _, address := range candidates {
+		expected := MailSignature(tot, purpose, action, address)
+		if expected != "" && hmac.Equal([]byte(expected), []byte(sig)) {
+			return address
+		}
+	}
+	return ""
+}
+
+// ApplyMailLink confirms or unsubscribes the address a link was signed for. Unsubscribing a pending
+// address cancels its confirmation. It reports false when the link matches no current address.
+func ApplyMailLink(tot *totModels.Tot, purpose, action, sig string) bool {
+	address := MailLinkAddress(tot, purpose, action, sig)
+	if address == "" {
+		return false
+	}
+	confirmed, pending := mailAddresses(tot, purpose)
+	switch {
+	case action == MailConfirm:
+		confirmed, pending = address, ""
+	case address == pending:
+		pending = ""
+	default:
+		confirmed = ""
+	}
+	setMailAddresses(tot, purpose, confirmed, pending)
+	return true
+}
+
+// mailAddresses returns the confirmed and pending addresses for purpose. Alerts only have a
+// confirmed address while they are sent by email.
+func mailAddresses(tot *totModels.Tot, purpose string) (confirmed, pending string) {
+	switch purpose {
+	case MailDigest:
+		return tot.Digest.Email, tot.Digest.Pending
+	case MailAlerts:
+		if tot.Alerts.Notifier == "email" {
+			confirmed = tot.Alerts.Target
+		}
+		return confirmed, tot.Alerts.Pending
+	}
+	return "", ""
+}
+
+func setMailAddresses(tot *totModels.Tot, purpose, confirmed, pending string) {
+	switch purpose {
+	case MailDigest:
+		tot.Digest.Email, tot.Digest.Pending = confirmed, pending
+	case MailAlerts:
+		if confirmed != "" || pending != "" {
+			tot.Alerts.Notifier, tot.Alerts.Target = "email", confirmed
+		} else if tot.Alerts.Notifier == "email" {
+			tot.Alerts.Notifier, tot.Alerts.Target = "", ""
+		}
+		tot.Alerts.Pending = pending
+	}
+}
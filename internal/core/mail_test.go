@@ -0,0 +1,103 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+func TestMail_ConfirmAndUnsubscribe(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	tot := &totModels.Tot{ID: "tot-1"}
+
+	if err := s.RequestMailConfirmation(tot, "newsletter", "parent@example.com", "https://tally.example.com"); err == nil {
+		t.Error("expected error for unknown purpose, got nil")
+	}
+	if err := s.RequestMailConfirmation(tot, MailDigest, "parent@example.com", "https://tally.example.com"); err != nil {
+		t.Fatalf("RequestMailConfirmation failed: %v", err)
+	}
+	if tot.Digest.Email != "" || tot.Digest.Pending != "parent@example.com" {
+		t.Fatalf("expected the address to wait for confirmation, got %+v", tot.Digest)
+	}
+	if totID, err := s.store.LoadLink(tot.Mail.Ref); err != nil || totID != "tot-1" {
+		t.Fatalf("expected link to tot-1, got %q %v", totID, err)
+	}
+
+	link := MailLink(&totConfig.Config{}, tot, MailDigest, MailConfirm, "parent@example.com")
+	if want := "https://tally.example.com/m/" + tot.Mail.Ref + "/digest/confirm/" + MailSignature(tot, MailDigest, MailConfirm, "parent@example.com"); link != want {
+		t.Errorf("expected %s, got %s", want, link)
+	}
+	if link := MailLink(&totConfig.Config{PublicURL: "https://public.example.com/"}, tot, MailDigest, MailConfirm, "x@example.com"); !strings.HasPrefix(link, "https://public.example.com/m/"+tot.Mail.Ref+"/") {
+		t.Errorf("expected PublicURL to take precedence, got %s", link)
+	}
+
+	confirm := MailSignature(tot, MailDigest, MailConfirm, "parent@example.com")
+	for _, bad := range []struct{ purpose, action, sig string }{
+		{MailAlerts, MailConfirm, confirm},
+		{MailDigest, MailUnsubscribe, confirm},
+		{MailDigest, MailConfirm, confirm[1:]},
+		{MailDigest, "delete", confirm},
+	} {
+		if ApplyMailLink(tot, bad.purpose, bad.action, bad.sig) {
+			t.Errorf("expected %+v to be refused", bad)
+		}
+	}
+	if other := (&totModels.Tot{ID: "tot-2", Mail: tot.Mail, Digest: tot.Digest}); MailLinkAddress(other, MailDigest, MailConfirm, confirm) != "" {
+		t.Error("expected the signature to be bound to the tot ID")
+	}
+
+	if !ApplyMailLink(tot, MailDigest, MailConfirm, confirm) {
+		t.Fatal("expected the confirmation link to apply")
+	}
+	if tot.Digest.Email != "parent@example.com" || tot.Digest.Pending != "" {
+		t.Errorf("expected the address to be confirmed, got %+v", tot.Digest)
+	}
+
+	// A new address waits for its own confirmation; the old one keeps its digest meanwhile,
+	// and either can unsubscribe.
+	s.RequestMailConfirmation(tot, MailDigest, "other@example.com", "https://tally.example.com")
+	if tot.Digest.Email != "parent@example.com" || tot.Digest.Pending != "other@example.com" {
+		t.Fatalf("unexpected digest settings %+v", tot.Digest)
+	}
+	if ApplyMailLink(tot, MailDigest, MailConfirm, confirm) {
+		t.Error("expected the old confirmation link to be refused")
+	}
+	if !ApplyMailLink(tot, MailDigest, MailUnsubscribe, MailSignature(tot, MailDigest, MailUnsubscribe, "other@example.com")) {
+		t.Fatal("expected the pending address to unsubscribe")
+	}
+	if tot.Digest.Email != "parent@example.com" || tot.Digest.Pending != "" {
+		t.Errorf("expected only the pending address to be cleared, got %+v", tot.Digest)
+	}
+	if !ApplyMailLink(tot, MailDigest, MailUnsubscribe, MailSignature(tot, MailDigest, MailUnsubscribe, "parent@example.com")) {
+		t.Fatal("expected the confirmed address to unsubscribe")
+	}
+	if tot.Digest.Email != "" {
+		t.Errorf("expected the digest to be disabled, got %+v", tot.Digest)
+	}
+}
+
+func TestMail_Alerts(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	tot := &totModels.Tot{ID: "tot-1", Alerts: totModels.AlertSettings{Notifier: "ntfy", Target: "https://ntfy.sh/topic"}}
+
+	s.RequestMailConfirmation(tot, MailAlerts, "parent@example.com", "https://tally.example.com")
+	if tot.Alerts.Notifier != "email" || tot.Alerts.Target != "" || tot.Alerts.Pending != "parent@example.com" {
+		t.Fatalf("expected email alerts to wait for confirmation, got %+v", tot.Alerts)
+	}
+	if !ApplyMailLink(tot, MailAlerts, MailConfirm, MailSignature(tot, MailAlerts, MailConfirm, "parent@example.com")) {
+		t.Fatal("expected the confirmation link to apply")
+	}
+	if tot.Alerts.Target != "parent@example.com" || tot.Alerts.Pending != "" {
+		t.Errorf("expected the address to be confirmed, got %+v", tot.Alerts)
+	}
+
+	if !ApplyMailLink(tot, MailAlerts, MailUnsubscribe, MailSignature(tot, MailAlerts, MailUnsubscribe, "parent@example.com")) {
+		t.Fatal("expected the address to unsubscribe")
+	}
+	if tot.Alerts.Notifier != "" || tot.Alerts.Target != "" {
+		t.Errorf("expected alerts to go back to the page only, got %+v", tot.Alerts)
+	}
+}
@@ -0,0 +1,93 @@
+// quicklog.go issues and verifies signed one-tap links that log a single tally kind.
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// CreateQuickLog issues a link for a tally kind, replacing any existing link for it.
+// The first link also creates the tot's public reference and signing secret.
+func (s *Service) CreateQuickLog(tot *totModels.Tot, key int64) error {
+	if _, ok := totConfig.TallyKindMap[key]; !ok {
+		return fmt.Errorf("core: unknown kind: %d", key)
+	}
+	if tot.QuickLog.Ref == "" {
+		ref := rand.Text()
+		if err := s.store.SaveLink(ref, tot.ID); err != nil {
+			return fmt.Errorf("core: failed to save quick-log link: %w", err)
+		}
+		tot.QuickLog = totModels.QuickLogSettings{Ref: ref, Secret: rand.Text()}
+	}
+	if tot.QuickLog.Links == nil {
+		tot.QuickLog.Links = map[int64]string{}
+	}
+	tot.QuickLog.Links[key] = rand.Text()
+	return nil
+}
+
+// RevokeQuickLog invalidates the link for one tally kind.
+func (s *Service) RevokeQuickLog(tot *totModels.Tot, key int64) {
+	delete(tot.QuickLog.Links, key)
+}
+
+// RevokeAllQuickLogs invalidates every link and retires the public reference.
+func (s *Service) RevokeAllQuickLogs(tot *totModels.Tot) error {
+	if tot.QuickLog.Ref != "" {
+		if err := s.store.DeleteLink(tot.QuickLog.Ref); err != nil {
+			return fmt.Errorf("core: failed to delete quick-log link: %w", err)
+		}
+	}
+	tot.QuickLog = totModels.QuickLogSettings{}
+	return nil
+}
+
+// QuickLogSignature signs the current link for a kind, or returns "" if the kind has no link.
+func QuickLogSignature(tot *totModels.Tot, key int64) string {
+	nonce, ok := tot.QuickLog.Links[key]
+	if !ok || tot.QuickLog.Secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(tot.QuickLog.Secret))
+	mac.Write([]byte(tot.ID + ":" + strconv.FormatInt(key, 10) + ":" + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// VerifyQuickLog reports whether sig is the signature of the current link for a kind.
+func VerifyQuickLog(tot *totModels.Tot, key int64, sig string) bool {
+	expected := QuickLogSignature(tot, key)
+	return expected != "" && hmac.Equal([]byte(expected), []byte(sig))
+}
+
+// QuickLogTally adds a tally from a quick-log link. It returns no tallies when the same kind
+// was logged within the debounce window, so a double tap or link preview doesn't log twice.
+func (s *Service) QuickLogTally(tot *totModels.Tot, key int64, now time.Time) ([]totModels.Tally, error) {
+	kind, ok := totConfig.TallyKindMap[key]
+	if !ok {
+		return nil, fmt.Errorf("core: unknown kind: %d", key)
+	}
+	if key == 13 {
+		kind = totConfig.TallyKindMap[12]
+	}
+	for _, t := range tot.Tallies {
+		if t.Time == nil || now.Sub(*t.Time) > s.config.QuickLogDebounce {
+			break
+		}
+		if t.Kind == kind {
+			return nil, nil
+		}
+	}
+
+	before := len(tot.Tallies)
+	if err := s.AddTally(tot, strconv.FormatInt(key, 10)); err != nil {
+		return nil, err
+	}
+	return append([]totModels.Tally(nil), tot.Tallies[:len(tot.Tallies)-before]...), nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+func TestQuickLog_Links(t *testing.T) {
+	s := setupCore(t)
+	s.config.LinkDirectory = t.TempDir()
+	tot := &totModels.Tot{ID: "tot-1"}
+
+	if err := s.CreateQuickLog(tot, 99); err == nil {
+		t.Error("expected error for unknown kind, got nil")
+	}
+	if err := s.CreateQuickLog(tot, 11); err != nil {
+		t.Fatalf("CreateQuickLog failed: %v", err)
+	}
+	ref := tot.QuickLog.Ref
+	if totID, err := s.store.LoadLink(ref); err != nil || totID != "tot-1" {
+		t.Fatalf("expected link to tot-1, got %q %v", totID, err)
+	}
+
+	sig := QuickLogSignature(tot, 11)
+	if !VerifyQuickLog(tot, 11, sig) || VerifyQuickLog(tot, 12, sig) || VerifyQuickLog(tot, 11, sig[1:]) {
+		t.Error("expected the signature to be valid for its kind only")
+	}
+	if other := (&totModels.Tot{ID: "tot-2", QuickLog: tot.QuickLog}); VerifyQuickLog(other, 11, sig) {
+		t.Error("expected the signature to be bound to the tot ID")
+	}
+
+	// Reissuing a link keeps the reference but invalidates the old signature.
+	s.CreateQuickLog(tot, 11)
+	if tot.QuickLog.Ref != ref || VerifyQuickLog(tot, 11, sig) {
+		t.Error("expected reissue to keep the reference and change the signature")
+	}
+
+	s.RevokeQuickLog(tot, 11)
+	if QuickLogSignature(tot, 11) != "" {
+		t.Error("expected revoked link to have no signature")
+	}
+
+	s.CreateQuickLog(tot, 12)
+	if err := s.RevokeAllQuickLogs(tot); err != nil {
+		t.Fatalf("RevokeAllQuickLogs failed: %v", err)
+	}
+	if tot.QuickLog.Ref != "" || len(tot.QuickLog.Links) != 0 {
+		t.Errorf("expected quick-log settings to be cleared, got %+v", tot.QuickLog)
+	}
+	if _, err := s.store.LoadLink(ref); err == nil {
+		t.Error("expected the old reference to be deleted")
+	}
+}
+
+func TestQuickLogTally_Debounce(t *testing.T) {
+	s := setupCore(t)
+	s.config.QuickLogDebounce = 30 * time.Second
+	tot := &totModels.Tot{ID: "tot-1"}
+
+	added, err := s.QuickLogTally(tot, 13, time.Now())
+	if err != nil || len(added) != 2 {
+		t.Fatalf("expected 2 tallies, got %v %v", added, err)
+	}
+	if added, _ := s.QuickLogTally(tot, 13, time.Now()); added != nil {
+		t.Errorf("expected a repeat to be debounced, got %v", added)
+	}
+	if added, _ := s.QuickLogTally(tot, 14, time.Now()); len(added) != 1 {
+		t.Errorf("expected a different kind to be logged, got %v", added)
+	}
+	if added, _ := s.QuickLogTally(tot, 13, time.Now().Add(time.Minute)); len(added) != 2 {
+		t.Errorf("expected a repeat after the window to be logged, got %v", added)
+	}
+	if _, err := s.QuickLogTally(tot, 99, time.Now()); err == nil {
+		t.Error("expected error for unknown kind, got nil")
+	}
+}
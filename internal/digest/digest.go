@@ -0,0 +1,224 @@
+// digest.go builds and sends the opt-in daily summary email: yesterday's totals compared with
+// the three days before, and the longest gaps between feeds and diapers.
+package digest
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+//go:embed digest.txt
+var digestText string
+
+var digestTemplate = template.Must(template.New("digest").Parse(digestText))
+
+// averageDays is how many days before yesterday its totals are compared with.
+const averageDays = 3
+
+// Data is the content of one digest.
+type Data struct {
+	Name  string
+	Day   string // e.g. "Thu 26 Oct".
+	Date  string // e.g. "2023-10-26", used to send each day once.
+	Rows  []Row
+	Gaps  []Gap
+	Empty bool // No tallies were recorded that day.
+	// Unsubscribe is the link that stops the digest.
+	Unsubscribe string
+}
+
+// Row is one category's total for the day.
+type Row struct {
+	Label  string
+	Value  string
+	Avg    string // "---" when none of the previous days has data.
+	Change string // e.g. "+20%"; empty when there is no average to compare with.
+}
+
+// Gap is the longest time between two events of a category.
+type Gap struct {
+	Label    string
+	Length   string
+	From, To string
+}
+
+// Build computes the digest for the tot day before the one containing now, with times in
+// timeFormat unless the tot chose its own clock or date order.
+func Build(engine *totStats.Engine, tot *totModels.Tot, tz *time.Location, now time.Time, timeFormat string) (Data, error) {
+	report, err := engine.Report(tot, tz, now, averageDays+2, totStats.Categories)
+	if err != nil {
+		return Data{}, err
+	}
+	display := totI18n.English.WithDisplay(tot.Display.Clock, tot.Display.DateOrder)
+	timeFormat = display.Layout(timeFormat)
+	day := report.Days[1]
+	data := Data{Name: tot.Name, Day: display.Format(day.Start, display.DateLayout(true, false)), Date: day.Start.Format(time.DateOnly), Empty: true}
+
+	for c, rc := range report.Categories {
+		sum, n := 0, 0
+		for _, prev := range report.Days[2:] {
+			if prev.HasData {
+				sum, n = sum+prev.Values[c], n+1
+			}
+		}
+		value := day.Values[c]
+		if value == 0 && sum == 0 {
+			continue
+		}
+		if value > 0 {
+			data.Empty = false
+		}
+
+		label := rc.Category.Label
+		if rc.Category.Unit != "" {
+			label += " (" + rc.Category.Unit + ")"
+		}
+		row := Row{Label: label, Value: strconv.Itoa(value), Avg: "---"}
+		if n > 0 {
+			avg := float64(sum) / float64(n)
+			row.Avg = strconv.FormatFloat(math.Round(avg*10)/10, 'f', -1, 64)
+			if avg > 0 {
+				row.Change = fmt.Sprintf("%+d%%", int((float64(value)-avg)/avg*100))
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	// Include the previous day, so a gap spanning midnight counts for the day it ended in.
+	end := report.Days[0].Start
+	for _, c := range []totStats.Category{totStats.FeedCategory, totStats.DiaperCategory} {
+		var longest Gap
+		var length time.Duration
+		events := engine.Timeline(tot, report.Days[2].Start, end, []totStats.Category{c})
+		for i := 1; i < len(events); i++ {
+			from, to := events[i-1].At, events[i].At
+			if to.Before(day.Start) || !to.Before(end) {
+				continue
+			}
+			if d := to.Sub(from); d > length {
+				length = d
+				longest = Gap{Label: c.Label, Length: totStats.FormatGap(d), From: display.Format(from.In(tz), timeFormat), To: display.Format(to.In(tz), timeFormat)}
+			}
+		}
+		if length > 0 {
+			data.Gaps = append(data.Gaps, longest)
+		}
+	}
+	return data, nil
+}
+
+// Render returns the subject and plain text body of a digest.
+func Render(data Data) (string, string, error) {
+	var b strings.Builder
+	if err := digestTemplate.Execute(&b, data); err != nil {
+		return "", "", fmt.Errorf("digest: failed to render: %w", err)
+	}
+	return fmt.Sprintf("Tot-Tally digest for %s, %s", data.Name, data.Day), b.String(), nil
+}
+
+// Scheduler periodically sends the previous day's digest to every tot that opted in.
+type Scheduler struct {
+	config *totConfig.Config
+	store  *totStorage.Repository
+	pool   *totShards.Pool
+	engine *totStats.Engine
+	mailer *totNotify.Mailer
+}
+
+// NewScheduler initializes the digest service.
+func NewScheduler(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool, engine *totStats.Engine, mailer *totNotify.Mailer) *Scheduler {
+	return &Scheduler{config: cfg, store: store, pool: pool, engine: engine, mailer: mailer}
+}
+
+// StartBackgroundDigest initiates a goroutine that checks for due digests every DigestInterval.
+func (s *Scheduler) StartBackgroundDigest(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.DigestInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sendAll(ctx)
+			case <-ctx.Done():
+				slog.Info("background digest scheduler stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) sendAll(ctx context.Context) {
+	ids, err := s.store.ListTotIDs()
+	if err != nil {
+		slog.Error("digest scheduling failed", "err", err)
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.sendTot(id, time.Now()); err != nil {
+			slog.Warn("digest failed for tot", "id", id, "err", err)
+		}
+	}
+}
+
+// sendTot sends the tot's digest once its local time reaches DigestHour, at most once per day.
+func (s *Scheduler) sendTot(id string, now time.Time) error {
+	mut := s.pool.GetShardMutex(id)
+	mut.Lock()
+
+	tot, err := s.store.LoadTot(id)
+	if err != nil {
+		mut.Unlock()
+		return err
+	}
+	tz, _ := time.LoadLocation(tot.Timezone)
+	// An address without a signing secret predates confirmation and was never confirmed.
+	if tot.Digest.Email == "" || tot.Mail.Secret == "" || now.In(tz).Hour() < s.config.DigestHour {
+		mut.Unlock()
+		return nil
+	}
+	data, err := Build(s.engine, tot, tz, now, s.config.TimeFormat)
+	if err != nil || data.Date == tot.Digest.SentFor {
+		mut.Unlock()
+		return err
+	}
+	to := tot.Digest.Email
+	data.Unsubscribe = totCore.MailLink(s.config, tot, totCore.MailDigest, totCore.MailUnsubscribe, to)
+	mut.Unlock()
+
+	// Send outside the lock so a slow relay never blocks requests for this tot.
+	subject, body, err := Render(data)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(to, subject, body, data.Unsubscribe); err != nil {
+		return err
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+	tot, err = s.store.LoadTot(id)
+	if err != nil {
+		return err
+	}
+	tot.Digest.SentFor = data.Date
+	return s.store.SaveTotWithoutActivity(tot)
+}
@@ -0,0 +1,182 @@
+package digest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	"tot-tally/internal/notify/notifytest"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with current output")
+
+// digestTot has tallies from Mon 23 through Thu 26 Oct 2023 in UTC.
+func digestTot() *totModels.Tot {
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2023, 10, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	return &totModels.Tot{
+		ID: "tot", Name: "👶", Timezone: "UTC", CreatedAt: *at(23, 0),
+		Tallies: []totModels.Tally{
+			{Time: at(26, 20), Kind: "💩"},
+			{Time: at(26, 12), Kind: "🤱L"},
+			{Time: at(26, 8), Kind: "🚽"},
+			{Time: at(26, 6), Kind: "🍼5"},
+			{Time: at(26, 1), Kind: "🍼4"},
+			{Time: at(25, 22), Kind: "🍼3"},
+			{Time: at(25, 10), Kind: "🚽"},
+			{Time: at(24, 10), Kind: "🍼3"},
+			{Time: at(23, 10), Kind: "🍼3"},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	engine := totStats.NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 9, 0, 0, 0, time.UTC)
+
+	data, err := Build(engine, digestTot(), time.UTC, now, "03:04PM")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if data.Day != "Thu 26 Oct" || data.Date != "2023-10-26" || data.Empty {
+		t.Errorf("unexpected day: %+v", data)
+	}
+	if len(data.Rows) != 4 {
+		t.Fatalf("expected rows for bottle, nursing, wet and dirty diapers, got %+v", data.Rows)
+	}
+	if r := data.Rows[0]; r.Label != "Bottle (oz)" || r.Value != "9" || r.Avg != "3" || r.Change != "+200%" {
+		t.Errorf("unexpected bottle row: %+v", r)
+	}
+	if r := data.Rows[1]; r.Value != "1" || r.Avg != "0" || r.Change != "" {
+		t.Errorf("expected no change without a previous average, got %+v", r)
+	}
+	// The overnight gap from Wednesday counts for Thursday.
+	if len(data.Gaps) != 2 || data.Gaps[0] != (Gap{Label: "Feeds", Length: "6h 0m", From: "06:00AM", To: "12:00PM"}) ||
+		data.Gaps[1] != (Gap{Label: "Diapers", Length: "22h 0m", From: "10:00AM", To: "08:00AM"}) {
+		t.Errorf("unexpected gaps: %+v", data.Gaps)
+	}
+
+	// The tot's clock and date order replace the configured format.
+	tot := digestTot()
+	tot.Display = totModels.DisplaySettings{Clock: "24h", DateOrder: "mdy"}
+	data, _ = Build(engine, tot, time.UTC, now, "03:04PM")
+	if data.Day != "Thu Oct 26" || data.Gaps[0].From != "Oct 26 06:00" || data.Gaps[0].To != "Oct 26 12:00" {
+		t.Errorf("unexpected display: %s %+v", data.Day, data.Gaps[0])
+	}
+
+	// Nothing recorded yesterday still reports the averages.
+	data, _ = Build(engine, digestTot(), time.UTC, now.AddDate(0, 0, 1), "03:04PM")
+	if !data.Empty || data.Date != "2023-10-27" {
+		t.Errorf("expected an empty day, got %+v", data)
+	}
+}
+
+func TestRender_Golden(t *testing.T) {
+	engine := totStats.NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 9, 0, 0, 0, time.UTC)
+	data, _ := Build(engine, digestTot(), time.UTC, now, "02 Jan 03:04PM")
+	data.Unsubscribe = "https://tally.example.com/m/ref/digest/unsubscribe/sig"
+
+	subject, body, err := Render(data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if subject != "Tot-Tally digest for 👶, Thu 26 Oct" {
+		t.Errorf("unexpected subject: %s", subject)
+	}
+
+	path := filepath.Join("testdata", "digest.golden.txt")
+	if *update {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if body != string(want) {
+		t.Errorf("output does not match %s (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, body, want)
+	}
+}
+
+func TestSendTot(t *testing.T) {
+	srv := notifytest.NewSMTPServer(t)
+	cfg := &totConfig.Config{
+		TotDirectory: t.TempDir(), MaxTallies: 20, TimeFormat: "02 Jan 03:04PM",
+		SMTPAddr: srv.Addr, SMTPFrom: "tot@example.com", DigestHour: 7,
+	}
+	pool := totShards.NewPool(4)
+	repo := totStorage.NewRepository(cfg, pool)
+	s := NewScheduler(cfg, repo, pool, totStats.NewEngine(cfg), totNotify.NewMailer(cfg))
+
+	tot := digestTot()
+	if err := repo.SaveTotWithoutActivity(tot); err != nil {
+		t.Fatalf("SaveTotWithoutActivity failed: %v", err)
+	}
+	send := func(now time.Time) {
+		t.Helper()
+		if err := s.sendTot("tot", now); err != nil {
+			t.Fatalf("sendTot failed: %v", err)
+		}
+	}
+
+	// Opted out.
+	send(time.Date(2023, 10, 27, 9, 0, 0, 0, time.UTC))
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("expected no mail without an address, got %d", n)
+	}
+
+	// An address saved before confirmation existed has no signing secret, so was never confirmed.
+	tot.Digest.Email = "parent@example.com"
+	repo.SaveTotWithoutActivity(tot)
+	send(time.Date(2023, 10, 27, 9, 0, 0, 0, time.UTC))
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("expected no mail to an unconfirmed address, got %d", n)
+	}
+
+	tot.Mail = totModels.MailSettings{Ref: "ref", Secret: "secret", BaseURL: "https://tally.example.com"}
+	repo.SaveTotWithoutActivity(tot)
+
+	// Before DigestHour.
+	send(time.Date(2023, 10, 27, 6, 0, 0, 0, time.UTC))
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("expected no mail before the digest hour, got %d", n)
+	}
+
+	// Sent once per day.
+	send(time.Date(2023, 10, 27, 9, 0, 0, 0, time.UTC))
+	send(time.Date(2023, 10, 27, 9, 15, 0, 0, time.UTC))
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 mail, got %d", len(msgs))
+	}
+	if msgs[0].To[0] != "parent@example.com" || !strings.Contains(msgs[0].Data, "Bottle (oz)") {
+		t.Errorf("unexpected mail: %+v", msgs[0])
+	}
+	unsubscribe := totCore.MailLink(cfg, tot, totCore.MailDigest, totCore.MailUnsubscribe, "parent@example.com")
+	if !strings.HasPrefix(unsubscribe, "https://tally.example.com/m/ref/digest/unsubscribe/") ||
+		!strings.Contains(msgs[0].Data, "List-Unsubscribe: <"+unsubscribe+">") || !strings.Contains(msgs[0].Data, "Stop these emails: "+unsubscribe) {
+		t.Errorf("expected the unsubscribe link %s in the mail, got %q", unsubscribe, msgs[0].Data)
+	}
+	loaded, _ := repo.LoadTot("tot")
+	if loaded.Digest.SentFor != "2023-10-26" {
+		t.Errorf("expected the sent day to be recorded, got %q", loaded.Digest.SentFor)
+	}
+
+	send(time.Date(2023, 10, 28, 9, 0, 0, 0, time.UTC))
+	if n := len(srv.Messages()); n != 2 {
+		t.Errorf("expected the next day's digest, got %d mails", n)
+	}
+}
@@ -0,0 +1,225 @@
+// de.go is the German catalog.
+package i18n
+
+// German uses a 24-hour clock and day-first dates.
+var German = &Locale{
+	Tag:        "de",
+	Name:       "Deutsch",
+	TimeFormat: "02. Jan 15:04",
+	HourFormat: "15:00",
+	ClockTime:  "15:04",
+	Clock:      Clock24,
+	DayMonth:   "02. Jan",
+	MonthDay:   "Jan 02",
+	Decimal:    ",",
+	Group:      ".",
+	Months: [12]string{
+		"Januar", "Februar", "März", "April", "Mai", "Juni",
+		"Juli", "August", "September", "Oktober", "November", "Dezember",
+	},
+	ShortMonths:   [12]string{"Jan", "Feb", "März", "Apr", "Mai", "Juni", "Juli", "Aug", "Sept", "Okt", "Nov", "Dez"},
+	Weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	ShortWeekdays: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	AM:            "AM",
+	PM:            "PM",
+	messages: map[string]string{
+		"flash.tally":            "Eintrag hinzugefügt!",
+		"flash.undo":             "Eintrag rückgängig gemacht",
+		"flash.updated":          "Einstellungen gespeichert",
+		"flash.deleted":          "Kind gelöscht",
+		"flash.confirm_email":    "Bestätigungslink per E-Mail gesendet",
+		"flash.error_alerts":     "Fehler: Ungültige Warnungseinstellungen!",
+		"flash.error_webhook":    "Fehler: Ungültiger Webhook!",
+		"flash.error_digest":     "Fehler: Ungültige E-Mail-Adresse für die Zusammenfassung!",
+		"flash.error_display":    "Fehler: Ungültige Anzeigeeinstellungen!",
+		"flash.journal":          "Tagebucheintrag hinzugefügt!",
+		"flash.journal_removed":  "Tagebucheintrag entfernt",
+		"flash.error_journal":    "Fehler: Ungültiger Tagebucheintrag!",
+		"flash.error_timezone":   "Fehler: Ungültiger Zeitzonenwechsel!",
+		"flash.error_limit":      "Fehler: Zu viele Anfragen!",
+		"flash.error_limit_ip":   "Fehler: Limit für diese IP erreicht!",
+		"flash.error_not_found":  "Fehler: Kind nicht gefunden!",
+		"flash.error_unexpected": "Fehler: Unerwarteter Fehler!",
+
+		"home.tagline":    "Behalte im Blick, was bei deinem Kind rein- und rausgeht!",
+		"home.no_login":   "Keine Anmeldung, kein Passwort",
+		"home.bookmark":   "Speichere die erzeugte URL als Lesezeichen",
+		"home.demo":       "Hier klicken für ein Demo-Video",
+		"home.tot":        "Kind",
+		"home.submit":     "Los geht's!",
+		"milk.title":      "Milcheinstellung",
+		"milk.bottle":     "Nur Flasche",
+		"milk.nursing":    "Nur Stillen",
+		"milk.both":       "Beides",
+		"milk.is.bottle":  "Flasche",
+		"milk.is.nursing": "Stillen",
+		"milk.is.both":    "Beides",
+		"milk.update":     "Milcheinstellung speichern",
+
+		"tot.milk":       "Milch",
+		"tot.soils":      "Windeln",
+		"tot.food":       "Essen",
+		"tot.hygiene":    "Pflege",
+		"tot.last":       "Zuletzt %s: %s",
+		"tally.left":     "🤱 Links",
+		"tally.right":    "🤱 Rechts",
+		"tally.pee":      "Pipi",
+		"tally.poo":      "Kacka",
+		"tally.both":     "Beides",
+		"tally.snack":    "Snack",
+		"tally.meal":     "Mahlzeit",
+		"tally.bath":     "Baden",
+		"tally.brush":    "Zähneputzen",
+		"time.not_yet":   "noch nicht",
+		"time.just_now":  "gerade eben",
+		"time.m_ago":     "vor %d Min.",
+		"time.h_ago":     "vor %d Std.",
+		"time.hm_ago":    "vor %d Std. %d Min.",
+		"time.d_ago":     "vor %d T.",
+		"predict.feed":   "Nächste Mahlzeit erwartet ~%s",
+		"predict.diaper": "Nächste Windel erwartet ~%s",
+		"late.feed":      "Mahlzeit erwartet seit ~%s",
+		"late.diaper":    "Windel erwartet seit ~%s",
+		"unusual.feed":   "⚠️ %d Std. %d Min. seit der letzten Mahlzeit, länger als üblich",
+		"unusual.diaper": "⚠️ %d Std. %d Min. seit der letzten Windel, länger als üblich",
+		"alert.feed":     "Mahlzeit",
+		"alert.diaper":   "Windel",
+		"alert.pee":      "Nasse Windel",
+		"alert.poo":      "Volle Windel",
+		"alert.last":     "%s überfällig: zuletzt vor %s (Warnung nach %s)",
+		"alert.none":     "%s überfällig: keine in %s erfasst (Warnung nach %s)",
+		"alert.title":    "%s: %s überfällig",
+		"duration.hm":    "%d Std. %d Min.",
+		"duration.m":     "%d Min.",
+
+		"stats.title":     "Statistik",
+		"stats.12h":       "12 Stunden",
+		"stats.24h":       "24 Stunden",
+		"stats.today":     "Heute",
+		"stats.yesterday": "Gestern",
+		"stats.2d":        "Vor 2 Tagen",
+		"stats.3d":        "Vor 3 Tagen",
+		"stats.avg":       "3-Tage-Schnitt",
+		"stats.avg_gap":   "Ø Abstand",
+		"stats.avg_help":  "Berechnet aus den letzten 3 Tagen (ab %s). Erfordert mindestens 4 Tage Verlauf.",
+		"chart.24h":       "Letzte 24 Stunden",
+		"chart.per_day":   "%s / Tag",
+		"chart.milk":      "Milch (oz) pro Tag",
+		"chart.nurse":     "Stillmahlzeiten pro Tag",
+		"chart.diapers":   "Windeln pro Tag",
+		"report.week":     "Wochenbericht",
+		"report.month":    "Monatsbericht",
+		"report.summary":  "Arztbericht",
+
+		"tallies.title":        "Einträge",
+		"tallies.confirm":      "Rückgängig machen bestätigen?",
+		"tallies.confirm_help": "Bitte aktivieren, um rückgängig zu machen",
+		"tallies.undo":         "Letzten Eintrag rückgängig machen",
+		"tallies.time":         "Zeit",
+		"tallies.tally":        "Eintrag",
+		"tallies.limit":        "Nur die letzten %s Einträge werden gespeichert",
+
+		"journal.title":      "Tagebuch",
+		"journal.help":       "Schlaf, Medikamente, Wachstum und Notizen für die Zusammenfassung zum Arztbesuch festhalten.",
+		"journal.sleep":      "😴 Schlaf",
+		"journal.medication": "💊 Medikament",
+		"journal.growth":     "📏 Wachstum",
+		"journal.note":       "📝 Notiz",
+		"journal.start":      "Eingeschlafen",
+		"journal.end":        "Aufgewacht (leer für jetzt)",
+		"journal.time":       "Zeit (leer für jetzt)",
+		"journal.name":       "Medikament",
+		"journal.dose":       "Dosis (z. B. 2,5 ml)",
+		"journal.weight":     "Gewicht (kg)",
+		"journal.length":     "Länge (cm)",
+		"journal.head":       "Kopfumfang (cm)",
+		"journal.text":       "Notiz",
+		"journal.add":        "Zum Tagebuch hinzufügen",
+		"journal.remove":     "Entfernen",
+		"journal.entry":      "Eintrag",
+		"journal.detail":     "Details",
+		"journal.kg":         "%s kg",
+		"journal.length_cm":  "%s cm lang",
+		"journal.head_cm":    "Kopf %s cm",
+		"journal.limit":      "Nur die letzten %s Einträge werden gespeichert",
+
+		"settings.title":   "Einstellungen",
+		"settings.current": "Aktuell: %s",
+
+		"language.title":  "Sprache",
+		"language.help":   "Automatisch folgt der Sprache des jeweiligen Browsers.",
+		"language.auto":   "Automatisch",
+		"language.update": "Sprache speichern",
+
+		"display.title":    "Zeitanzeige",
+		"display.help":     "Automatisch folgt der Sprache der Seite.",
+		"display.clock":    "Uhr",
+		"display.12h":      "12 Stunden (3:04 PM)",
+		"display.24h":      "24 Stunden (15:04)",
+		"display.dates":    "Datum",
+		"display.dmy":      "Tag zuerst (31. Jan)",
+		"display.mdy":      "Monat zuerst (Jan 31)",
+		"display.history":  "Zeit der Einträge",
+		"display.absolute": "Datum und Uhrzeit",
+		"display.relative": "Vergangene Zeit (vor 2 Std. 5 Min.)",
+		"display.update":   "Zeitanzeige speichern",
+
+		"timezone.title":  "Zeitzone",
+		"timezone.travel": "Auf Reisen? Beim Wechsel bleiben frühere Einträge an den Tagen, an denen sie passiert sind; erst Einträge ab dem Wechsel nutzen die neue Zeitzone. Falls du den Wechsel bei der Ankunft vergessen hast, gib die Ankunftszeit in der neuen Ortszeit an.",
+		"timezone.since":  "Seit (optional, neue Ortszeit)",
+		"timezone.update": "Zeitzone speichern",
+		"timezone.recent": "Letzte Wechsel:",
+
+		"day.title":    "Tag beginnt um",
+		"day.help":     "Tagessummen, Durchschnitte und Abstände zählen ab dieser Stunde, damit nächtliche Mahlzeiten zusammenbleiben.",
+		"day.midnight": "%s (Mitternacht)",
+		"day.noon":     "%s (Mittag)",
+		"day.update":   "Tagesbeginn speichern",
+
+		"alerts.title":     "Warnungen",
+		"alerts.help":      "Warnen, wenn eine Aktivität so viele Stunden nicht erfasst wurde. Leer lassen zum Deaktivieren.",
+		"alerts.notify":    "Benachrichtigen",
+		"alerts.page":      "Nur auf dieser Seite",
+		"alerts.ntfy":      "ntfy-Topic-URL",
+		"alerts.webhook":   "Webhook-URL",
+		"alerts.email":     "E-Mail",
+		"alerts.target":    "URL oder E-Mail-Adresse",
+		"alerts.update":    "Warnungen speichern",
+		"digest.title":     "Tägliche Zusammenfassung",
+		"digest.help":      "Jeden Morgen die Summen von gestern und die längsten Abstände per E-Mail. Leer lassen zum Deaktivieren.",
+		"digest.email":     "E-Mail-Adresse",
+		"digest.update":    "Zusammenfassung speichern",
+		"mail.pending":     "Wartet auf Bestätigung durch %s.",
+		"webhooks.title":   "Webhooks",
+		"webhooks.help":    "Sendet ein signiertes JSON-Ereignis per POST an deine URL, wenn ein Eintrag hinzugefügt oder rückgängig gemacht oder eine Einstellung geändert wird.",
+		"webhooks.secret":  "Geheimnis:",
+		"webhooks.remove":  "Entfernen",
+		"webhooks.url":     "URL",
+		"webhooks.new":     "Gemeinsames Geheimnis (leer lassen zum Erzeugen)",
+		"webhooks.add":     "Webhook hinzufügen",
+		"webhooks.event":   "Ereignis",
+		"webhooks.tries":   "Versuche",
+		"webhooks.result":  "Ergebnis",
+		"webhooks.removed": "(entfernt)",
+		"quicklog.title":   "Schnell-Links",
+		"quicklog.help":    "Öffne einen Link, um mit einem Tipp einen Eintrag zu erfassen. Schreibe ihn auf ein NFC-Tag oder einen Smart Button. Links verraten diese Seite nicht.",
+		"quicklog.revoke":  "Widerrufen",
+		"quicklog.create":  "Link erstellen",
+		"quicklog.all":     "Alle Links widerrufen",
+		"feeds.title":      "Nur-Lese-Feeds",
+		"feeds.help":       "Verfolge die letzten Einträge in einer Kalender-App oder einem Feedreader. Feed-Links können keine Einträge hinzufügen oder ändern.",
+		"feeds.calendar":   "Kalender",
+		"feeds.atom":       "Atom",
+		"feeds.rotate":     "Neuer Link",
+		"feeds.disable":    "Deaktivieren",
+		"feeds.create":     "Feed-Link erstellen",
+		"data.title":       "Daten",
+		"data.help":        "Lade eine Sicherung deiner Rohdaten herunter.",
+		"data.export":      "Daten exportieren",
+		"delete.title":     "Kind löschen",
+		"delete.help":      "Kinder werden nach 6 Monaten Inaktivität automatisch gelöscht.",
+		"delete.confirm":   "Löschen bestätigen? (Kann nicht rückgängig gemacht werden)",
+		"delete.check":     "Bitte aktivieren, um dieses Kind zu löschen",
+		"delete.button":    "Dieses Kind löschen",
+	},
+}
@@ -0,0 +1,65 @@
+// display.go adjusts a locale to a tot's clock and date order preferences.
+package i18n
+
+import "cmp"
+
+// Clocks a tot can choose between.
+const (
+	Clock12 = "12h"
+	Clock24 = "24h"
+)
+
+// Date orders a tot can choose between.
+const (
+	DayFirst   = "dmy"
+	MonthFirst = "mdy"
+)
+
+// clockLayouts are the ClockTime and HourFormat of each clock.
+var clockLayouts = map[string][2]string{
+	Clock12: {"3:04 PM", "3 PM"},
+	Clock24: {"15:04", "15:00"},
+}
+
+// ValidClock reports whether clock is a supported clock, or empty to follow the locale.
+func ValidClock(clock string) bool {
+	_, ok := clockLayouts[clock]
+	return ok || clock == ""
+}
+
+// ValidDateOrder reports whether order is a supported date order, or empty to follow the locale.
+func ValidDateOrder(order string) bool {
+	return order == "" || order == DayFirst || order == MonthFirst
+}
+
+// WithDisplay returns l using the given clock and date order; empty values keep the locale's own.
+// Once either is set the tally layout is built from them, so config.TimeFormat no longer applies.
+func (l *Locale) WithDisplay(clock, order string) *Locale {
+	if clock == "" && order == "" {
+		return l
+	}
+	c := *l
+	c.Clock = cmp.Or(clock, l.Clock)
+	c.ClockTime, c.HourFormat = clockLayouts[c.Clock][0], clockLayouts[c.Clock][1]
+	if order != "" {
+		c.MonthFirst = order == MonthFirst
+	}
+	c.TimeFormat = c.DateLayout(false, false) + " " + c.ClockTime
+	return &c
+}
+
+// DateLayout is the layout of a date in the locale's order, optionally with the weekday before
+// and the year after, e.g. "Mon 02 Jan 2006".
+func (l *Locale) DateLayout(weekday, year bool) string {
+	layout := l.DayMonth
+	if l.MonthFirst {
+		layout = l.MonthDay
+	}
+	if weekday {
+		layout = "Mon " + layout
+	}
+	if year {
+		layout += " 2006"
+	}
+	return layout
+}
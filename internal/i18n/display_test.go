@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithDisplay(t *testing.T) {
+	at := time.Date(2023, 3, 5, 15, 4, 0, 0, time.UTC)
+	if English.WithDisplay("", "") != English {
+		t.Error("expected no preferences to keep the locale")
+	}
+	tests := []struct {
+		locale       *Locale
+		clock, order string
+		want, hour   string
+		date         string
+	}{
+		{English, Clock24, "", "05 Mar 15:04", "15:00", "Sun 05 Mar 2023"},
+		{English, "", MonthFirst, "Mar 05 3:04 PM", "3 PM", "Sun Mar 05 2023"},
+		{German, Clock12, "", "05. März 3:04 PM", "3 PM", "So 05. März 2023"},
+		{Spanish, Clock24, MonthFirst, "mar 05 15:04", "15:00", "dom mar 05 2023"},
+	}
+	for _, tt := range tests {
+		l := tt.locale.WithDisplay(tt.clock, tt.order)
+		if got := l.Format(at, l.Layout("02 Jan 03:04PM")); got != tt.want {
+			t.Errorf("%s %q %q: got %q, want %q", tt.locale.Tag, tt.clock, tt.order, got, tt.want)
+		}
+		if got := l.Hour(15); got != tt.hour {
+			t.Errorf("%s %q: got hour %q, want %q", tt.locale.Tag, tt.clock, got, tt.hour)
+		}
+		if got := l.Format(at, l.DateLayout(true, true)); got != tt.date {
+			t.Errorf("%s %q: got date %q, want %q", tt.locale.Tag, tt.order, got, tt.date)
+		}
+	}
+	if English.WithDisplay(Clock24, "").TimeFormat == "" || English.TimeFormat != "" {
+		t.Error("expected WithDisplay to copy the locale")
+	}
+}
+
+func TestValidDisplay(t *testing.T) {
+	for _, clock := range []string{"", Clock12, Clock24} {
+		if !ValidClock(clock) {
+			t.Errorf("expected clock %q to be valid", clock)
+		}
+	}
+	for _, order := range []string{"", DayFirst, MonthFirst} {
+		if !ValidDateOrder(order) {
+			t.Errorf("expected order %q to be valid", order)
+		}
+	}
+	if ValidClock("13h") || ValidDateOrder("ymd") {
+		t.Error("expected unknown preferences to be invalid")
+	}
+}
@@ -0,0 +1,224 @@
+// en.go is the English catalog, the reference every other catalog must cover.
+package i18n
+
+// English is the default locale.
+var English = &Locale{
+	Tag:        "en",
+	Name:       "English",
+	HourFormat: "3 PM",
+	ClockTime:  "3:04 PM",
+	Clock:      Clock12,
+	DayMonth:   "02 Jan",
+	MonthDay:   "Jan 02",
+	Decimal:    ".",
+	Group:      ",",
+	Months: [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+	ShortMonths:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	Weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	ShortWeekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	AM:            "AM",
+	PM:            "PM",
+	messages: map[string]string{
+		"flash.tally":            "Tally Added!",
+		"flash.undo":             "Tally Undone",
+		"flash.updated":          "Settings Updated",
+		"flash.deleted":          "Tot Deleted",
+		"flash.confirm_email":    "Check your inbox for a confirmation link",
+		"flash.error_alerts":     "Error: Invalid alert settings!",
+		"flash.error_webhook":    "Error: Invalid webhook!",
+		"flash.error_digest":     "Error: Invalid digest email address!",
+		"flash.error_display":    "Error: Invalid display settings!",
+		"flash.journal":          "Journal Entry Added!",
+		"flash.journal_removed":  "Journal Entry Removed",
+		"flash.error_journal":    "Error: Invalid journal entry!",
+		"flash.error_timezone":   "Error: Invalid timezone change!",
+		"flash.error_limit":      "Error: Too many requests!",
+		"flash.error_limit_ip":   "Error: Tot limit reached for this IP!",
+		"flash.error_not_found":  "Error: Tot not found!",
+		"flash.error_unexpected": "Error: Unexpected error!",
+
+		"home.tagline":    "Keep a tally of tot's ins and outs!",
+		"home.no_login":   "No login or password required",
+		"home.bookmark":   "Bookmark the generated URL",
+		"home.demo":       "Click here for a demo video",
+		"home.tot":        "Tot",
+		"home.submit":     "Tally-go!",
+		"milk.title":      "Milk Setting",
+		"milk.bottle":     "Bottle Only",
+		"milk.nursing":    "Nursing Only",
+		"milk.both":       "Both",
+		"milk.is.bottle":  "Bottle",
+		"milk.is.nursing": "Nursing",
+		"milk.is.both":    "Both",
+		"milk.update":     "Update Milk Setting",
+
+		"tot.milk":       "Milk",
+		"tot.soils":      "Soils",
+		"tot.food":       "Food",
+		"tot.hygiene":    "Hygiene",
+		"tot.last":       "Last %s: %s",
+		"tally.left":     "🤱L Side",
+		"tally.right":    "🤱R Side",
+		"tally.pee":      "Pee",
+		"tally.poo":      "Poo",
+		"tally.both":     "Both",
+		"tally.snack":    "Snack",
+		"tally.meal":     "Meal",
+		"tally.bath":     "Bath",
+		"tally.brush":    "Brush",
+		"time.not_yet":   "not yet",
+		"time.just_now":  "just now",
+		"time.m_ago":     "%dm ago",
+		"time.h_ago":     "%dh ago",
+		"time.hm_ago":    "%dh %dm ago",
+		"time.d_ago":     "%dd ago",
+		"predict.feed":   "Next feed expected ~%s",
+		"predict.diaper": "Next diaper expected ~%s",
+		"late.feed":      "Feed expected since ~%s",
+		"late.diaper":    "Diaper expected since ~%s",
+		"unusual.feed":   "⚠️ %dh %dm since last feed, longer than usual",
+		"unusual.diaper": "⚠️ %dh %dm since last diaper, longer than usual",
+		"alert.feed":     "Feed",
+		"alert.diaper":   "Diaper",
+		"alert.pee":      "Wet diaper",
+		"alert.poo":      "Dirty diaper",
+		"alert.last":     "%s overdue: last %s ago (alert after %s)",
+		"alert.none":     "%s overdue: none recorded in %s (alert after %s)",
+		"alert.title":    "%s %s overdue",
+		"duration.hm":    "%dh %dm",
+		"duration.m":     "%dm",
+
+		"stats.title":     "Stats",
+		"stats.12h":       "12 Hours",
+		"stats.24h":       "24 Hours",
+		"stats.today":     "Today",
+		"stats.yesterday": "Yesterday",
+		"stats.2d":        "2 Days Ago",
+		"stats.3d":        "3 Days Ago",
+		"stats.avg":       "3-Day Avg",
+		"stats.avg_gap":   "Avg Gap",
+		"stats.avg_help":  "Calculated using data from previous 3 days (starting at %s). Requires at least 4 days of history.",
+		"chart.24h":       "Last 24 Hours",
+		"chart.per_day":   "%s / Day",
+		"chart.milk":      "Milk (oz) per day",
+		"chart.nurse":     "Nursing sessions per day",
+		"chart.diapers":   "Diapers per day",
+		"report.week":     "Weekly Report",
+		"report.month":    "Monthly Report",
+		"report.summary":  "Visit Summary",
+
+		"tallies.title":        "Tallies",
+		"tallies.confirm":      "Confirm undo?",
+		"tallies.confirm_help": "Please check this box if you want to undo",
+		"tallies.undo":         "Undo Latest Tally",
+		"tallies.time":         "Time",
+		"tallies.tally":        "Tally",
+		"tallies.limit":        "Only the latest %s tallies are saved",
+
+		"journal.title":      "Journal",
+		"journal.help":       "Log sleep, medications, growth and notes for the visit summary.",
+		"journal.sleep":      "😴 Sleep",
+		"journal.medication": "💊 Medication",
+		"journal.growth":     "📏 Growth",
+		"journal.note":       "📝 Note",
+		"journal.start":      "Fell asleep",
+		"journal.end":        "Woke up (blank for now)",
+		"journal.time":       "Time (blank for now)",
+		"journal.name":       "Medication",
+		"journal.dose":       "Dose (e.g. 2.5 ml)",
+		"journal.weight":     "Weight (kg)",
+		"journal.length":     "Length (cm)",
+		"journal.head":       "Head circumference (cm)",
+		"journal.text":       "Note",
+		"journal.add":        "Add to Journal",
+		"journal.remove":     "Remove",
+		"journal.entry":      "Entry",
+		"journal.detail":     "Details",
+		"journal.kg":         "%s kg",
+		"journal.length_cm":  "%s cm long",
+		"journal.head_cm":    "head %s cm",
+		"journal.limit":      "Only the latest %s entries are saved",
+
+		"settings.title":   "Settings",
+		"settings.current": "Current: %s",
+
+		"language.title":  "Language",
+		"language.help":   "Automatic follows the language of each browser.",
+		"language.auto":   "Automatic",
+		"language.update": "Update Language",
+
+		"display.title":    "Time Display",
+		"display.help":     "Automatic follows the language of the page.",
+		"display.clock":    "Clock",
+		"display.12h":      "12-hour (3:04 PM)",
+		"display.24h":      "24-hour (15:04)",
+		"display.dates":    "Dates",
+		"display.dmy":      "Day first (31 Jan)",
+		"display.mdy":      "Month first (Jan 31)",
+		"display.history":  "Tally times",
+		"display.absolute": "Date and time",
+		"display.relative": "Time ago (2h 5m ago)",
+		"display.update":   "Update Time Display",
+
+		"timezone.title":  "Timezone",
+		"timezone.travel": "Travelling? Switching keeps past tallies on the days they happened; only tallies from the switch onwards use the new timezone. If you forgot to switch on arrival, set when you arrived in the new local time.",
+		"timezone.since":  "Since (optional, new local time)",
+		"timezone.update": "Update Timezone",
+		"timezone.recent": "Recent switches:",
+
+		"day.title":    "Day Starts At",
+		"day.help":     "Daily totals, averages and gaps count from this hour, so night feeds stay together.",
+		"day.midnight": "%s (midnight)",
+		"day.noon":     "%s (noon)",
+		"day.update":   "Update Day Start",
+
+		"alerts.title":     "Alerts",
+		"alerts.help":      "Warn when an activity hasn't been logged for this many hours. Leave blank to disable.",
+		"alerts.notify":    "Notify",
+		"alerts.page":      "Only on this page",
+		"alerts.ntfy":      "ntfy topic URL",
+		"alerts.webhook":   "Webhook URL",
+		"alerts.email":     "Email",
+		"alerts.target":    "URL or email address",
+		"alerts.update":    "Update Alerts",
+		"digest.title":     "Daily Digest",
+		"digest.help":      "Email yesterday's totals and longest gaps each morning. Leave blank to disable.",
+		"digest.email":     "Email address",
+		"digest.update":    "Update Digest",
+		"mail.pending":     "Waiting for %s to confirm.",
+		"webhooks.title":   "Webhooks",
+		"webhooks.help":    "POST a signed JSON event to your URL whenever a tally is added or undone, or a setting changes.",
+		"webhooks.secret":  "Secret:",
+		"webhooks.remove":  "Remove",
+		"webhooks.url":     "URL",
+		"webhooks.new":     "Shared secret (leave blank to generate)",
+		"webhooks.add":     "Add Webhook",
+		"webhooks.event":   "Event",
+		"webhooks.tries":   "Tries",
+		"webhooks.result":  "Result",
+		"webhooks.removed": "(removed)",
+		"quicklog.title":   "Quick-Log Links",
+		"quicklog.help":    "Open a link to log a tally in one tap. Write it to an NFC tag or a smart button. Links don't reveal this page.",
+		"quicklog.revoke":  "Revoke",
+		"quicklog.create":  "Create Link",
+		"quicklog.all":     "Revoke All Links",
+		"feeds.title":      "Read-Only Feeds",
+		"feeds.help":       "Follow recent tallies from a calendar app or feed reader. Feed links can't add or change tallies.",
+		"feeds.calendar":   "Calendar",
+		"feeds.atom":       "Atom",
+		"feeds.rotate":     "New Link",
+		"feeds.disable":    "Disable",
+		"feeds.create":     "Create Feed Link",
+		"data.title":       "Data",
+		"data.help":        "Download a raw backup of your data.",
+		"data.export":      "Export Data",
+		"delete.title":     "Delete Tot",
+		"delete.help":      "Tots are automatically deleted after 6 months of inactivity.",
+		"delete.confirm":   "Confirm deletion? (Can't be undone)",
+		"delete.check":     "Please check this box if you want to delete this Tot",
+		"delete.button":    "Delete This Tot",
+	},
+}
@@ -0,0 +1,225 @@
+// es.go is the Spanish catalog.
+package i18n
+
+// Spanish uses a 24-hour clock and day-first dates.
+var Spanish = &Locale{
+	Tag:        "es",
+	Name:       "Español",
+	TimeFormat: "02 Jan 15:04",
+	HourFormat: "15:00",
+	ClockTime:  "15:04",
+	Clock:      Clock24,
+	DayMonth:   "02 Jan",
+	MonthDay:   "Jan 02",
+	Decimal:    ",",
+	Group:      ".",
+	Months: [12]string{
+		"enero", "febrero", "marzo", "abril", "mayo", "junio",
+		"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+	},
+	ShortMonths:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	Weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	ShortWeekdays: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	AM:            "a. m.",
+	PM:            "p. m.",
+	messages: map[string]string{
+		"flash.tally":            "¡Registro añadido!",
+		"flash.undo":             "Registro deshecho",
+		"flash.updated":          "Ajustes actualizados",
+		"flash.deleted":          "Peque eliminado",
+		"flash.confirm_email":    "Revisa tu correo para confirmar la dirección",
+		"flash.error_alerts":     "Error: ¡Ajustes de alertas no válidos!",
+		"flash.error_webhook":    "Error: ¡Webhook no válido!",
+		"flash.error_digest":     "Error: ¡Correo del resumen no válido!",
+		"flash.error_display":    "Error: ¡Ajustes de visualización no válidos!",
+		"flash.journal":          "¡Entrada añadida al diario!",
+		"flash.journal_removed":  "Entrada del diario eliminada",
+		"flash.error_journal":    "Error: ¡Entrada de diario no válida!",
+		"flash.error_timezone":   "Error: ¡Cambio de zona horaria no válido!",
+		"flash.error_limit":      "Error: ¡Demasiadas solicitudes!",
+		"flash.error_limit_ip":   "Error: ¡Límite de peques alcanzado para esta IP!",
+		"flash.error_not_found":  "Error: ¡Peque no encontrado!",
+		"flash.error_unexpected": "Error: ¡Error inesperado!",
+
+		"home.tagline":    "¡Lleva la cuenta de todo lo que entra y sale de tu peque!",
+		"home.no_login":   "Sin usuario ni contraseña",
+		"home.bookmark":   "Guarda la URL generada en favoritos",
+		"home.demo":       "Haz clic aquí para ver un vídeo de demostración",
+		"home.tot":        "Peque",
+		"home.submit":     "¡A contar!",
+		"milk.title":      "Ajuste de leche",
+		"milk.bottle":     "Solo biberón",
+		"milk.nursing":    "Solo pecho",
+		"milk.both":       "Ambos",
+		"milk.is.bottle":  "Biberón",
+		"milk.is.nursing": "Pecho",
+		"milk.is.both":    "Ambos",
+		"milk.update":     "Actualizar ajuste de leche",
+
+		"tot.milk":       "Leche",
+		"tot.soils":      "Pañales",
+		"tot.food":       "Comida",
+		"tot.hygiene":    "Higiene",
+		"tot.last":       "Último %s: %s",
+		"tally.left":     "🤱 Izquierdo",
+		"tally.right":    "🤱 Derecho",
+		"tally.pee":      "Pis",
+		"tally.poo":      "Caca",
+		"tally.both":     "Ambos",
+		"tally.snack":    "Merienda",
+		"tally.meal":     "Comida",
+		"tally.bath":     "Baño",
+		"tally.brush":    "Cepillado",
+		"time.not_yet":   "todavía no",
+		"time.just_now":  "ahora mismo",
+		"time.m_ago":     "hace %d min",
+		"time.h_ago":     "hace %d h",
+		"time.hm_ago":    "hace %d h %d min",
+		"time.d_ago":     "hace %d d",
+		"predict.feed":   "Próxima toma prevista ~%s",
+		"predict.diaper": "Próximo pañal previsto ~%s",
+		"late.feed":      "Toma prevista desde ~%s",
+		"late.diaper":    "Pañal previsto desde ~%s",
+		"unusual.feed":   "⚠️ %d h %d min desde la última toma, más de lo habitual",
+		"unusual.diaper": "⚠️ %d h %d min desde el último pañal, más de lo habitual",
+		"alert.feed":     "Toma",
+		"alert.diaper":   "Pañal",
+		"alert.pee":      "Pañal mojado",
+		"alert.poo":      "Pañal sucio",
+		"alert.last":     "%s atrasado: último hace %s (alerta tras %s)",
+		"alert.none":     "%s atrasado: ninguno registrado en %s (alerta tras %s)",
+		"alert.title":    "%s: %s atrasado",
+		"duration.hm":    "%d h %d min",
+		"duration.m":     "%d min",
+
+		"stats.title":     "Estadísticas",
+		"stats.12h":       "12 horas",
+		"stats.24h":       "24 horas",
+		"stats.today":     "Hoy",
+		"stats.yesterday": "Ayer",
+		"stats.2d":        "Hace 2 días",
+		"stats.3d":        "Hace 3 días",
+		"stats.avg":       "Media 3 días",
+		"stats.avg_gap":   "Intervalo medio",
+		"stats.avg_help":  "Calculado con los 3 días anteriores (desde las %s). Requiere al menos 4 días de historial.",
+		"chart.24h":       "Últimas 24 horas",
+		"chart.per_day":   "%s / día",
+		"chart.milk":      "Leche (oz) por día",
+		"chart.nurse":     "Tomas de pecho por día",
+		"chart.diapers":   "Pañales por día",
+		"report.week":     "Informe semanal",
+		"report.month":    "Informe mensual",
+		"report.summary":  "Resumen para la consulta",
+
+		"tallies.title":        "Registros",
+		"tallies.confirm":      "¿Confirmar deshacer?",
+		"tallies.confirm_help": "Marca esta casilla si quieres deshacer",
+		"tallies.undo":         "Deshacer último registro",
+		"tallies.time":         "Hora",
+		"tallies.tally":        "Registro",
+		"tallies.limit":        "Solo se guardan los últimos %s registros",
+
+		"journal.title":      "Diario",
+		"journal.help":       "Anota sueño, medicamentos, crecimiento y notas para el resumen de la consulta.",
+		"journal.sleep":      "😴 Sueño",
+		"journal.medication": "💊 Medicamento",
+		"journal.growth":     "📏 Crecimiento",
+		"journal.note":       "📝 Nota",
+		"journal.start":      "Se durmió",
+		"journal.end":        "Se despertó (en blanco para ahora)",
+		"journal.time":       "Hora (en blanco para ahora)",
+		"journal.name":       "Medicamento",
+		"journal.dose":       "Dosis (p. ej. 2,5 ml)",
+		"journal.weight":     "Peso (kg)",
+		"journal.length":     "Talla (cm)",
+		"journal.head":       "Perímetro cefálico (cm)",
+		"journal.text":       "Nota",
+		"journal.add":        "Añadir al diario",
+		"journal.remove":     "Quitar",
+		"journal.entry":      "Entrada",
+		"journal.detail":     "Detalles",
+		"journal.kg":         "%s kg",
+		"journal.length_cm":  "%s cm de talla",
+		"journal.head_cm":    "cabeza %s cm",
+		"journal.limit":      "Solo se guardan las últimas %s entradas",
+
+		"settings.title":   "Ajustes",
+		"settings.current": "Actual: %s",
+
+		"language.title":  "Idioma",
+		"language.help":   "Automático usa el idioma de cada navegador.",
+		"language.auto":   "Automático",
+		"language.update": "Actualizar idioma",
+
+		"display.title":    "Formato de hora",
+		"display.help":     "Automático sigue el idioma de la página.",
+		"display.clock":    "Reloj",
+		"display.12h":      "12 horas (3:04 p. m.)",
+		"display.24h":      "24 horas (15:04)",
+		"display.dates":    "Fechas",
+		"display.dmy":      "Día primero (31 ene)",
+		"display.mdy":      "Mes primero (ene 31)",
+		"display.history":  "Hora de los registros",
+		"display.absolute": "Fecha y hora",
+		"display.relative": "Tiempo transcurrido (hace 2 h 5 min)",
+		"display.update":   "Actualizar formato de hora",
+
+		"timezone.title":  "Zona horaria",
+		"timezone.travel": "¿De viaje? Al cambiar, los registros anteriores se quedan en los días en que ocurrieron; solo los registros desde el cambio usan la nueva zona horaria. Si olvidaste cambiarla al llegar, indica la hora de llegada en la nueva hora local.",
+		"timezone.since":  "Desde (opcional, nueva hora local)",
+		"timezone.update": "Actualizar zona horaria",
+		"timezone.recent": "Cambios recientes:",
+
+		"day.title":    "El día empieza a las",
+		"day.help":     "Los totales diarios, las medias y los intervalos cuentan desde esta hora, para que las tomas nocturnas queden juntas.",
+		"day.midnight": "%s (medianoche)",
+		"day.noon":     "%s (mediodía)",
+		"day.update":   "Actualizar inicio del día",
+
+		"alerts.title":     "Alertas",
+		"alerts.help":      "Avisa cuando una actividad no se ha registrado en tantas horas. Déjalo en blanco para desactivarla.",
+		"alerts.notify":    "Notificar",
+		"alerts.page":      "Solo en esta página",
+		"alerts.ntfy":      "URL de tema de ntfy",
+		"alerts.webhook":   "URL de webhook",
+		"alerts.email":     "Correo electrónico",
+		"alerts.target":    "URL o dirección de correo",
+		"alerts.update":    "Actualizar alertas",
+		"digest.title":     "Resumen diario",
+		"digest.help":      "Envía cada mañana por correo los totales de ayer y los intervalos más largos. Déjalo en blanco para desactivarlo.",
+		"digest.email":     "Dirección de correo",
+		"digest.update":    "Actualizar resumen",
+		"mail.pending":     "Esperando la confirmación de %s.",
+		"webhooks.title":   "Webhooks",
+		"webhooks.help":    "Envía un evento JSON firmado a tu URL cada vez que se añade o deshace un registro o cambia un ajuste.",
+		"webhooks.secret":  "Secreto:",
+		"webhooks.remove":  "Quitar",
+		"webhooks.url":     "URL",
+		"webhooks.new":     "Secreto compartido (déjalo en blanco para generarlo)",
+		"webhooks.add":     "Añadir webhook",
+		"webhooks.event":   "Evento",
+		"webhooks.tries":   "Intentos",
+		"webhooks.result":  "Resultado",
+		"webhooks.removed": "(eliminado)",
+		"quicklog.title":   "Enlaces de registro rápido",
+		"quicklog.help":    "Abre un enlace para registrar con un toque. Grábalo en una etiqueta NFC o un botón inteligente. Los enlaces no revelan esta página.",
+		"quicklog.revoke":  "Revocar",
+		"quicklog.create":  "Crear enlace",
+		"quicklog.all":     "Revocar todos los enlaces",
+		"feeds.title":      "Feeds de solo lectura",
+		"feeds.help":       "Sigue los registros recientes desde una app de calendario o un lector de feeds. Los enlaces de feed no pueden añadir ni cambiar registros.",
+		"feeds.calendar":   "Calendario",
+		"feeds.atom":       "Atom",
+		"feeds.rotate":     "Nuevo enlace",
+		"feeds.disable":    "Desactivar",
+		"feeds.create":     "Crear enlace de feed",
+		"data.title":       "Datos",
+		"data.help":        "Descarga una copia de seguridad de tus datos.",
+		"data.export":      "Exportar datos",
+		"delete.title":     "Eliminar peque",
+		"delete.help":      "Los peques se eliminan automáticamente tras 6 meses de inactividad.",
+		"delete.confirm":   "¿Confirmar eliminación? (No se puede deshacer)",
+		"delete.check":     "Marca esta casilla si quieres eliminar este peque",
+		"delete.button":    "Eliminar este peque",
+	},
+}
@@ -0,0 +1,202 @@
+// i18n.go holds the message catalogs of the supported languages, picks one for a request and
+// formats messages, times and numbers in it.
+package i18n
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a language the UI is translated to, with its date, time and number conventions.
+type Locale struct {
+	Tag  string // ISO 639-1 language code, e.g. "es".
+	Name string // In the language itself, for the language selector.
+	// TimeFormat is the Go layout for tally times. It is empty for English, which uses
+	// config.TimeFormat so operators can still choose the default.
+	TimeFormat string
+	HourFormat string // Layout of a whole hour, e.g. "3 PM".
+	ClockTime  string // Layout of a time of day, e.g. "3:04 PM".
+	Clock      string // Clock12 or Clock24, matching HourFormat and ClockTime.
+	DayMonth   string // Layout of a date without the year, e.g. "02 Jan".
+	MonthDay   string // DayMonth with the month first, e.g. "Jan 02".
+	MonthFirst bool   // Whether dates put the month before the day.
+	Decimal    string
+	Group      string // Thousands separator.
+
+	// Names substituted for Go's English ones when formatting.
+	Months, ShortMonths     [12]string
+	Weekdays, ShortWeekdays [7]string // Sunday first, like time.Weekday.
+	AM, PM                  string
+
+	messages map[string]string
+}
+
+// Default is the fallback locale for unknown languages and missing messages.
+var Default = English
+
+// Locales lists the supported languages in selector order.
+var Locales = []*Locale{English, Spanish, German}
+
+// Lookup returns the locale with the given tag, or nil if it is not supported.
+func Lookup(tag string) *Locale {
+	for _, l := range Locales {
+		if strings.EqualFold(l.Tag, tag) {
+			return l
+		}
+	}
+	return nil
+}
+
+// Negotiate picks the supported locale the client prefers most from an Accept-Language header,
+// e.g. "de-CH, en;q=0.8". Regions are ignored, and Default is used when nothing matches.
+func Negotiate(acceptLanguage string) *Locale {
+	type choice struct {
+		locale *Locale
+		q      float64
+	}
+	var choices []choice
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		if l := Lookup(primary); l != nil && q > 0 {
+			choices = append(choices, choice{l, q})
+		}
+	}
+	slices.SortStableFunc(choices, func(a, b choice) int { return cmp.Compare(b.q, a.q) })
+	if len(choices) == 0 {
+		return Default
+	}
+	return choices[0].locale
+}
+
+// T returns the message for key, formatted with args like fmt.Sprintf. Messages missing from the
+// catalog fall back to Default, then to the key itself.
+func (l *Locale) T(key string, args ...any) string {
+	msg, ok := l.messages[key]
+	if !ok {
+		if msg, ok = Default.messages[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has reports whether key is in the catalog.
+func (l *Locale) Has(key string) bool {
+	_, ok := l.messages[key]
+	return ok
+}
+
+// Keys returns the message keys of the catalog, sorted.
+func (l *Locale) Keys() []string {
+	keys := make([]string, 0, len(l.messages))
+	for k := range l.messages {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Layout returns the locale's layout for tally times, or fallback if it has none.
+func (l *Locale) Layout(fallback string) string {
+	return cmp.Or(l.TimeFormat, fallback)
+}
+
+// layoutNames are the English names in Go layouts, longest first so "January" is not read as "Jan".
+var layoutNames = []string{"January", "Monday", "Jan", "Mon", "PM", "pm"}
+
+// Format is time.Format with month, weekday and AM/PM names in the locale's language.
+func (l *Locale) Format(t time.Time, layout string) string {
+	var b strings.Builder
+	for layout != "" {
+		i, name := len(layout), ""
+		for _, n := range layoutNames {
+			if j := strings.Index(layout, n); j >= 0 && (j < i || j == i && len(n) > len(name)) {
+				i, name = j, n
+			}
+		}
+		b.WriteString(t.Format(layout[:i]))
+		if name == "" {
+			break
+		}
+		switch name {
+		case "January":
+			b.WriteString(l.Months[t.Month()-1])
+		case "Jan":
+			b.WriteString(l.ShortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(l.Weekdays[t.Weekday()])
+		case "Mon":
+			b.WriteString(l.ShortWeekdays[t.Weekday()])
+		case "PM":
+			b.WriteString(l.meridiem(t))
+		case "pm":
+			b.WriteString(strings.ToLower(l.meridiem(t)))
+		}
+		layout = layout[i+len(name):]
+	}
+	return b.String()
+}
+
+func (l *Locale) meridiem(t time.Time) string {
+	if t.Hour() < 12 {
+		return l.AM
+	}
+	return l.PM
+}
+
+// Hour formats a whole hour of the day (0-23), e.g. "3 PM" or "15:00".
+func (l *Locale) Hour(hour int) string {
+	return l.Format(time.Date(2000, 1, 1, hour, 0, 0, 0, time.UTC), l.HourFormat)
+}
+
+// Number formats n with the locale's separators and the given number of decimals,
+// e.g. 1234.5 with one decimal is "1,234.5" in English and "1.234,5" in German.
+func (l *Locale) Number(n float64, decimals int) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if s[0] == '-' {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteString(l.Decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// Integer formats n with the locale's thousands separator.
+func (l *Locale) Integer(n int) string {
+	return l.Number(float64(n), 0)
+}
+
+// Duration formats d like stats.FormatGap in the locale, e.g. "2h 5m" or "45m" in English.
+func (l *Locale) Duration(d time.Duration) string {
+	if d < time.Hour {
+		return l.T("duration.m", int(d.Minutes()))
+	}
+	return l.T("duration.hm", int(d.Hours()), int(d.Minutes())%60)
+}
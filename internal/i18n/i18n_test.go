@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+var verbs = regexp.MustCompile(`%[a-z]`)
+
+func TestCatalogs_Complete(t *testing.T) {
+	for _, l := range Locales {
+		for _, key := range English.Keys() {
+			if !l.Has(key) {
+				t.Errorf("%s: missing %q", l.Tag, key)
+				continue
+			}
+			// Translations must take the same arguments in the same order.
+			if want, got := verbs.FindAllString(English.messages[key], -1), verbs.FindAllString(l.messages[key], -1); !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, want %v", l.Tag, key, got, want)
+			}
+		}
+		for _, key := range l.Keys() {
+			if !English.Has(key) {
+				t.Errorf("%s: %q is not in the English catalog", l.Tag, key)
+			}
+		}
+		for i, name := range append(l.Months[:], l.ShortMonths[:]...) {
+			if name == "" {
+				t.Errorf("%s: month %d has no name", l.Tag, i%12+1)
+			}
+		}
+		for _, name := range append(l.Weekdays[:], l.ShortWeekdays[:]...) {
+			if name == "" {
+				t.Errorf("%s: missing weekday name", l.Tag)
+			}
+		}
+		if l.HourFormat == "" || l.ClockTime == "" || l.Clock == "" || l.DayMonth == "" || l.MonthDay == "" || l.Decimal == "" || l.Group == "" || l.Name == "" {
+			t.Errorf("%s: missing formats: %+v", l.Tag, l)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   *Locale
+	}{
+		{"", English},
+		{"fr-FR, fr;q=0.9", English},
+		{"es-MX,es;q=0.9,en;q=0.8", Spanish},
+		{"de-CH", German},
+		{"en;q=0.5, de;q=0.8", German},
+		{"fr, es;q=0.7, de;q=0.7", Spanish},
+		{"de;q=0, es", Spanish},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.header, got.Tag, tt.want.Tag)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := Spanish.T("tallies.limit", "20"); got != "Solo se guardan los últimos 20 registros" {
+		t.Errorf("unexpected message: %s", got)
+	}
+	if got := German.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("expected the key for a missing message, got %s", got)
+	}
+	if Lookup("DE") != German || Lookup("fr") != nil {
+		t.Error("unexpected Lookup result")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2023, 3, 5, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		locale *Locale
+		layout string
+		want   string
+	}{
+		{English, "02 Jan 03:04PM", "05 Mar 03:04PM"},
+		{Spanish, Spanish.Layout("02 Jan 03:04PM"), "05 mar 15:04"},
+		{German, German.Layout("02 Jan 03:04PM"), "05. März 15:04"},
+		{German, "Monday, 2. January 2006", "Sonntag, 5. März 2023"},
+		{Spanish, "Mon 3:04 pm", "dom 3:04 p. m."},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.Format(at, tt.layout); got != tt.want {
+			t.Errorf("%s Format(%q) = %q, want %q", tt.locale.Tag, tt.layout, got, tt.want)
+		}
+	}
+	if English.Layout("02 Jan 03:04PM") != "02 Jan 03:04PM" {
+		t.Error("expected English to use the configured layout")
+	}
+	if English.Hour(0) != "12 AM" || English.Hour(13) != "1 PM" || German.Hour(13) != "13:00" {
+		t.Errorf("unexpected hours: %s %s %s", English.Hour(0), English.Hour(13), German.Hour(13))
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		locale   *Locale
+		n        float64
+		decimals int
+		want     string
+	}{
+		{English, 1234567.25, 2, "1,234,567.25"},
+		{German, 1234.5, 1, "1.234,5"},
+		{Spanish, -999, 0, "-999"},
+		{English, 100, 0, "100"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.Number(tt.n, tt.decimals); got != tt.want {
+			t.Errorf("%s Number(%v) = %q, want %q", tt.locale.Tag, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for _, tt := range []struct {
+		locale *Locale
+		d      time.Duration
+		want   string
+	}{
+		{English, 3*time.Hour + 5*time.Minute, "3h 5m"},
+		{Spanish, 3*time.Hour + 5*time.Minute, "3 h 5 min"},
+		{German, 40 * time.Minute, "40 Min."},
+	} {
+		if got := tt.locale.Duration(tt.d); got != tt.want {
+			t.Errorf("%s Duration(%v) = %q, want %q", tt.locale.Tag, tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// ical.go writes iCalendar (RFC 5545) feeds with a generated VTIMEZONE, so calendar apps show
+// events at the right local time without relying on their own timezone database.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	localFormat = "20060102T150405"
+	utcFormat   = "20060102T150405Z"
+	maxLine     = 75 // Octets per line before folding.
+)
+
+// Event is a single VEVENT. A zero End, or one equal to Start, marks a point in time.
+type Event struct {
+	UID     string
+	Start   time.Time
+	End     time.Time
+	Summary string
+}
+
+// Calendar is a feed of events shown in one timezone.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	Events   []Event
+}
+
+// Encode writes the calendar. The VTIMEZONE covers every transition between the earliest
+// event and now, and now is also used as the DTSTAMP of each event.
+func (c Calendar) Encode(w io.Writer, now time.Time) error {
+	var b strings.Builder
+	line := func(format string, args ...any) { writeLine(&b, fmt.Sprintf(format, args...)) }
+	tzid := c.Location.String()
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//tot-tally//tot-tally//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", escapeText(c.Name))
+	line("X-WR-TIMEZONE:%s", tzid)
+
+	from := now
+	for _, e := range c.Events {
+		if e.Start.Before(from) {
+			from = e.Start
+		}
+	}
+	writeTimezone(&b, c.Location, from, now)
+
+	stamp := now.UTC().Format(utcFormat)
+	for _, e := range c.Events {
+		line("BEGIN:VEVENT")
+		line("UID:%s", escapeText(e.UID))
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART;TZID=%s:%s", tzid, e.Start.In(c.Location).Format(localFormat))
+		if e.End.After(e.Start) {
+			line("DTEND;TZID=%s:%s", tzid, e.End.In(c.Location).Format(localFormat))
+		}
+		line("SUMMARY:%s", escapeText(e.Summary))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("ical: failed to write calendar: %w", err)
+	}
+	return nil
+}
+
+// writeTimezone writes a VTIMEZONE with one observance per zone period between from and to.
+// Explicit observances avoid encoding DST rules as RRULEs, which Go doesn't expose.
+func writeTimezone(b *strings.Builder, loc *time.Location, from, to time.Time) {
+	writeLine(b, "BEGIN:VTIMEZONE")
+	writeLine(b, "TZID:"+loc.String())
+
+	t := from.In(loc)
+	for {
+		name, offset := t.Zone()
+		start, end := t.ZoneBounds()
+
+		component := "STANDARD"
+		if t.IsDST() {
+			component = "DAYLIGHT"
+		}
+		offsetFrom, dtstart := offset, "19700101T000000"
+		if !start.IsZero() {
+			_, offsetFrom = start.Add(-time.Second).Zone()
+			dtstart = start.In(time.FixedZone("", offsetFrom)).Format(localFormat)
+		}
+
+		writeLine(b, "BEGIN:"+component)
+		writeLine(b, "DTSTART:"+dtstart)
+		writeLine(b, "TZOFFSETFROM:"+formatOffset(offsetFrom))
+		writeLine(b, "TZOFFSETTO:"+formatOffset(offset))
+		writeLine(b, "TZNAME:"+escapeText(name))
+		writeLine(b, "END:"+component)
+
+		if end.IsZero() || end.After(to) {
+			break
+		}
+		t = end
+	}
+	writeLine(b, "END:VTIMEZONE")
+}
+
+// formatOffset formats seconds east of UTC as e.g. "-0500".
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeLine writes a content line with CRLF, folding it at 75 octets without splitting a character.
+func writeLine(b *strings.Builder, s string) {
+	limit := maxLine
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLine - 1 // Continuation lines start with a space.
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
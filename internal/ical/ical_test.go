@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	chicago, _ := time.LoadLocation("America/Chicago")
+	now := time.Date(2023, 11, 10, 12, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		Name:     "👶, tallies",
+		Location: chicago,
+		Events: []Event{
+			{UID: "1@tot-tally", Start: time.Date(2023, 11, 9, 15, 0, 0, 0, time.UTC), Summary: "🚽"},
+			{UID: "2@tot-tally", Start: time.Date(2023, 11, 1, 14, 0, 0, 0, time.UTC), End: time.Date(2023, 11, 1, 14, 20, 0, 0, time.UTC), Summary: "🤱L 🤱R"},
+		},
+	}
+
+	var b strings.Builder
+	if err := cal.Encode(&b, now); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:👶\\, tallies\r\n",
+		// CDT until the first Sunday in November, then CST.
+		"BEGIN:DAYLIGHT\r\nDTSTART:20230312T020000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0500\r\nTZNAME:CDT\r\nEND:DAYLIGHT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20231105T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0600\r\nTZNAME:CST\r\nEND:STANDARD\r\n",
+		"DTSTART;TZID=America/Chicago:20231109T090000\r\nSUMMARY:🚽\r\n",
+		"DTSTART;TZID=America/Chicago:20231101T090000\r\nDTEND;TZID=America/Chicago:20231101T092000\r\n",
+		"DTSTAMP:20231110T120000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 2 || strings.Count(out, "DTEND") != 1 {
+		t.Errorf("expected two events and one DTEND:\n%s", out)
+	}
+}
+
+func TestEncode_NoDST(t *testing.T) {
+	phoenix, _ := time.LoadLocation("America/Phoenix")
+	var b strings.Builder
+	Calendar{Name: "🦖", Location: phoenix}.Encode(&b, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	if strings.Contains(b.String(), "DAYLIGHT") || strings.Count(b.String(), "BEGIN:STANDARD") != 1 {
+		t.Errorf("expected a single standard observance:\n%s", b.String())
+	}
+	// Phoenix last changed offset in 1967.
+	if !strings.Contains(b.String(), "DTSTART:19671029T020000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0700\r\n") {
+		t.Errorf("expected the last transition to -0700:\n%s", b.String())
+	}
+	var u strings.Builder
+	Calendar{Name: "🦖", Location: time.UTC}.Encode(&u, time.Now())
+	if !strings.Contains(u.String(), "DTSTART:19700101T000000\r\nTZOFFSETFROM:+0000\r\nTZOFFSETTO:+0000\r\n") {
+		t.Errorf("expected a fixed UTC observance:\n%s", u.String())
+	}
+}
+
+func TestWriteLine_Folding(t *testing.T) {
+	var b strings.Builder
+	writeLine(&b, "SUMMARY:"+strings.Repeat("🍼", 40))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected folded lines, got %q", b.String())
+	}
+	var joined string
+	for i, l := range lines {
+		if len(l) > maxLine || !utf8.ValidString(l) {
+			t.Errorf("line %d is too long or splits a character: %q", i, l)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d must start with a space: %q", i, l)
+			}
+			l = l[1:]
+		}
+		joined += l
+	}
+	if joined != "SUMMARY:"+strings.Repeat("🍼", 40) {
+		t.Errorf("unfolded line does not match: %q", joined)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	if got := escapeText("a;b,c\\d\ne"); got != `a\;b\,c\\d\ne` {
+		t.Errorf("unexpected escape: %s", got)
+	}
+	if got := formatOffset(-5*3600 - 30*60); got != "-0530" {
+		t.Errorf("unexpected offset: %s", got)
+	}
+}
@@ -0,0 +1,249 @@
+// integrity.go audits the data directories for damaged or inconsistent files, and repairs the
+// problems that can be fixed without guessing.
+package integrity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+// Problems an Issue can report.
+const (
+	OrphanedTmp  = "orphaned tmp file"
+	Undecodable  = "undecodable"
+	MismatchedID = "id mismatch"
+	MissingTime  = "missing time"
+	Unsorted     = "tallies out of order"
+	StaleStats   = "stale stats"
+	UnknownKind  = "unknown kind"
+)
+
+// tmpGrace is how old a .tmp file must be to count as orphaned rather than a write in progress.
+const tmpGrace = time.Minute
+
+// Issue is one problem with one data file.
+type Issue struct {
+	Path    string
+	Problem string
+	Detail  string
+	Fix     string // What a repair did, e.g. "re-sorted"; empty when it was left alone.
+}
+
+// Report is the outcome of a check or repair.
+type Report struct {
+	Tots   int // How many tot files were checked.
+	Issues []Issue
+}
+
+// Unfixed counts the issues left as they were.
+func (r Report) Unfixed() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Fix == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Checker finds and repairs problems in the data directories.
+type Checker struct {
+	config *totConfig.Config
+	store  *totStorage.Repository
+	pool   *totShards.Pool
+	engine *totStats.Engine
+}
+
+// NewChecker initializes the integrity checker.
+func NewChecker(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool, engine *totStats.Engine) *Checker {
+	return &Checker{config: cfg, store: store, pool: pool, engine: engine}
+}
+
+// Run checks every tot file and looks for orphaned .tmp files. With repair set it also fixes what
+// it safely can: tallies are re-sorted, stats recomputed, IDs matched to file names, and files
+// that can't be read are quarantined rather than deleted. Unknown kinds and tallies without a
+// time are only reported, since fixing them would mean dropping data.
+func (c *Checker) Run(repair bool) (Report, error) {
+	ids, err := c.store.ListTotIDs()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Tots: len(ids)}
+	var errs []error
+	for _, id := range ids {
+		issues, err := c.checkTot(id, repair)
+		report.Issues = append(report.Issues, issues...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("integrity: %s: %w", id, err))
+		}
+	}
+	for _, dir := range []string{c.config.TotDirectory, c.config.LimitDirectory, c.config.LinkDirectory} {
+		issues, err := c.checkTmp(dir, time.Now(), repair)
+		report.Issues = append(report.Issues, issues...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// checkTot checks one tot file under its shard lock, so a running server's writes aren't lost.
+func (c *Checker) checkTot(id string, repair bool) ([]Issue, error) {
+	mut := c.pool.GetShardMutex(id)
+	mut.Lock()
+	defer mut.Unlock()
+
+	path := filepath.Join(c.config.TotDirectory, id+".json")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil // Deleted since the directory was listed.
+	}
+	tot, err := c.store.LoadTot(id)
+	if err != nil {
+		issue := Issue{Path: path, Problem: Undecodable, Detail: err.Error()}
+		if repair {
+			dest, err := c.store.QuarantineFile(path, Undecodable+": "+issue.Detail)
+			if err != nil {
+				return []Issue{issue}, err
+			}
+			issue.Fix = "moved to " + dest
+		}
+		return []Issue{issue}, nil
+	}
+
+	// Every fix is made in memory, then saved once.
+	var issues []Issue
+	var fixes []string // What saving does about each issue; empty if nothing.
+	add := func(problem, detail, fix string) {
+		issues = append(issues, Issue{Path: path, Problem: problem, Detail: detail})
+		fixes = append(fixes, fix)
+	}
+	if tot.ID != id {
+		add(MismatchedID, fmt.Sprintf("holds tot %q", tot.ID), "id set to "+id)
+		tot.ID = id
+	}
+	if kinds := unknownKinds(tot.Tallies); len(kinds) > 0 {
+		add(UnknownKind, strings.Join(kinds, ", "), "")
+	}
+	missing := slices.IndexFunc(tot.Tallies, func(t totModels.Tally) bool { return t.Time == nil })
+	if missing >= 0 {
+		// Tallies can't be ordered or counted without times, so stats are left alone too.
+		add(MissingTime, fmt.Sprintf("tally %d has no time", missing), "")
+	} else {
+		if !slices.IsSortedFunc(tot.Tallies, newestFirst) {
+			add(Unsorted, fmt.Sprintf("%d tallies", len(tot.Tallies)), "re-sorted")
+			slices.SortStableFunc(tot.Tallies, newestFirst)
+		}
+		// Tallies past MaxTallies are dropped on save, so a marker no remaining tally backs is
+		// kept; only one older than a remaining tally is stale.
+		want := *tot
+		c.engine.RecalculateStats(&want)
+		if !sameStats(tot.Stats, totStats.NewerStats(tot.Stats, want.Stats)) {
+			add(StaleStats, "latest activity is older than the tallies", "recomputed")
+		}
+	}
+
+	if !repair || !slices.ContainsFunc(fixes, func(fix string) bool { return fix != "" }) {
+		return issues, nil
+	}
+	if err := c.save(tot, missing < 0); err != nil {
+		return issues, err
+	}
+	for i := range issues {
+		issues[i].Fix = fixes[i]
+	}
+	return issues, nil
+}
+
+// save writes a repaired tot, recomputing its stats when its tallies allow. Markers newer than
+// the tallies are kept, and so is UpdatedAt, so a repair doesn't hold an inactive tot back from cleanup.
+func (c *Checker) save(tot *totModels.Tot, recompute bool) error {
+	if recompute {
+		tz, err := time.LoadLocation(tot.Timezone)
+		if err != nil {
+			tz = time.UTC
+		}
+		kept := tot.Stats
+		c.engine.RecalculateStats(tot)
+		tot.Stats = totStats.NewerStats(kept, tot.Stats)
+		generated, err := c.engine.GenerateStats(tot, tz, time.Now())
+		if err != nil {
+			return err
+		}
+		tot.GeneratedStats = generated
+	}
+	return c.store.SaveTotWithoutActivity(tot)
+}
+
+// checkTmp finds .tmp files left in dir by writes that never finished, e.g. after a crash. They
+// may hold newer data than the file they were meant to replace, so repair quarantines them.
+func (c *Checker) checkTmp(dir string, now time.Time, repair bool) ([]Issue, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("integrity: failed to read %s: %w", dir, err)
+	}
+
+	var issues []Issue
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < tmpGrace {
+			continue
+		}
+		issue := Issue{Path: filepath.Join(dir, entry.Name()), Problem: OrphanedTmp, Detail: "last written " + info.ModTime().UTC().Format(time.RFC3339)}
+		if repair {
+			if dest, err := c.store.QuarantineFile(issue.Path, OrphanedTmp); err != nil {
+				errs = append(errs, err)
+			} else {
+				issue.Fix = "moved to " + dest
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, errors.Join(errs...)
+}
+
+// newestFirst orders tallies the way they are stored.
+func newestFirst(a, b totModels.Tally) int {
+	return b.Time.Compare(*a.Time)
+}
+
+// unknownKinds lists the kinds of tallies not in TallyKindMap, each once.
+func unknownKinds(tallies []totModels.Tally) []string {
+	known := map[string]bool{}
+	for _, kind := range totConfig.TallyKindMap {
+		known[kind] = true
+	}
+	var kinds []string
+	for _, t := range tallies {
+		if !known[t.Kind] && !slices.Contains(kinds, t.Kind) {
+			kinds = append(kinds, t.Kind)
+		}
+	}
+	return kinds
+}
+
+// sameStats compares stats by their saved form, so equal times in different locations match.
+func sameStats(a, b totModels.Stats) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
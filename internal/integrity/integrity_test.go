@@ -0,0 +1,180 @@
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+func setupChecker(t *testing.T) (*Checker, *totStorage.Repository, *totConfig.Config) {
+	dir := t.TempDir()
+	cfg := totConfig.NewDefaultConfig()
+	cfg.TotDirectory = filepath.Join(dir, "tots")
+	cfg.LimitDirectory = filepath.Join(dir, "limits")
+	cfg.LinkDirectory = filepath.Join(dir, "links")
+	cfg.QuarantineDirectory = filepath.Join(dir, "quarantine")
+	os.MkdirAll(cfg.TotDirectory, 0755)
+	os.MkdirAll(cfg.LimitDirectory, 0755)
+	pool := totShards.NewPool(4)
+	store := totStorage.NewRepository(cfg, pool)
+	return NewChecker(cfg, store, pool, totStats.NewEngine(cfg)), store, cfg
+}
+
+func at(hoursAgo int) *time.Time {
+	t := time.Now().UTC().Add(-time.Duration(hoursAgo) * time.Hour).Truncate(time.Second)
+	return &t
+}
+
+// problems maps each issue's file name to its problems, in order.
+func problems(issues []Issue) map[string][]string {
+	found := map[string][]string{}
+	for _, issue := range issues {
+		name := filepath.Base(issue.Path)
+		found[name] = append(found[name], issue.Problem)
+	}
+	return found
+}
+
+func TestRun_Healthy(t *testing.T) {
+	c, store, _ := setupChecker(t)
+	tot := &totModels.Tot{ID: "good", Name: "👶", Timezone: "UTC", Tallies: []totModels.Tally{{Time: at(1), Kind: "🚽"}, {Time: at(2), Kind: "🍼2"}}}
+	c.engine.RecalculateStats(tot)
+	store.SaveTot(tot)
+
+	report, err := c.Run(false)
+	if err != nil || report.Tots != 1 || len(report.Issues) != 0 {
+		t.Errorf("expected a clean report, got %+v (%v)", report, err)
+	}
+}
+
+func TestRun_Verify(t *testing.T) {
+	c, store, cfg := setupChecker(t)
+	store.SaveTot(&totModels.Tot{ID: "other", Name: "👶", Tallies: []totModels.Tally{{Time: at(2), Kind: "🚽"}, {Time: at(1), Kind: "🦕"}}})
+	os.Rename(filepath.Join(cfg.TotDirectory, "other.json"), filepath.Join(cfg.TotDirectory, "misnamed.json"))
+	store.SaveTot(&totModels.Tot{ID: "timeless", Name: "👶", Tallies: []totModels.Tally{{Kind: "🚽"}}})
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "broken.json"), []byte(`{"id": `), 0644)
+
+	// Only .tmp files too old to be a write in progress are orphans.
+	orphan := filepath.Join(cfg.LimitDirectory, "abc.tmp")
+	os.WriteFile(orphan, []byte("1\n0"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(orphan, old, old)
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "busy.json.tmp"), []byte("{"), 0644)
+
+	report, err := c.Run(false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := map[string][]string{
+		"misnamed.json": {MismatchedID, UnknownKind, Unsorted, StaleStats},
+		"timeless.json": {MissingTime},
+		"broken.json":   {Undecodable},
+		"abc.tmp":       {OrphanedTmp},
+	}
+	got := problems(report.Issues)
+	if len(got) != len(want) {
+		t.Errorf("expected problems in %d files, got %v", len(want), got)
+	}
+	for name, w := range want {
+		if strings.Join(got[name], ",") != strings.Join(w, ",") {
+			t.Errorf("%s: expected %v, got %v", name, w, got[name])
+		}
+	}
+	if report.Tots != 3 || report.Unfixed() != len(report.Issues) {
+		t.Errorf("expected 3 tots and nothing fixed, got %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TotDirectory, "broken.json")); err != nil {
+		t.Errorf("expected verify to change nothing: %v", err)
+	}
+}
+
+func TestRun_Repair(t *testing.T) {
+	c, store, cfg := setupChecker(t)
+	updated := time.Date(2023, 10, 26, 0, 0, 0, 0, time.UTC)
+	store.SaveTotWithoutActivity(&totModels.Tot{
+		ID: "wrong", Name: "👶", Timezone: "UTC", UpdatedAt: updated,
+		Tallies: []totModels.Tally{{Time: at(3), Kind: "🍼2"}, {Time: at(1), Kind: "🚽"}, {Time: at(2), Kind: "🤱L"}},
+	})
+	os.Rename(filepath.Join(cfg.TotDirectory, "wrong.json"), filepath.Join(cfg.TotDirectory, "right.json"))
+	os.WriteFile(filepath.Join(cfg.TotDirectory, "broken.json"), []byte("not json"), 0644)
+	store.SaveTot(&totModels.Tot{ID: "odd", Name: "👶", Tallies: []totModels.Tally{{Time: at(1), Kind: "🦕"}}})
+
+	report, err := c.Run(true)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, issue := range report.Issues {
+		fixed := issue.Fix != ""
+		if wantFixed := issue.Problem != UnknownKind; fixed != wantFixed {
+			t.Errorf("%s %s: unexpected fix %q", issue.Path, issue.Problem, issue.Fix)
+		}
+	}
+	if report.Unfixed() != 1 {
+		t.Errorf("expected only the unknown kind left, got %+v", report.Issues)
+	}
+
+	tot, err := store.LoadTot("right")
+	if err != nil {
+		t.Fatalf("expected the repaired tot: %v", err)
+	}
+	if tot.ID != "right" || tot.Tallies[0].Kind != "🚽" || tot.Tallies[2].Kind != "🍼2" {
+		t.Errorf("expected the id matched and tallies sorted, got %s %+v", tot.ID, tot.Tallies)
+	}
+	if tot.Stats.LastPee == nil || tot.Stats.LastNurseSide != "L" || tot.GeneratedStats.Last24HoursPee != "1" {
+		t.Errorf("expected stats recomputed, got %+v %+v", tot.Stats, tot.GeneratedStats)
+	}
+	if !tot.UpdatedAt.Equal(updated) {
+		t.Errorf("expected UpdatedAt kept, got %v", tot.UpdatedAt)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.TotDirectory, "broken.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the broken file moved, got %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.QuarantineDirectory); len(entries) != 2 {
+		t.Errorf("expected the broken file and its note in quarantine, got %v", entries)
+	}
+
+	report, _ = c.Run(true)
+	if len(report.Issues) != 1 || report.Issues[0].Problem != UnknownKind {
+		t.Errorf("expected a second repair to find only the unknown kind, got %+v", report.Issues)
+	}
+}
+
+func TestRun_TruncatedTallies(t *testing.T) {
+	c, store, cfg := setupChecker(t)
+	// The bath's tally was dropped past MaxTallies, so only the saved marker remembers it.
+	bath := at(cfg.MaxTallies + 10)
+	tot := &totModels.Tot{ID: "busy", Name: "👶", Timezone: "UTC", Stats: totModels.Stats{LastBath: bath}}
+	for i := range cfg.MaxTallies + 5 {
+		tot.Tallies = append(tot.Tallies, totModels.Tally{Time: at(i + 1), Kind: "🚽"})
+	}
+	tot.Stats.LastPee = tot.Tallies[0].Time
+	store.SaveTot(tot)
+
+	if report, err := c.Run(true); err != nil || len(report.Issues) != 0 {
+		t.Fatalf("expected a truncated tot to be healthy, got %+v (%v)", report.Issues, err)
+	}
+
+	// A marker older than a remaining tally is still stale, and repair keeps the bath.
+	loaded, _ := store.LoadTot("busy")
+	loaded.Stats.LastPee = at(cfg.MaxTallies + 20)
+	store.SaveTotWithoutActivity(loaded)
+	report, err := c.Run(true)
+	if err != nil || len(report.Issues) != 1 || report.Issues[0].Problem != StaleStats || report.Issues[0].Fix == "" {
+		t.Fatalf("expected the stale pee marker repaired, got %+v (%v)", report.Issues, err)
+	}
+	loaded, _ = store.LoadTot("busy")
+	if loaded.Stats.LastBath == nil || !loaded.Stats.LastBath.Equal(*bath) {
+		t.Errorf("expected the bath marker kept, got %v", loaded.Stats.LastBath)
+	}
+	if loaded.Stats.LastPee == nil || !loaded.Stats.LastPee.Equal(*at(1)) {
+		t.Errorf("expected the pee marker recomputed, got %v", loaded.Stats.LastPee)
+	}
+}
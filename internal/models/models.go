@@ -1,19 +1,112 @@
 // models.go defines domain models and template data structures.
 package models
 
-import "time"
+import (
+	"html/template"
+	"time"
+	totI18n "tot-tally/internal/i18n"
+)
 
 // Tot is the core model representing a child's record.
 type Tot struct {
-	ID             string         `json:"id"`
-	Name           string         `json:"name"`
-	Timezone       string         `json:"timezone"`
-	MilkSetting    string         `json:"milkSetting"`
-	Tallies        []Tally        `json:"tallies"`
-	Stats          Stats          `json:"stats"`
-	GeneratedStats GeneratedStats `json:"generatedStats"`
-	CreatedAt      time.Time      `json:"createdAt"`
-	UpdatedAt      time.Time      `json:"updatedAt"`
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Timezone        string            `json:"timezone"`
+	Locale          string            `json:"locale"` // Language tag; empty follows each browser.
+	Display         DisplaySettings   `json:"display"`
+	TimezoneChanges []TimezoneChange  `json:"timezoneChanges"` // Oldest first.
+	MilkSetting     string            `json:"milkSetting"`
+	DayStartsAt     int               `json:"dayStartsAt"`
+	Alerts          AlertSettings     `json:"alerts"`
+	Webhooks        []Webhook         `json:"webhooks"`
+	WebhookLog      []WebhookDelivery `json:"webhookLog"` // Newest first.
+	QuickLog        QuickLogSettings  `json:"quickLog"`
+	ReadToken       string            `json:"readToken"` // Grants read-only access to feeds; empty when disabled.
+	Digest          DigestSettings    `json:"digest"`
+	Mail            MailSettings      `json:"mail"`
+	Tallies         []Tally           `json:"tallies"`
+	Journal         []JournalEntry    `json:"journal"` // Newest first.
+	Stats           Stats             `json:"stats"`
+	GeneratedStats  GeneratedStats    `json:"generatedStats"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+// JournalEntry is something logged for the visit summary that a tally can't hold, e.g. how long
+// a nap lasted or a medication's dose. Only the fields of its kind are set.
+type JournalEntry struct {
+	ID       string     `json:"id"`
+	Kind     string     `json:"kind"` // "sleep", "medication", "growth" or "note".
+	Time     time.Time  `json:"time"` // When it happened; for sleep, when it began.
+	End      *time.Time `json:"end"`  // When sleep ended.
+	Name     string     `json:"name"` // Medication name.
+	Dose     string     `json:"dose"` // Free text, e.g. "2.5 ml".
+	WeightKg float64    `json:"weightKg"`
+	LengthCm float64    `json:"lengthCm"`
+	HeadCm   float64    `json:"headCm"` // Head circumference.
+	Text     string     `json:"text"`   // Note.
+}
+
+// TimezoneChange is a switch from one timezone to another, e.g. when travelling. Tallies are
+// bucketed into days by the zone in effect when they happened, so past totals don't move.
+type TimezoneChange struct {
+	At   time.Time `json:"at"` // When the new zone took effect.
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+// DisplaySettings are how a tot's times are shown. Empty values follow the page's language.
+type DisplaySettings struct {
+	Clock     string `json:"clock"`     // "12h" or "24h".
+	DateOrder string `json:"dateOrder"` // "dmy" or "mdy".
+	History   string `json:"history"`   // "relative" shows tallies as "2h 5m ago"; empty shows the time.
+}
+
+// DigestSettings configures the opt-in daily summary email.
+type DigestSettings struct {
+	Email   string `json:"email"`   // Confirmed address; empty disables the digest.
+	Pending string `json:"pending"` // Address sent a confirmation link, not mailed anything else until it is clicked.
+	SentFor string `json:"sentFor"` // Date of the last day digested, e.g. "2023-10-26".
+}
+
+// MailSettings signs the links in emails that confirm an address or unsubscribe it.
+type MailSettings struct {
+	Ref     string `json:"ref"`     // Public reference used in link URLs instead of the tot ID.
+	Secret  string `json:"secret"`  // HMAC key for link signatures.
+	BaseURL string `json:"baseUrl"` // Scheme and host the last address was entered on, used when PublicURL isn't set.
+}
+
+// AlertSettings configures overdue alerts for a tot.
+type AlertSettings struct {
+	Thresholds map[string]int       `json:"thresholds"` // Alert kind to minutes without an event.
+	Notifier   string               `json:"notifier"`
+	Target     string               `json:"target"`  // For email, only set once the address is confirmed.
+	Pending    string               `json:"pending"` // Email address sent a confirmation link.
+	Overdue    map[string]time.Time `json:"overdue"` // Alert kinds already notified, with when.
+}
+
+// Webhook is a subscription to a tot's change events.
+type Webhook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"` // Key for the HMAC-SHA256 signature of each payload.
+}
+
+// WebhookDelivery records the outcome of delivering one event to one webhook.
+type WebhookDelivery struct {
+	WebhookID string    `json:"webhookId"`
+	Event     string    `json:"event"`
+	At        time.Time `json:"at"`
+	Attempts  int       `json:"attempts"`
+	Status    int       `json:"status"` // HTTP status of the last attempt, 0 if there was no response.
+	Error     string    `json:"error"`
+}
+
+// QuickLogSettings holds the signed one-tap links that log a tally without opening the dashboard.
+type QuickLogSettings struct {
+	Ref    string           `json:"ref"`    // Public reference used in link URLs instead of the tot ID.
+	Secret string           `json:"secret"` // HMAC key for link signatures.
+	Links  map[int64]string `json:"links"`  // Kind key to the nonce of its current link.
 }
 
 // Tally represents a single recorded event.
@@ -73,28 +166,172 @@ type GeneratedStats struct {
 
 // HomePageData is passed to the index.html template.
 type HomePageData struct {
+	Locale       *totI18n.Locale
 	FlashMessage string
 	IsErrorFlash bool
+	Timezones    []TimezoneGroup
+}
+
+// TimezoneGroup is an <optgroup> of the timezone selectors, e.g. "Europe".
+type TimezoneGroup struct {
+	Region  string
+	Options []TimezoneOption
+}
+
+// TimezoneOption is one zone, labelled with its current offset, e.g. "Buenos Aires (GMT-3:00)".
+type TimezoneOption struct {
+	Value    string
+	Label    string
+	Selected bool
 }
 
 // TotPageData is passed to the tot.html dashboard template.
 type TotPageData struct {
+	Locale             *totI18n.Locale // Translates the page.
+	Languages          []TotPageLanguage
+	DayStartOptions    []TotPageHour
+	Display            DisplaySettings
 	ID                 string
 	Name               string
 	Timezone           string
 	MilkSetting        string
 	MilkSettingDisplay string
+	DayStartsAt        int
+	DayStartsAtDisplay string
 	FlashMessage       string
 	IsErrorFlash       bool
 	Tallies            []TotPageTally
 	Stats              TotPageStats
 	GeneratedStats     GeneratedStats
+	Charts             TotPageCharts
+	Predictions        TotPagePredictions
+	Alerts             []string
+	AlertSettings      TotPageAlertSettings
+	Webhooks           []TotPageWebhook
+	WebhookLog         []TotPageWebhookDelivery
+	QuickLogs          []TotPageQuickLog
+	QuickLogKinds      []TotPageQuickLogKind
+	CalendarPath       string
+	AtomPath           string
+	DigestEmail        string
+	DigestPending      string
+	Timezones          []TimezoneGroup
+	TimezoneChanges    []TotPageTimezoneChange // Newest first.
+	BaseURL            string
 	MaxTallies         int
+	Journal            []TotPageJournalEntry
+	MaxJournalEntries  int
 }
 
-type TotPageTally struct {
-	Time string
+// TotPageJournalEntry is a journal entry in the page's language.
+type TotPageJournalEntry struct {
+	ID     string
+	Time   string
+	Kind   string
+	Detail string // e.g. how long a sleep lasted, or a medication and its dose.
+}
+
+// TotPageAlertSettings holds the current alert settings for the settings form.
+type TotPageAlertSettings struct {
+	Kinds        []TotPageAlertKind
+	Notifier     string
+	Target       string
+	Pending      string // Email address awaiting confirmation.
+	EmailEnabled bool
+}
+
+type TotPageAlertKind struct {
+	Name  string
+	Label string
+	Hours string // Empty when disabled.
+}
+
+type TotPageWebhook struct {
+	ID     string
+	URL    string
+	Secret string
+}
+
+type TotPageWebhookDelivery struct {
+	Time     string
+	Event    string
+	URL      string
+	Attempts int
+	Result   string
+	Failed   bool
+}
+
+type TotPageQuickLog struct {
+	Key  int64
 	Kind string
+	Path string
+}
+
+type TotPageQuickLogKind struct {
+	Key  int64
+	Kind string
+}
+
+// TotPageLanguage is an option of the language selector.
+type TotPageLanguage struct {
+	Tag      string // Empty for automatic.
+	Name     string
+	Selected bool
+}
+
+// TotPageHour is an option of the day start selector.
+type TotPageHour struct {
+	Value int
+	Label string
+}
+
+// QuickLogPageData is passed to the quicklog.html confirmation template.
+type QuickLogPageData struct {
+	Name    string
+	Kind    string
+	Message string
+	Error   bool
+}
+
+// MailPageData is passed to the mail.html template shown for confirmation and unsubscribe links.
+type MailPageData struct {
+	Name    string
+	Message string
+	Button  string // Label of the button that applies the link; empty once it has been applied.
+	Error   bool
+}
+
+// TotPagePredictions holds the next expected feed and diaper messages.
+type TotPagePredictions struct {
+	Feed   TotPagePrediction
+	Diaper TotPagePrediction
+}
+
+// TotPagePrediction is empty when there is not enough history to predict from.
+type TotPagePrediction struct {
+	Next    string
+	Warning string
+}
+
+// TotPageCharts holds pre-rendered inline SVG charts for the dashboard.
+type TotPageCharts struct {
+	Milk     template.HTML
+	Nurse    template.HTML
+	Diapers  template.HTML
+	Timeline template.HTML
+}
+
+type TotPageTally struct {
+	Time  string
+	Exact string // Time as a date and time when Time is relative.
+	Kind  string
+}
+
+// TotPageTimezoneChange is a recorded timezone switch, with At in the zone switched to.
+type TotPageTimezoneChange struct {
+	At   string
+	From string
+	To   string
 }
 
 type TotPageStats struct {
@@ -109,3 +346,139 @@ type TotPageStats struct {
 	LastBath       string
 	LastBrush      string
 }
+
+// ReportPageData is passed to the report.html trend report template.
+type ReportPageData struct {
+	ID          string
+	Name        string
+	Timezone    string
+	Range       string
+	Title       string
+	DayStartsAt string
+	GeneratedAt string
+	Columns     []ReportColumn
+	Rows        []ReportRow
+	MaxTallies  int
+	CoveredFrom string // The first day with saved tallies, when older ones were dropped.
+}
+
+type ReportColumn struct {
+	Label  string
+	Unit   string
+	Avg    string
+	Min    string
+	Max    string
+	Change string
+}
+
+type ReportRow struct {
+	Date    string
+	Partial bool
+	Values  []string
+}
+
+// SummaryPageData is passed to the summary.html printable visit summary template.
+type SummaryPageData struct {
+	ID          string
+	Name        string
+	Timezone    string
+	From        string // yyyy-mm-dd, for the date inputs.
+	To          string
+	Title       string
+	DayStartsAt string
+	GeneratedAt string
+	Totals      []SummaryTotal
+	Columns     []ReportColumn
+	Rows        []ReportRow
+	Medications []SummaryEntry
+	Growth      []SummaryGrowth
+	Notes       []SummaryEntry
+	MaxTallies  int
+	CoveredFrom string // The first day with saved tallies, when older ones were dropped.
+}
+
+// SummaryEntry is a medication and its dose, or a note, on the visit summary.
+type SummaryEntry struct {
+	Time   string
+	Text   string
+	Detail string
+}
+
+// SummaryGrowth is a growth measurement on the visit summary, with "---" for what wasn't measured.
+type SummaryGrowth struct {
+	Date   string
+	Weight string
+	Length string
+	Head   string
+}
+
+// SummaryTotal summarizes one category across a summary's date range.
+type SummaryTotal struct {
+	Label string
+	Total string
+	Avg   string
+	Min   string
+	Max   string
+}
+
+// APITot is a tot in the JSON API.
+type APITot struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Timezone    string    `json:"timezone"`
+	MilkSetting string    `json:"milkSetting"`
+	DayStartsAt int       `json:"dayStartsAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// APITotUpdate changes the settings of a tot. Omitted fields are left unchanged.
+type APITotUpdate struct {
+	Timezone    *string `json:"timezone"`
+	MilkSetting *string `json:"milkSetting"`
+	DayStartsAt *int    `json:"dayStartsAt"`
+}
+
+// APITally is a tally in the JSON API. Key is its number in config.TallyKindMap.
+type APITally struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Key  int64     `json:"key"`
+}
+
+type APITallyList struct {
+	Tallies []APITally `json:"tallies"`
+}
+
+// APITallyInput adds a tally, or edits one when Time is set. Omitted fields are left unchanged.
+type APITallyInput struct {
+	Key  *int64     `json:"key"`
+	Time *time.Time `json:"time"`
+}
+
+// APIStats holds the computed stats of a tot.
+type APIStats struct {
+	Latest      Stats                    `json:"latest"`
+	Summary     GeneratedStats           `json:"summary"`
+	Predictions map[string]APIPrediction `json:"predictions"`
+	Alerts      []string                 `json:"alerts"`
+}
+
+// APIPrediction is the expected next occurrence of an activity. Next is null when there is
+// not enough recent history.
+type APIPrediction struct {
+	Next               *time.Time `json:"next"`
+	ExpectedGapMinutes int        `json:"expectedGapMinutes"`
+	Unusual            bool       `json:"unusual"`
+}
+
+// APIError is the body of every JSON API error response.
+type APIError struct {
+	Error APIErrorDetail `json:"error"`
+}
+
+type APIErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,271 @@
+// mqtt.go is a minimal MQTT 3.1.1 client over net. It only publishes at QoS 0, which is all
+// retained sensor state needs, and keeps the connection alive with pings.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet types, already shifted into the high nibble of the fixed header.
+const (
+	packetConnect    = 0x10
+	packetConnAck    = 0x20
+	packetPublish    = 0x30
+	packetPingReq    = 0xC0
+	packetDisconnect = 0xE0
+)
+
+// Connect flags.
+const (
+	flagCleanSession = 0x02
+	flagWill         = 0x04
+	flagWillRetain   = 0x20
+	flagPassword     = 0x40
+	flagUsername     = 0x80
+)
+
+// maxRemainingLength is the largest length the four byte variable length encoding can hold.
+const maxRemainingLength = 268435455
+
+// ErrClosed is returned when publishing on a connection that has been lost or closed.
+var ErrClosed = errors.New("mqtt: connection closed")
+
+// Message is an application message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Options configures a connection.
+type Options struct {
+	ClientID string
+	Username string // Empty connects anonymously.
+	Password string
+	// KeepAlive is the longest the broker waits between packets before dropping the client.
+	// Pings are sent at half this interval. Zero disables keep alive.
+	KeepAlive time.Duration
+	// Will is published by the broker if the connection is lost without a disconnect.
+	Will *Message
+	// WriteTimeout bounds every write after connecting. A write that times out drops the
+	// connection, since part of a packet may have been sent. Zero waits indefinitely.
+	WriteTimeout time.Duration
+}
+
+// Client is a connection to a broker. It is safe for concurrent use.
+type Client struct {
+	conn         net.Conn
+	writeTimeout time.Duration
+
+	mu     sync.Mutex // Serializes writes.
+	done   chan struct{}
+	closer sync.Once
+}
+
+// Dial connects to the broker at addr and waits for it to accept the connection.
+func Dial(ctx context.Context, addr string, opts Options) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: failed to dial %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(connectPacket(opts)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: failed to send connect: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	header, body, err := readPacket(r)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: failed to read connack: %w", err)
+	}
+	if header&0xF0 != packetConnAck || len(body) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: unexpected packet 0x%02x instead of connack", header)
+	}
+	if body[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: connection refused with code %d", body[1])
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &Client{conn: conn, writeTimeout: opts.WriteTimeout, done: make(chan struct{})}
+	go c.read(r)
+	if opts.KeepAlive > 0 {
+		go c.ping(opts.KeepAlive / 2)
+	}
+	return c, nil
+}
+
+// Publish sends a message at QoS 0.
+func (c *Client) Publish(m Message) error {
+	header := byte(packetPublish)
+	if m.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, m.Topic)
+	body = append(body, m.Payload...)
+	return c.write(header, body)
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects cleanly, so the broker discards the will.
+func (c *Client) Close() error {
+	err := c.write(packetDisconnect, nil)
+	c.shutdown()
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) write(header byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return errors.New("mqtt: packet too large")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	packet := append([]byte{header}, encodeLength(len(body))...)
+	if _, err := c.conn.Write(append(packet, body...)); err != nil {
+		c.shutdown()
+		return fmt.Errorf("mqtt: write failed: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) shutdown() {
+	c.closer.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// read discards incoming packets until the connection ends. A publish-only client with
+// QoS 0 only ever receives ping responses.
+func (c *Client) read(r *bufio.Reader) {
+	defer c.shutdown()
+	for {
+		if _, _, err := readPacket(r); err != nil {
+			return
+		}
+	}
+}
+
+func (c *Client) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(packetPingReq, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func connectPacket(opts Options) []byte {
+	flags := byte(flagCleanSession)
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1.
+	flagsAt := len(body)
+	body = append(body, 0)
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+
+	body = appendString(body, opts.ClientID)
+	if opts.Will != nil {
+		flags |= flagWill
+		if opts.Will.Retain {
+			flags |= flagWillRetain
+		}
+		body = appendString(body, opts.Will.Topic)
+		body = binary.BigEndian.AppendUint16(body, uint16(len(opts.Will.Payload)))
+		body = append(body, opts.Will.Payload...)
+	}
+	if opts.Username != "" {
+		flags |= flagUsername
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			flags |= flagPassword
+			body = appendString(body, opts.Password)
+		}
+	}
+	body[flagsAt] = flags
+
+	return append(append([]byte{packetConnect}, encodeLength(len(body))...), body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// encodeLength encodes a remaining length as 7 bits per byte, least significant first.
+func encodeLength(n int) []byte {
+	var b []byte
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("mqtt: malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
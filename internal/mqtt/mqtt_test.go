@@ -0,0 +1,98 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"tot-tally/internal/mqtt/mqtttest"
+)
+
+func TestClient(t *testing.T) {
+	broker := mqtttest.NewBroker(t)
+	broker.Username, broker.Password = "tot", "secret"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := Dial(ctx, broker.Addr, Options{ClientID: "test", Username: "tot", Password: "wrong"}); err == nil || !strings.Contains(err.Error(), "code 5") {
+		t.Fatalf("expected bad credentials to be refused, got %v", err)
+	}
+
+	will := &Message{Topic: "test/status", Payload: []byte("offline"), Retain: true}
+	c, err := Dial(ctx, broker.Addr, Options{ClientID: "test", Username: "tot", Password: "secret", KeepAlive: 20 * time.Millisecond, Will: will})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	long := strings.Repeat("🍼", 100) // Needs a two byte remaining length.
+	if err := c.Publish(Message{Topic: "test/long", Payload: []byte(long), Retain: true}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	c.Publish(Message{Topic: "test/event", Payload: []byte("hi")})
+
+	// Pings keep the connection open past the keep alive.
+	time.Sleep(50 * time.Millisecond)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := c.Publish(Message{Topic: "test/late"}); err != ErrClosed {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+
+	waitFor(t, func() bool { return len(broker.Messages()) == 2 })
+	if v, _ := broker.Retained("test/long"); v != long {
+		t.Errorf("unexpected retained payload %q", v)
+	}
+	if _, ok := broker.Retained("test/event"); ok {
+		t.Error("expected a non-retained message not to be retained")
+	}
+	// A clean disconnect discards the will.
+	if _, ok := broker.Retained("test/status"); ok {
+		t.Error("expected no will after a clean disconnect")
+	}
+
+	c, _ = Dial(ctx, broker.Addr, Options{ClientID: "test", Username: "tot", Password: "secret", Will: will})
+	broker.DropClients()
+	<-c.Done()
+	waitFor(t, func() bool { v, _ := broker.Retained("test/status"); return v == "offline" })
+}
+
+func TestClient_WriteTimeout(t *testing.T) {
+	// Nothing reads the other end of the pipe, like a broker that stopped reading.
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	c := &Client{conn: conn, writeTimeout: 20 * time.Millisecond, done: make(chan struct{})}
+
+	if err := c.Publish(Message{Topic: "test/stuck", Payload: []byte("hi")}); err == nil || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected the write to time out, got %v", err)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("expected a timed out write to drop the connection")
+	}
+	if err := c.Publish(Message{Topic: "test/late"}); err != ErrClosed {
+		t.Errorf("expected ErrClosed after a timeout, got %v", err)
+	}
+}
+
+func TestEncodeLength(t *testing.T) {
+	for n, want := range map[int]string{0: "\x00", 127: "\x7f", 128: "\x80\x01", 16383: "\xff\x7f", 2097152: "\x80\x80\x80\x01"} {
+		if got := string(encodeLength(n)); got != want {
+			t.Errorf("encodeLength(%d) = %q, expected %q", n, got, want)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for range 100 {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the broker")
+}
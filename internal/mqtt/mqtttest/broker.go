@@ -0,0 +1,230 @@
+// broker.go provides an in-process MQTT broker stand-in for tests, in the spirit of httptest.
+package mqtttest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// Message is a publish received by the Broker, or a will it published for a lost client.
+type Message struct {
+	Topic   string
+	Payload string
+	Retain  bool
+}
+
+// Broker accepts MQTT 3.1.1 clients on a local port and records what they publish. It
+// supports just enough of the protocol for QoS 0 publishers: connect with an optional will
+// and credentials, publish, ping and disconnect. Nothing is forwarded to subscribers.
+type Broker struct {
+	Addr string
+	// Username and Password, when set, are required from clients.
+	Username, Password string
+	listener           net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	retained map[string]string
+	conns    map[net.Conn]bool
+	connects int
+}
+
+// NewBroker starts a broker that is closed when the test ends.
+func NewBroker(t testing.TB) *Broker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mqtttest: failed to listen: %v", err)
+	}
+
+	b := &Broker{Addr: l.Addr().String(), listener: l, retained: map[string]string{}, conns: map[net.Conn]bool{}}
+	go b.serve()
+	t.Cleanup(func() {
+		l.Close()
+		b.DropClients()
+	})
+	return b
+}
+
+// Messages returns a copy of every message received so far.
+func (b *Broker) Messages() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.messages...)
+}
+
+// Retained returns the retained payload of a topic.
+func (b *Broker) Retained(topic string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// Connects returns how many clients have connected successfully.
+func (b *Broker) Connects() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connects
+}
+
+// DropClients closes every client connection without a disconnect, publishing their wills.
+func (b *Broker) DropClients() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn := range b.conns {
+		conn.Close()
+	}
+}
+
+func (b *Broker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *Broker) handle(conn net.Conn) {
+	b.mu.Lock()
+	b.conns[conn] = true
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	header, body, err := readPacket(r)
+	if err != nil || header != 0x10 {
+		return
+	}
+	will, ok := b.connect(body)
+	if !ok {
+		conn.Write([]byte{0x20, 0x02, 0x00, 0x05}) // Not authorized.
+		return
+	}
+	conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			if will != nil {
+				b.record(*will)
+			}
+			return
+		}
+		switch header & 0xF0 {
+		case 0x30:
+			if header&0x06 != 0 || len(body) < 2 {
+				return // Only QoS 0 is supported.
+			}
+			n := int(binary.BigEndian.Uint16(body))
+			if len(body) < 2+n {
+				return
+			}
+			b.record(Message{Topic: string(body[2 : 2+n]), Payload: string(body[2+n:]), Retain: header&0x01 != 0})
+		case 0xC0:
+			conn.Write([]byte{0xD0, 0x00})
+		case 0xE0:
+			return
+		default:
+			return
+		}
+	}
+}
+
+// connect parses a CONNECT body, returning the client's will and whether it may connect.
+func (b *Broker) connect(body []byte) (*Message, bool) {
+	var err error
+	next := func() string {
+		if err != nil || len(body) < 2 {
+			err = errors.New("short packet")
+			return ""
+		}
+		n := int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+n {
+			err = errors.New("short packet")
+			return ""
+		}
+		s := string(body[2 : 2+n])
+		body = body[2+n:]
+		return s
+	}
+
+	if next() != "MQTT" || len(body) < 4 || body[0] != 4 {
+		return nil, false
+	}
+	flags := body[1]
+	body = body[4:]
+	next() // Client ID.
+
+	var will *Message
+	if flags&0x04 != 0 {
+		will = &Message{Topic: next(), Payload: next(), Retain: flags&0x20 != 0}
+	}
+	var username, password string
+	if flags&0x80 != 0 {
+		username = next()
+	}
+	if flags&0x40 != 0 {
+		password = next()
+	}
+	if err != nil || username != b.Username || password != b.Password {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	b.connects++
+	b.mu.Unlock()
+	return will, true
+}
+
+func (b *Broker) record(m Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, m)
+	if m.Retain {
+		if m.Payload == "" {
+			delete(b.retained, m.Topic)
+		} else {
+			b.retained[m.Topic] = m.Payload
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("mqtttest: malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
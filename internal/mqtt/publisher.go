@@ -0,0 +1,297 @@
+// publisher.go publishes each tot's state as retained MQTT topics for home automation, with
+// Home Assistant discovery payloads so the sensors appear without manual configuration.
+package mqtt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+// sensor is one state topic of a tot, e.g. "tot-tally/<tot>/last_milk".
+type sensor struct {
+	Key      string
+	Name     string
+	Category totStats.Category
+	Today    bool // Today's total rather than the time of the last tally.
+	// Marker is the latest-activity time kept in the tot's stats, used when the last tally was
+	// dropped past MaxTallies.
+	Marker func(totModels.Stats) *time.Time
+}
+
+var sensors = []sensor{
+	{Key: "last_milk", Name: "Last bottle", Category: totStats.MilkCategory, Marker: func(s totModels.Stats) *time.Time { return s.LastMilk }},
+	{Key: "today_milk", Name: "Bottle today", Category: totStats.MilkCategory, Today: true},
+	{Key: "last_nurse", Name: "Last nursing", Category: totStats.NurseCategory, Marker: func(s totModels.Stats) *time.Time { return s.LastNurse }},
+	{Key: "today_nurse", Name: "Nursing sessions today", Category: totStats.NurseCategory, Today: true},
+	{Key: "last_diaper", Name: "Last diaper", Category: totStats.DiaperCategory, Marker: func(s totModels.Stats) *time.Time {
+		if s.LastPee == nil || (s.LastPoo != nil && s.LastPoo.After(*s.LastPee)) {
+			return s.LastPoo
+		}
+		return s.LastPee
+	}},
+	{Key: "today_pee", Name: "Wet diapers today", Category: totStats.PeeCategory, Today: true},
+	{Key: "today_poo", Name: "Dirty diapers today", Category: totStats.PooCategory, Today: true},
+}
+
+// noValue tells Home Assistant a sensor has no state yet.
+const noValue = "None"
+
+// state is a snapshot of a tot's sensor values.
+type state struct {
+	node   string // Identifies the tot in topics without revealing its ID.
+	name   string
+	values []string // Indexed like sensors.
+}
+
+type discoveryPayload struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	StateTopic        string          `json:"state_topic"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Unit              string          `json:"unit_of_measurement,omitempty"`
+	Device            discoveryDevice `json:"device"`
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// Publisher queues tot changes from core.Service and publishes them to the broker.
+type Publisher struct {
+	config *totConfig.Config
+	store  *totStorage.Repository
+	pool   *totShards.Pool
+	engine *totStats.Engine
+	queue  chan state
+
+	// Owned by the background goroutine.
+	client    *Client
+	announced map[string]string // Node to the tot name its discovery payloads were sent with.
+}
+
+// NewPublisher initializes the MQTT publishing service.
+func NewPublisher(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool, engine *totStats.Engine) *Publisher {
+	return &Publisher{
+		config: cfg,
+		store:  store,
+		pool:   pool,
+		engine: engine,
+		queue:  make(chan state, cfg.MQTTQueue),
+	}
+}
+
+// TotChanged implements core.Listener by queueing the tot's new state.
+// States are dropped rather than blocking the request when the queue is full.
+func (p *Publisher) TotChanged(tot *totModels.Tot, event totCore.Event) {
+	st, err := p.snapshot(tot, time.Now())
+	if err != nil {
+		slog.Error("MQTT state failed", "id", tot.ID, "err", err)
+		return
+	}
+	select {
+	case p.queue <- st:
+	default:
+		slog.Warn("MQTT queue full, dropping state", "id", tot.ID, "event", event.Type)
+	}
+}
+
+// StartBackgroundPublisher initiates a goroutine that publishes queued states, and every tot's
+// state each MQTTInterval, until ctx is done.
+func (p *Publisher) StartBackgroundPublisher(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.config.MQTTInterval)
+		defer ticker.Stop()
+		defer p.disconnect()
+
+		p.publishAll(ctx)
+		for {
+			select {
+			case st := <-p.queue:
+				p.publish(ctx, st)
+			case <-ticker.C:
+				p.publishAll(ctx)
+			case <-ctx.Done():
+				slog.Info("background MQTT publisher stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (p *Publisher) publishAll(ctx context.Context) {
+	ids, err := p.store.ListTotIDs()
+	if err != nil {
+		slog.Error("MQTT publishing failed", "err", err)
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		mut := p.pool.GetShardMutex(id)
+		mut.Lock()
+		tot, err := p.store.LoadTot(id)
+		if err != nil {
+			mut.Unlock()
+			slog.Warn("MQTT publishing failed for tot", "id", id, "err", err)
+			continue
+		}
+		st, err := p.snapshot(tot, time.Now())
+		mut.Unlock()
+		if err != nil {
+			slog.Warn("MQTT publishing failed for tot", "id", id, "err", err)
+			continue
+		}
+		p.publish(ctx, st)
+	}
+}
+
+// snapshot computes the values of every sensor. Times are RFC 3339 in UTC, which Home Assistant
+// shows in the viewer's timezone.
+func (p *Publisher) snapshot(tot *totModels.Tot, now time.Time) (state, error) {
+	tz, _ := time.LoadLocation(tot.Timezone)
+	categories := make([]totStats.Category, len(sensors))
+	for i, s := range sensors {
+		categories[i] = s.Category
+	}
+	report, err := p.engine.Report(tot, tz, now, 1, categories)
+	if err != nil {
+		return state{}, err
+	}
+
+	sum := sha256.Sum256([]byte(tot.ID))
+	st := state{node: fmt.Sprintf("%x", sum[:8]), name: tot.Name, values: make([]string, len(sensors))}
+	for i, s := range sensors {
+		if s.Today {
+			st.values[i] = fmt.Sprint(report.Days[0].Values[i])
+			continue
+		}
+		var last *time.Time
+		for _, t := range tot.Tallies {
+			if t.Time != nil && s.Category.Match(t.Kind) && (last == nil || t.Time.After(*last)) {
+				last = t.Time
+			}
+		}
+		if last == nil {
+			last = s.Marker(tot.Stats)
+		}
+		st.values[i] = noValue
+		if last != nil {
+			st.values[i] = last.UTC().Format(time.RFC3339)
+		}
+	}
+	return st, nil
+}
+
+// publish sends a state as retained messages, preceded by its discovery payloads the first time
+// the tot is seen on a connection or after it is renamed.
+func (p *Publisher) publish(ctx context.Context, st state) {
+	if err := p.connect(ctx); err != nil {
+		slog.Warn("MQTT connection failed", "err", err)
+		return
+	}
+
+	var msgs []Message
+	if p.config.MQTTDiscoveryPrefix != "" && p.announced[st.node] != st.name {
+		for _, s := range sensors {
+			msgs = append(msgs, p.discovery(st, s))
+		}
+	}
+	for i, s := range sensors {
+		msgs = append(msgs, Message{Topic: p.stateTopic(st.node, s), Payload: []byte(st.values[i]), Retain: true})
+	}
+
+	for _, m := range msgs {
+		if err := p.client.Publish(m); err != nil {
+			slog.Warn("MQTT publish failed", "topic", m.Topic, "err", err)
+			p.client = nil
+			return
+		}
+	}
+	p.announced[st.node] = st.name
+}
+
+func (p *Publisher) discovery(st state, s sensor) Message {
+	id := "tot_tally_" + st.node
+	payload := discoveryPayload{
+		Name:              s.Name,
+		UniqueID:          id + "_" + s.Key,
+		StateTopic:        p.stateTopic(st.node, s),
+		AvailabilityTopic: p.availabilityTopic(),
+		DeviceClass:       "timestamp",
+		Device:            discoveryDevice{Identifiers: []string{id}, Name: "Tot-Tally " + st.name, Manufacturer: "Tot-Tally"},
+	}
+	if s.Today {
+		// Daily totals drop back to zero when the day starts, which total_increasing treats as a reset.
+		payload.DeviceClass, payload.StateClass, payload.Unit = "", "total_increasing", s.Category.Unit
+	}
+	body, _ := json.Marshal(payload)
+	return Message{Topic: fmt.Sprintf("%s/sensor/%s/%s/config", p.config.MQTTDiscoveryPrefix, id, s.Key), Payload: body, Retain: true}
+}
+
+func (p *Publisher) stateTopic(node string, s sensor) string {
+	return p.config.MQTTTopicPrefix + "/" + node + "/" + s.Key
+}
+
+// availabilityTopic is "online" while connected. The broker publishes the "offline" will
+// if the connection is lost.
+func (p *Publisher) availabilityTopic() string {
+	return p.config.MQTTTopicPrefix + "/status"
+}
+
+// connect reuses the current connection or dials a new one. Discovery payloads are sent again
+// after reconnecting, in case the broker lost its retained messages.
+func (p *Publisher) connect(ctx context.Context) error {
+	if p.client != nil {
+		select {
+		case <-p.client.Done():
+			p.client = nil
+		default:
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.NotifyTimeout)
+	defer cancel()
+	client, err := Dial(ctx, p.config.MQTTAddr, Options{
+		ClientID:  p.config.MQTTClientID,
+		Username:  p.config.MQTTUsername,
+		Password:  p.config.MQTTPassword,
+		KeepAlive: p.config.MQTTKeepAlive,
+		Will:      &Message{Topic: p.availabilityTopic(), Payload: []byte("offline"), Retain: true},
+		// A broker that stops reading would otherwise stall publishing for good.
+		WriteTimeout: p.config.NotifyTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	if err := client.Publish(Message{Topic: p.availabilityTopic(), Payload: []byte("online"), Retain: true}); err != nil {
+		return err
+	}
+	p.client, p.announced = client, map[string]string{}
+	return nil
+}
+
+// disconnect marks the sensors unavailable and closes the connection cleanly.
+func (p *Publisher) disconnect() {
+	if p.client == nil {
+		return
+	}
+	p.client.Publish(Message{Topic: p.availabilityTopic(), Payload: []byte("offline"), Retain: true})
+	p.client.Close()
+	p.client = nil
+}
@@ -0,0 +1,149 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	"tot-tally/internal/mqtt/mqtttest"
+	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
+	totStorage "tot-tally/internal/storage"
+)
+
+func setupPublisher(t *testing.T) (*Publisher, *totStorage.Repository, *mqtttest.Broker) {
+	broker := mqtttest.NewBroker(t)
+	cfg := &totConfig.Config{
+		TotDirectory: t.TempDir(), MaxTallies: 10, NotifyTimeout: time.Second,
+		MQTTAddr: broker.Addr, MQTTClientID: "tot-tally", MQTTTopicPrefix: "tot-tally",
+		MQTTDiscoveryPrefix: "homeassistant", MQTTInterval: time.Hour, MQTTQueue: 4,
+	}
+	pool := totShards.NewPool(4)
+	repo := totStorage.NewRepository(cfg, pool)
+	p := NewPublisher(cfg, repo, pool, totStats.NewEngine(cfg))
+	t.Cleanup(p.disconnect)
+	return p, repo, broker
+}
+
+func TestPublisher(t *testing.T) {
+	p, _, broker := setupPublisher(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	milk, pee := now.Add(-time.Second), now.Add(-2*time.Second)
+	tot := &totModels.Tot{ID: "tot", Name: "👶", Timezone: "UTC", Tallies: []totModels.Tally{
+		{Time: &milk, Kind: "🍼4"},
+		{Time: &pee, Kind: "🚽"},
+	}}
+
+	p.TotChanged(tot, totCore.Event{Type: totCore.EventTallyAdded})
+	p.publish(context.Background(), <-p.queue)
+
+	node := p.stateTopic(mustSnapshot(t, p, tot).node, sensors[0])
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "tot-tally/"), "/last_milk")
+	if strings.Contains(node, "tot") || len(node) != 16 {
+		t.Errorf("expected a hashed node ID, got %q", node)
+	}
+	for topic, want := range map[string]string{
+		"tot-tally/status":                   "online",
+		"tot-tally/" + node + "/last_milk":   milk.Format(time.RFC3339),
+		"tot-tally/" + node + "/today_milk":  "4",
+		"tot-tally/" + node + "/last_nurse":  "None",
+		"tot-tally/" + node + "/last_diaper": pee.Format(time.RFC3339),
+		"tot-tally/" + node + "/today_pee":   "1",
+	} {
+		expectRetained(t, broker, topic, want)
+	}
+
+	raw, _ := broker.Retained("homeassistant/sensor/tot_tally_" + node + "/today_milk/config")
+	var discovery discoveryPayload
+	json.Unmarshal([]byte(raw), &discovery)
+	if discovery.StateTopic != "tot-tally/"+node+"/today_milk" || discovery.Unit != "oz" || discovery.StateClass != "total_increasing" ||
+		discovery.AvailabilityTopic != "tot-tally/status" || discovery.Device.Name != "Tot-Tally 👶" {
+		t.Errorf("unexpected discovery payload: %s", raw)
+	}
+
+	// Discovery is only sent again after a rename.
+	count := len(broker.Messages())
+	p.publish(context.Background(), mustSnapshot(t, p, tot))
+	waitFor(t, func() bool { return len(broker.Messages()) >= count+len(sensors) })
+	tot.Name = "🦖"
+	p.publish(context.Background(), mustSnapshot(t, p, tot))
+	waitFor(t, func() bool { return len(broker.Messages()) == count+3*len(sensors) })
+	raw, _ = broker.Retained("homeassistant/sensor/tot_tally_" + node + "/last_milk/config")
+	if !strings.Contains(raw, "Tot-Tally 🦖") {
+		t.Errorf("expected renamed device, got %s", raw)
+	}
+}
+
+func TestPublisher_SnapshotMarkers(t *testing.T) {
+	p, _, _ := setupPublisher(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	milk, nurse, pee, poo := now.Add(-time.Minute), now.Add(-2*time.Hour), now.Add(-3*time.Hour), now.Add(-4*time.Hour)
+	// Nursing and diaper tallies were dropped past MaxTallies; their latest-activity times remain.
+	tot := &totModels.Tot{ID: "tot", Name: "👶", Timezone: "UTC",
+		Tallies: []totModels.Tally{{Time: &milk, Kind: "🍼4"}},
+		Stats:   totModels.Stats{LastMilk: &pee, LastNurse: &nurse, LastPee: &pee, LastPoo: &poo},
+	}
+
+	st := mustSnapshot(t, p, tot)
+	for i, want := range map[int]string{
+		0: milk.Format(time.RFC3339), // A matching tally wins over the marker.
+		2: nurse.Format(time.RFC3339),
+		4: pee.Format(time.RFC3339), // The newer of the pee and poo markers.
+	} {
+		if st.values[i] != want {
+			t.Errorf("%s: expected %s, got %s", sensors[i].Key, want, st.values[i])
+		}
+	}
+
+	tot.Stats = totModels.Stats{}
+	if st := mustSnapshot(t, p, tot); st.values[4] != noValue {
+		t.Errorf("expected no diaper time without tallies or markers, got %s", st.values[4])
+	}
+}
+
+func TestPublisher_Reconnect(t *testing.T) {
+	p, repo, broker := setupPublisher(t)
+	tot := &totModels.Tot{ID: "tot", Name: "👶", Timezone: "UTC"}
+	repo.SaveTotWithoutActivity(tot)
+
+	p.publishAll(context.Background())
+	client := p.client
+	broker.DropClients()
+	<-client.Done()
+	expectRetained(t, broker, "tot-tally/status", "offline")
+
+	p.publishAll(context.Background())
+	if broker.Connects() != 2 {
+		t.Errorf("expected a reconnect, got %d connects", broker.Connects())
+	}
+	expectRetained(t, broker, "tot-tally/status", "online")
+
+	p.disconnect()
+	expectRetained(t, broker, "tot-tally/status", "offline")
+}
+
+// expectRetained waits for the broker to hold a retained payload, since publishes are not acknowledged.
+func expectRetained(t *testing.T, broker *mqtttest.Broker, topic, want string) {
+	t.Helper()
+	for range 100 {
+		if got, _ := broker.Retained(topic); got == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	got, _ := broker.Retained(topic)
+	t.Errorf("%s: expected %q, got %q", topic, want, got)
+}
+
+func mustSnapshot(t *testing.T, p *Publisher, tot *totModels.Tot) state {
+	t.Helper()
+	st, err := p.snapshot(tot, time.Now())
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	return st
+}
@@ -0,0 +1,86 @@
+// guard.go keeps URLs set by tot owners from reaching the server's own network.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for URLs and connections to loopback, private, link-local or
+// unspecified addresses.
+var ErrPrivateAddress = errors.New("notify: private address")
+
+// nonPublic lists the ranges netip has no predicate for: "this network" and carrier-grade NAT.
+var nonPublic = []netip.Prefix{netip.MustParsePrefix("0.0.0.0/8"), netip.MustParsePrefix("100.64.0.0/10")}
+
+// PublicAddr reports whether addr may be reached by a URL anyone with a tot's link can set.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL checks that raw is an http(s) URL and, unless allowPrivate is set, that its host isn't
+// a private address or localhost. Host names are only resolved when the client connects, where
+// NewClient checks the address again, so a DNS answer that changes after this check is caught.
+func CheckURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return errors.New("notify: invalid URL")
+	}
+	if allowPrivate {
+		return nil
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !PublicAddr(addr) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client for URLs set by tot owners. Unless allowPrivate is set, it
+// refuses to connect to a private address, checking the resolved IP of every connection,
+// redirects included. Proxies from the environment are ignored, since the check would then only
+// see the proxy's address.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// refusePrivate is a net.Dialer Control function, run with the resolved address just before
+// each connection is made.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !PublicAddr(addr) {
+		return fmt.Errorf("notify: refusing to connect to %s: %w", address, ErrPrivateAddress)
+	}
+	return nil
+}
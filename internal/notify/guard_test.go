@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.5":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"0.0.0.0":         false,
+		"::":              false,
+		"100.64.0.1":      false,
+		"::ffff:10.0.0.1": false,
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+	} {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("PublicAddr(%s) = %v, expected %v", addr, got, public)
+		}
+	}
+}
+
+func TestNewClient_RefusesPrivateAddresses(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+	// A host name is only checked once resolved, as a rebinding DNS server could answer anything.
+	byName := fmt.Sprintf("http://localhost:%d", srv.Listener.Addr().(*net.TCPAddr).Port)
+
+	n := &WebhookNotifier{Client: NewClient(time.Second, false)}
+	for _, target := range []string{srv.URL, byName} {
+		if err := n.Notify(context.Background(), target, Notification{}); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("%s: expected the connection refused, got %v", target, err)
+		}
+	}
+	if hit {
+		t.Error("expected no request to reach the private server")
+	}
+
+	n.Client = NewClient(time.Second, true)
+	if err := n.Notify(context.Background(), srv.URL, Notification{}); err != nil || !hit {
+		t.Errorf("expected private addresses allowed when configured, got %v", err)
+	}
+}
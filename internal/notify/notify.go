@@ -0,0 +1,156 @@
+// notify.go delivers user-facing notifications through pluggable transports.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+)
+
+// Notification is a short message for a person, e.g. an overdue alert.
+type Notification struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Unsubscribe is a link that stops the notifications; only email includes it.
+	Unsubscribe string `json:"-"`
+}
+
+// Notifier sends a notification to a transport-specific target, such as a URL or email address.
+type Notifier interface {
+	Notify(ctx context.Context, target string, n Notification) error
+}
+
+// NewNotifiers returns the available notifiers keyed by the names in config.AllowedNotifiers.
+// Email is only available when an SMTP relay is configured.
+func NewNotifiers(cfg *totConfig.Config) map[string]Notifier {
+	client := NewClient(cfg.NotifyTimeout, cfg.AllowPrivateTargets)
+	notifiers := map[string]Notifier{
+		"webhook": &WebhookNotifier{Client: client},
+		"ntfy":    &NtfyNotifier{Client: client},
+	}
+	if cfg.SMTPAddr != "" {
+		notifiers["email"] = &EmailNotifier{Mailer: NewMailer(cfg)}
+	}
+	return notifiers
+}
+
+// WebhookNotifier POSTs the notification as JSON.
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, target string, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("notify: failed to encode webhook: %w", err)
+	}
+	return post(ctx, w.Client, target, "application/json", body, nil)
+}
+
+// NtfyNotifier publishes to an ntfy-style topic URL: the message is the plain text body and
+// the title travels in a header.
+type NtfyNotifier struct {
+	Client *http.Client
+}
+
+// Notify implements Notifier.
+func (n *NtfyNotifier) Notify(ctx context.Context, target string, notification Notification) error {
+	headers := map[string]string{
+		"Title":    mime.QEncoding.Encode("utf-8", notification.Title),
+		"Priority": "high",
+		"Tags":     "warning",
+	}
+	return post(ctx, n.Client, target, "text/plain; charset=utf-8", []byte(notification.Message), headers)
+}
+
+func post(ctx context.Context, client *http.Client, target, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "tot-tally")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notify: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier sends the notification as a plain text email.
+type EmailNotifier struct {
+	Mailer *Mailer
+}
+
+// Notify implements Notifier.
+func (e *EmailNotifier) Notify(ctx context.Context, target string, n Notification) error {
+	body := n.Message
+	if n.Unsubscribe != "" {
+		body += "\n\n--\nStop these alerts: " + n.Unsubscribe + "\n"
+	}
+	return e.Mailer.Send(target, n.Title, body, n.Unsubscribe)
+}
+
+// Mailer sends plain text mail through an SMTP relay.
+type Mailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// NewMailer configures a Mailer from the SMTP settings. Authentication is only used when
+// a username is set; net/smtp refuses to send credentials without TLS except to localhost.
+func NewMailer(cfg *totConfig.Config) *Mailer {
+	m := &Mailer{Addr: cfg.SMTPAddr, From: cfg.SMTPFrom}
+	if cfg.SMTPUsername != "" {
+		host, _, _ := strings.Cut(cfg.SMTPAddr, ":")
+		m.Auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, host)
+	}
+	return m
+}
+
+// Send delivers a UTF-8 plain text message to a single recipient. A non-empty unsubscribe URL is
+// offered to mail clients for RFC 8058 one-click unsubscribe; the body should show it too.
+func (m *Mailer) Send(to, subject, body, unsubscribe string) error {
+	if strings.ContainsAny(to+subject+unsubscribe, "\r\n") {
+		return fmt.Errorf("notify: invalid header value")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if unsubscribe != "" {
+		fmt.Fprintf(&msg, "List-Unsubscribe: <%s>\r\n", unsubscribe)
+		msg.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(m.Addr, m.Auth, m.From, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("notify: failed to send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	"tot-tally/internal/notify/notifytest"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON content type, got %s", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n := &WebhookNotifier{Client: srv.Client()}
+	err := n.Notify(context.Background(), srv.URL, Notification{Title: "👶 Feed overdue", Message: "Last feed 3h 40m ago"})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.Title != "👶 Feed overdue" || got.Message != "Last feed 3h 40m ago" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	var title, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	n := &NtfyNotifier{Client: srv.Client()}
+	err := n.Notify(context.Background(), srv.URL+"/topic", Notification{Title: "Feed overdue", Message: "Last feed 3h ago"})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if title != "Feed overdue" || body != "Last feed 3h ago" {
+		t.Errorf("unexpected request: title=%q body=%q", title, body)
+	}
+}
+
+func TestNotifier_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	for name, n := range map[string]Notifier{
+		"webhook": &WebhookNotifier{Client: srv.Client()},
+		"ntfy":    &NtfyNotifier{Client: srv.Client()},
+	} {
+		if err := n.Notify(context.Background(), srv.URL, Notification{}); err == nil {
+			t.Errorf("%s: expected error for 500 response, got nil", name)
+		}
+		if err := n.Notify(context.Background(), "://bad-url", Notification{}); err == nil {
+			t.Errorf("%s: expected error for bad URL, got nil", name)
+		}
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	srv := notifytest.NewSMTPServer(t)
+	cfg := &totConfig.Config{SMTPAddr: srv.Addr, SMTPFrom: "tot@example.com", NotifyTimeout: time.Second}
+
+	n := NewNotifiers(cfg)["email"]
+	if n == nil {
+		t.Fatal("expected email notifier when SMTP is configured")
+	}
+
+	err := n.Notify(context.Background(), "parent@example.com", Notification{Title: "👶 Feed overdue", Message: "Line one\nLine two", Unsubscribe: "https://tally.example.com/m/ref/alerts/unsubscribe/sig"})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	if msgs[0].From != "tot@example.com" || len(msgs[0].To) != 1 || msgs[0].To[0] != "parent@example.com" {
+		t.Errorf("unexpected envelope: %+v", msgs[0])
+	}
+	if !strings.Contains(msgs[0].Data, "Subject: =?utf-8?q?") || !strings.Contains(msgs[0].Data, "Line one\r\nLine two") {
+		t.Errorf("unexpected message data: %q", msgs[0].Data)
+	}
+	for _, want := range []string{
+		"List-Unsubscribe: <https://tally.example.com/m/ref/alerts/unsubscribe/sig>\r\n",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n",
+		"Stop these alerts: https://tally.example.com/m/ref/alerts/unsubscribe/sig",
+	} {
+		if !strings.Contains(msgs[0].Data, want) {
+			t.Errorf("expected message to contain %q, got %q", want, msgs[0].Data)
+		}
+	}
+}
+
+func TestMailer_Errors(t *testing.T) {
+	m := NewMailer(&totConfig.Config{SMTPAddr: "127.0.0.1:1", SMTPUsername: "user"})
+	if m.Auth == nil {
+		t.Error("expected auth when a username is configured")
+	}
+	if err := m.Send("a@example.com\r\nBcc: b@example.com", "hi", "body", ""); err == nil {
+		t.Error("expected error for header injection, got nil")
+	}
+	if err := m.Send("a@example.com", "hi", "body", "https://example.com/\r\nBcc: b@example.com"); err == nil {
+		t.Error("expected error for header injection in the unsubscribe link, got nil")
+	}
+	if err := m.Send("a@example.com", "hi", "body", ""); err == nil {
+		t.Error("expected error for unreachable relay, got nil")
+	}
+}
+
+func TestNewNotifiers(t *testing.T) {
+	notifiers := NewNotifiers(totConfig.NewDefaultConfig())
+	if _, ok := notifiers["email"]; ok {
+		t.Error("expected email to be disabled without an SMTP relay")
+	}
+	for name := range notifiers {
+		if _, ok := totConfig.AllowedNotifiers[name]; !ok {
+			t.Errorf("notifier %q is not in config.AllowedNotifiers", name)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// aggregate.go is the declarative aggregation engine that evaluates metrics over time windows.
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// Aggregate selects how the tallies matching a metric are combined within a window.
+type Aggregate int
+
+const (
+	// Count is the number of matching tallies.
+	Count Aggregate = iota
+	// Sum is the total of the category amounts of matching tallies.
+	Sum
+	// AvgGap is the mean time between consecutive matching tallies.
+	AvgGap
+	// LastAt is the time of the most recent matching tally.
+	LastAt
+)
+
+// Category groups tally kinds under a single name.
+type Category struct {
+	Name  string
+	Emoji string
+	Label string // Plural description for people reading summaries, e.g. "Wet diapers".
+	Unit  string // Unit of Amount, empty for counted categories.
+	Match func(kind string) bool
+	// Amount extracts a numeric value from a matching kind. When nil, each tally counts as 1.
+	Amount func(kind string) (int, error)
+}
+
+// Metric applies an aggregate to the tallies of one category.
+type Metric struct {
+	Name      string
+	Category  Category
+	Aggregate Aggregate
+}
+
+// WindowKind selects how a window's time range is derived from the current time.
+type WindowKind int
+
+const (
+	// Rolling windows cover a trailing duration ending now.
+	Rolling WindowKind = iota
+	// Days windows cover whole days, counted back from today using the tot's day start.
+	Days
+)
+
+// Window is a time range that metrics are evaluated over.
+type Window struct {
+	Name     string
+	Kind     WindowKind
+	Duration time.Duration // Rolling: length of the window.
+	Offset   int           // Days: how many days before today the most recent day is.
+	Days     int           // Days: how many days the window covers.
+	// Average divides counts and sums by Days. The result is only valid once the tot has
+	// more than Days+1 days of history, so a partial first day never skews the average.
+	Average bool
+}
+
+// Spec is the declarative list of metrics and windows to compute. Every metric is evaluated
+// over every window.
+type Spec struct {
+	Metrics []Metric
+	Windows []Window
+}
+
+// Key identifies a single value in a Result.
+type Key struct {
+	Metric string
+	Window string
+}
+
+// Value is the outcome of one metric over one window.
+type Value struct {
+	Aggregate Aggregate
+	Number    int           // Count and Sum.
+	Duration  time.Duration // AvgGap.
+	At        *time.Time    // LastAt.
+	// Valid is false when there is not enough data, e.g. fewer than two events for a gap.
+	Valid bool
+}
+
+// String formats the value for display, using "---" when it is not valid.
+func (v Value) String() string {
+	if !v.Valid {
+		return "---"
+	}
+	switch v.Aggregate {
+	case AvgGap:
+		return FormatGap(v.Duration)
+	case LastAt:
+		return v.At.Format(time.RFC3339)
+	default:
+		return strconv.Itoa(v.Number)
+	}
+}
+
+// Result maps each metric and window pair to its computed value.
+type Result map[Key]Value
+
+// Get returns the value for a metric and window.
+func (r Result) Get(metric, window string) Value {
+	return r[Key{Metric: metric, Window: window}]
+}
+
+var (
+	// MilkCategory sums bottle volumes encoded in the kind, e.g. "🍼4".
+	MilkCategory = Category{
+		Name:  "milk",
+		Emoji: "🍼",
+		Label: "Bottle",
+		Unit:  "oz",
+		Match: func(kind string) bool { return strings.HasPrefix(kind, "🍼") },
+		Amount: func(kind string) (int, error) {
+			amountStr := strings.TrimPrefix(kind, "🍼")
+			amount, err := strconv.Atoi(amountStr)
+			if err != nil {
+				return 0, fmt.Errorf("stats: invalid milk amount %q: %w", amountStr, err)
+			}
+			return amount, nil
+		},
+	}
+	NurseCategory = Category{Name: "nurse", Emoji: "🤱", Label: "Nursing sessions", Match: kindIs(totConfig.TallyKindMap[16], totConfig.TallyKindMap[17])}
+	PeeCategory   = Category{Name: "pee", Emoji: "🚽", Label: "Wet diapers", Match: kindIs(totConfig.TallyKindMap[11], totConfig.TallyKindMap[13])}
+	PooCategory   = Category{Name: "poo", Emoji: "💩", Label: "Dirty diapers", Match: kindIs(totConfig.TallyKindMap[12], totConfig.TallyKindMap[13])}
+	SnackCategory = Category{Name: "snack", Emoji: "🍎", Label: "Snacks", Match: kindIs(totConfig.TallyKindMap[9])}
+	MealCategory  = Category{Name: "meal", Emoji: "🍲", Label: "Meals", Match: kindIs(totConfig.TallyKindMap[10])}
+	BathCategory  = Category{Name: "bath", Emoji: "🛁", Label: "Baths", Match: kindIs(totConfig.TallyKindMap[14])}
+	BrushCategory = Category{Name: "brush", Emoji: "🦷", Label: "Tooth brushing", Match: kindIs(totConfig.TallyKindMap[15])}
+
+	// Categories lists every tracked category in display order.
+	Categories = []Category{
+		MilkCategory, NurseCategory, PeeCategory, PooCategory,
+		SnackCategory, MealCategory, BathCategory, BrushCategory,
+	}
+)
+
+// Aggregate returns the natural daily aggregate of the category: sums for categories with
+// an amount, counts otherwise.
+func (c Category) Aggregate() Aggregate {
+	if c.Amount != nil {
+		return Sum
+	}
+	return Count
+}
+
+func kindIs(kinds ...string) func(string) bool {
+	return func(kind string) bool {
+		for _, k := range kinds {
+			if kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// accumulator tracks a single metric and window pair while scanning tallies.
+type accumulator struct {
+	count  int
+	sum    int
+	newest *time.Time
+	oldest *time.Time
+}
+
+func (a *accumulator) add(t *time.Time, amount int) {
+	a.count++
+	a.sum += amount
+	if a.newest == nil || t.After(*a.newest) {
+		a.newest = t
+	}
+	if a.oldest == nil || t.Before(*a.oldest) {
+		a.oldest = t
+	}
+}
+
+// windowRange is the resolved range of a window. Rolling windows cover the instants from start.
+// Days windows cover the days from first to last, matched by the day a tally fell on in the zone
+// in effect when it happened; a nil last is unbounded.
+type windowRange struct {
+	start time.Time
+	days  bool
+	first time.Time
+	last  *time.Time
+}
+
+func (r windowRange) contains(t, day time.Time) bool {
+	if r.days {
+		return !day.Before(r.first) && (r.last == nil || !day.After(*r.last))
+	}
+	return !t.Before(r.start)
+}
+
+// Compute evaluates every metric in the spec over every window for the tot's tallies.
+func (e *Engine) Compute(tot *totModels.Tot, tzLocation *time.Location, now time.Time, spec Spec) (Result, error) {
+	now = now.In(tzLocation)
+	todayStart := DayStart(now, tot.DayStartsAt)
+
+	ranges := make([]windowRange, len(spec.Windows))
+	for i, w := range spec.Windows {
+		switch w.Kind {
+		case Rolling:
+			ranges[i] = windowRange{start: now.Add(-w.Duration)}
+		case Days:
+			ranges[i] = windowRange{days: true, first: dayOf(dayStartBefore(todayStart, tot.DayStartsAt, w.Offset+w.Days-1), tot.DayStartsAt)}
+			if w.Offset > 0 {
+				last := dayOf(dayStartBefore(todayStart, tot.DayStartsAt, w.Offset), tot.DayStartsAt)
+				ranges[i].last = &last
+			}
+		}
+	}
+
+	zones := NewZones(tot, tzLocation)
+	var oldest *time.Time
+	accs := make([]accumulator, len(spec.Metrics)*len(spec.Windows))
+	for i := range tot.Tallies {
+		tally := &tot.Tallies[i]
+		if oldest == nil || tally.Time.Before(*oldest) {
+			oldest = tally.Time
+		}
+		day := dayOf(zones.In(*tally.Time), tot.DayStartsAt)
+
+		for m, metric := range spec.Metrics {
+			if !metric.Category.Match(tally.Kind) {
+				continue
+			}
+			amount := 1
+			if metric.Category.Amount != nil {
+				var err error
+				if amount, err = metric.Category.Amount(tally.Kind); err != nil {
+					return nil, err
+				}
+			}
+			for w := range spec.Windows {
+				if ranges[w].contains(*tally.Time, day) {
+					accs[m*len(spec.Windows)+w].add(tally.Time, amount)
+				}
+			}
+		}
+	}
+
+	res := make(Result, len(accs))
+	for m, metric := range spec.Metrics {
+		for w, window := range spec.Windows {
+			acc := accs[m*len(spec.Windows)+w]
+			val := Value{Aggregate: metric.Aggregate, Valid: true}
+
+			switch metric.Aggregate {
+			case Count:
+				val.Number = acc.count
+			case Sum:
+				val.Number = acc.sum
+			case AvgGap:
+				if acc.count < 2 {
+					val.Valid = false
+					break
+				}
+				avgSecs := int64(acc.newest.Sub(*acc.oldest).Seconds()) / int64(acc.count-1)
+				val.Duration = time.Duration(avgSecs) * time.Second
+			case LastAt:
+				val.At = acc.newest
+				val.Valid = acc.newest != nil
+			}
+
+			if window.Kind == Days && window.Average {
+				if metric.Aggregate == Count || metric.Aggregate == Sum {
+					val.Number /= window.Days
+				}
+				// Require history older than the averaged days plus a buffer day.
+				if oldest == nil || !oldest.Before(now.AddDate(0, 0, -(window.Days+1))) {
+					val.Valid = false
+				}
+			}
+
+			res[Key{Metric: metric.Name, Window: window.Name}] = val
+		}
+	}
+	return res, nil
+}
+
+// FormatGap renders a duration as hours and minutes, e.g. "3h 5m", or just "45m" under an hour.
+func FormatGap(d time.Duration) string {
+	hours := int(d.Hours())
+	mins := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", mins)
+	}
+	return fmt.Sprintf("%dh %dm", hours, mins)
+}
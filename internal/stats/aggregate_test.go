@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+func TestCompute_CustomSpec(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+
+	tAt := func(d, h int) *time.Time {
+		tm := now.AddDate(0, 0, -d).Add(time.Duration(-h) * time.Hour)
+		return &tm
+	}
+
+	tot := &totModels.Tot{
+		Tallies: []totModels.Tally{
+			{Kind: totConfig.TallyKindMap[9], Time: tAt(0, 1)},
+			{Kind: totConfig.TallyKindMap[9], Time: tAt(0, 3)},
+			{Kind: totConfig.TallyKindMap[14], Time: tAt(1, 0)},
+			{Kind: totConfig.TallyKindMap[9], Time: tAt(6, 0)},
+		},
+	}
+
+	spec := Spec{
+		Metrics: []Metric{
+			{Name: "snacks", Category: SnackCategory, Aggregate: Count},
+			{Name: "snackGap", Category: SnackCategory, Aggregate: AvgGap},
+			{Name: "lastBath", Category: BathCategory, Aggregate: LastAt},
+		},
+		Windows: []Window{
+			{Name: "last6Hours", Kind: Rolling, Duration: 6 * time.Hour},
+			{Name: "week", Kind: Days, Offset: 0, Days: 7},
+			{Name: "fiveDayAvg", Kind: Days, Offset: 1, Days: 5, Average: true},
+		},
+	}
+
+	res, err := e.Compute(tot, tz, now, spec)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	checks := []struct {
+		metric, window string
+		expected       string
+	}{
+		{"snacks", "last6Hours", "2"},
+		{"snacks", "week", "3"},
+		{"snackGap", "last6Hours", "2h 0m"},
+		{"snackGap", "week", "71h 30m"},
+		{"lastBath", "last6Hours", "---"},
+		{"lastBath", "week", tAt(1, 0).Format(time.RFC3339)},
+		// Only 6 days of history, the 5-day average needs more than 6.
+		{"snacks", "fiveDayAvg", "---"},
+		{"missing", "week", "---"},
+	}
+
+	for _, c := range checks {
+		if got := res.Get(c.metric, c.window).String(); got != c.expected {
+			t.Errorf("%s/%s: expected %s, got %s", c.metric, c.window, c.expected, got)
+		}
+	}
+}
+
+func TestCompute_Average(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+
+	tAt := func(d int) *time.Time {
+		tm := now.AddDate(0, 0, -d)
+		return &tm
+	}
+
+	tot := &totModels.Tot{
+		Tallies: []totModels.Tally{
+			{Kind: "🍼3", Time: tAt(1)},
+			{Kind: "🍼4", Time: tAt(2)},
+			{Kind: "🍼5", Time: tAt(3)},
+			{Kind: "🍼9", Time: tAt(5)},
+		},
+	}
+
+	spec := Spec{
+		Metrics: []Metric{{Name: "milk", Category: MilkCategory, Aggregate: Sum}},
+		Windows: []Window{{Name: "avg", Kind: Days, Offset: 1, Days: 3, Average: true}},
+	}
+
+	res, err := e.Compute(tot, tz, now, spec)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	v := res.Get("milk", "avg")
+	if !v.Valid || v.Number != 4 {
+		t.Errorf("expected valid average 4, got %+v", v)
+	}
+}
+
+func TestCompute_MalformedAmount(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Now()
+	old := now.AddDate(-1, 0, 0)
+
+	// Malformed amounts fail even when outside every window.
+	tot := &totModels.Tot{
+		Tallies: []totModels.Tally{{Kind: "🍼abc", Time: &old}},
+	}
+
+	spec := Spec{
+		Metrics: []Metric{{Name: "milk", Category: MilkCategory, Aggregate: Sum}},
+		Windows: []Window{{Name: "today", Kind: Days, Days: 1}},
+	}
+
+	if _, err := e.Compute(tot, time.UTC, now, spec); err == nil {
+		t.Error("expected error for malformed milk amount, got nil")
+	}
+}
+
+func TestFormatGap(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                            "0m",
+		45*time.Minute + time.Second: "45m",
+		time.Hour:                    "1h 0m",
+		26*time.Hour + 5*time.Minute: "26h 5m",
+	} {
+		if got := FormatGap(d); got != want {
+			t.Errorf("FormatGap(%v) = %q, expected %q", d, got, want)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	tests := []struct {
+		category Category
+		kind     string
+		expected bool
+	}{
+		{MilkCategory, "🍼4", true},
+		{MilkCategory, "🍎", false},
+		{NurseCategory, "🤱L", true},
+		{NurseCategory, "🤱R", true},
+		{PeeCategory, "🚽", true},
+		{PeeCategory, "🚽💩", true},
+		{PooCategory, "🚽💩", true},
+		{PooCategory, "🚽", false},
+		{BrushCategory, "🦷", true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.category.Match(tt.kind); got != tt.expected {
+			t.Errorf("%s.Match(%q): expected %v, got %v", tt.category.Name, tt.kind, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// journal.go summarizes a tot's journal over the days of a report: sleep is split across the days
+// it spans, and medications, growth measurements and notes are listed.
+package stats
+
+import (
+	"slices"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// SleepCategory describes sleep in reports. Sleep is logged in the journal rather than as tallies,
+// so it matches no tally kind; its values are minutes asleep.
+var SleepCategory = Category{Name: "sleep", Emoji: "😴", Label: "Sleep", Unit: "min", Match: func(string) bool { return false }}
+
+// JournalReport is a tot's journal over the days of a report.
+type JournalReport struct {
+	// Days holds the minutes asleep in Values[0], indexed like the report's days. Days that end
+	// before the first sleep logged have no data.
+	Days  []ReportDay
+	Sleep ReportCategory
+	// Entries are the report days' medications, growth measurements and notes, oldest first.
+	Entries []totModels.JournalEntry
+}
+
+// JournalReport covers the days of report. Its most recent day ends at now when it is partial.
+func (e *Engine) JournalReport(tot *totModels.Tot, report Report, now time.Time) JournalReport {
+	jr := JournalReport{Days: make([]ReportDay, len(report.Days))}
+	if len(report.Days) == 0 {
+		return jr
+	}
+
+	var sleeps []totModels.JournalEntry
+	for _, entry := range tot.Journal {
+		if entry.Kind == totConfig.JournalSleep && entry.End != nil {
+			sleeps = append(sleeps, entry)
+		}
+	}
+
+	first, latest := report.Days[len(report.Days)-1].Start, report.Days[0]
+	end := dayStartBefore(latest.Start, tot.DayStartsAt, -1)
+	if latest.Partial {
+		end = now
+	}
+	for i, day := range report.Days {
+		dayEnd := end
+		if i > 0 {
+			dayEnd = report.Days[i-1].Start
+		}
+		minutes := 0
+		for _, sleep := range sleeps {
+			from, to := maxTime(sleep.Time, day.Start), minTime(*sleep.End, dayEnd)
+			if to.After(from) {
+				minutes += int(to.Sub(from).Minutes())
+			}
+		}
+		// The journal is newest first, so the last sleep is the first logged.
+		hasData := len(sleeps) > 0 && dayEnd.After(sleeps[len(sleeps)-1].Time)
+		jr.Days[i] = ReportDay{Start: day.Start, Partial: day.Partial, HasData: hasData, Values: []int{minutes}}
+	}
+	jr.Sleep = summarizeDays(SleepCategory, jr.Days, 0)
+
+	for _, entry := range slices.Backward(tot.Journal) {
+		if entry.Kind != totConfig.JournalSleep && !entry.Time.Before(first) && entry.Time.Before(end) {
+			jr.Entries = append(jr.Entries, entry)
+		}
+	}
+	return jr
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
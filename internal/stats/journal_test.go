@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+func TestJournalReport(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+	at := func(day, hour int) time.Time { return time.Date(2023, 10, day, hour, 0, 0, 0, tz) }
+	until := func(day, hour int) *time.Time { t := at(day, hour); return &t }
+
+	// Newest first, as saved.
+	tot := &totModels.Tot{
+		Tallies: []totModels.Tally{{Kind: "🚽", Time: until(20, 8)}},
+		Journal: []totModels.JournalEntry{
+			{Kind: totConfig.JournalNote, Time: at(27, 9), Text: "After the range"},
+			{Kind: totConfig.JournalSleep, Time: at(25, 22), End: until(26, 6)},
+			{Kind: totConfig.JournalMedication, Time: at(25, 10), Name: "Vitamin D"},
+			{Kind: totConfig.JournalSleep, Time: at(23, 23), End: until(24, 1)},
+			{Kind: totConfig.JournalGrowth, Time: at(23, 9), WeightKg: 5},
+		},
+	}
+	report, err := e.RangeReport(tot, tz, at(22, 0), at(26, 0), now, []Category{PeeCategory})
+	if err != nil {
+		t.Fatalf("RangeReport failed: %v", err)
+	}
+
+	jr := e.JournalReport(tot, report, now)
+	want := []int{360, 120, 60, 60, 0} // 26th back to the 22nd.
+	for i, day := range jr.Days {
+		if day.Values[0] != want[i] {
+			t.Errorf("%s: expected %d minutes asleep, got %d", day.Start.Format(time.DateOnly), want[i], day.Values[0])
+		}
+	}
+	if !jr.Days[3].HasData || jr.Days[4].HasData {
+		t.Error("expected days from the first sleep logged to have data")
+	}
+	if s := jr.Sleep; !s.Valid || s.Avg != 150 || s.Min != 60 || !s.MinDay.Equal(at(24, 0)) || s.Max != 360 {
+		t.Errorf("unexpected sleep summary: %+v", s)
+	}
+	if len(jr.Entries) != 2 || jr.Entries[0].Kind != totConfig.JournalGrowth || jr.Entries[1].Name != "Vitamin D" {
+		t.Errorf("expected the growth and medication entries oldest first, got %+v", jr.Entries)
+	}
+
+	// Today's sleep so far counts up to now.
+	report, _ = e.Report(tot, tz, now, 2, []Category{PeeCategory})
+	tot.Journal[0] = totModels.JournalEntry{Kind: totConfig.JournalSleep, Time: at(27, 11), End: until(27, 13)}
+	if jr := e.JournalReport(tot, report, now); jr.Days[0].Values[0] != 60 || jr.Days[1].Values[0] != 360 {
+		t.Errorf("expected an hour today and six yesterday, got %v %v", jr.Days[0].Values, jr.Days[1].Values)
+	}
+}
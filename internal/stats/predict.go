@@ -0,0 +1,118 @@
+// predict.go estimates when the next feed or diaper is due from recent intervals.
+package stats
+
+import (
+	"math"
+	"slices"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+const (
+	// predictLookback is how far back gaps are collected from.
+	predictLookback = 72 * time.Hour
+	// predictHalfLife halves the weight of a gap for every period of age.
+	predictHalfLife = 12 * time.Hour
+	// predictMinGaps is the fewest gaps a prediction is made from.
+	predictMinGaps = 3
+	// predictMinGap ignores near-simultaneous events, e.g. the two tallies of a "Both" diaper.
+	predictMinGap = time.Minute
+	// unusualStdDevs is how many standard deviations above the mean gap counts as unusual.
+	unusualStdDevs = 2
+)
+
+var (
+	// FeedCategory matches any bottle or nursing tally.
+	FeedCategory = Category{
+		Name: "feed", Emoji: "🍼", Label: "Feeds",
+		Match: func(kind string) bool { return MilkCategory.Match(kind) || NurseCategory.Match(kind) },
+	}
+	// DiaperCategory matches any pee or poo tally.
+	DiaperCategory = Category{
+		Name: "diaper", Emoji: "🚽", Label: "Diapers",
+		Match: func(kind string) bool { return PeeCategory.Match(kind) || PooCategory.Match(kind) },
+	}
+)
+
+// Prediction is the estimated next occurrence of a category.
+type Prediction struct {
+	// Valid is false when there are too few recent events to predict from.
+	Valid       bool
+	Last        time.Time
+	Next        time.Time
+	ExpectedGap time.Duration
+	CurrentGap  time.Duration
+	// Unusual is set when the current gap is well beyond the recent distribution of gaps.
+	Unusual bool
+}
+
+// Predict estimates the next occurrence of a category from the gaps between its recent events.
+// Gaps are weighted toward recent ones and toward those that started at a similar time of day
+// to the last event, since feeds and diapers follow a daily rhythm.
+func (e *Engine) Predict(tot *totModels.Tot, tzLocation *time.Location, now time.Time, category Category) Prediction {
+	now = now.In(tzLocation)
+	var times []time.Time
+	for i := range tot.Tallies {
+		t := tot.Tallies[i].Time
+		if category.Match(tot.Tallies[i].Kind) && !t.Before(now.Add(-predictLookback)) && !t.After(now) {
+			times = append(times, t.In(tzLocation))
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	type gap struct {
+		start time.Time
+		end   time.Time
+	}
+	var gaps []gap
+	for i := 1; i < len(times); i++ {
+		if times[i].Sub(times[i-1]) >= predictMinGap {
+			gaps = append(gaps, gap{start: times[i-1], end: times[i]})
+		}
+	}
+	if len(gaps) < predictMinGaps {
+		return Prediction{}
+	}
+
+	last := times[len(times)-1]
+	var weighted, totalWeight, mean float64
+	for _, g := range gaps {
+		length := g.end.Sub(g.start).Seconds()
+		age := now.Sub(g.end)
+		recency := math.Pow(0.5, float64(age)/float64(predictHalfLife))
+		weight := recency * timeOfDayWeight(g.start, last)
+		weighted += weight * length
+		totalWeight += weight
+		mean += length
+	}
+	mean /= float64(len(gaps))
+
+	var variance float64
+	for _, g := range gaps {
+		d := g.end.Sub(g.start).Seconds() - mean
+		variance += d * d
+	}
+	stdDev := math.Sqrt(variance / float64(len(gaps)))
+
+	expected := time.Duration(weighted/totalWeight) * time.Second
+	current := now.Sub(last)
+	return Prediction{
+		Valid:       true,
+		Last:        last,
+		Next:        last.Add(expected),
+		ExpectedGap: expected,
+		CurrentGap:  current,
+		Unusual:     current > expected && current.Seconds() > mean+unusualStdDevs*stdDev,
+	}
+}
+
+// timeOfDayWeight scores how close two times are on the 24 hour clock, from 0.25 for
+// opposite sides of the day to 1.25 for the same time of day.
+func timeOfDayWeight(a, b time.Time) float64 {
+	hourOf := func(t time.Time) float64 { return float64(t.Hour()) + float64(t.Minute())/60 }
+	diff := math.Abs(hourOf(a) - hourOf(b))
+	if diff > 12 {
+		diff = 24 - diff
+	}
+	return 0.25 + (1+math.Cos(math.Pi*diff/12))/2
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// everyNHours builds count tallies of kind spaced by gap, the newest at last.
+func everyNHours(kind string, last time.Time, gap time.Duration, count int) []totModels.Tally {
+	tallies := make([]totModels.Tally, count)
+	for i := range count {
+		t := last.Add(-time.Duration(i) * gap)
+		tallies[i] = totModels.Tally{Kind: kind, Time: &t}
+	}
+	return tallies
+}
+
+func TestPredict_RegularGaps(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	last := now.Add(-1 * time.Hour)
+
+	tot := &totModels.Tot{Tallies: everyNHours("🍼3", last, 3*time.Hour, 8)}
+
+	p := e.Predict(tot, time.UTC, now, FeedCategory)
+	if !p.Valid {
+		t.Fatal("expected valid prediction")
+	}
+	if p.ExpectedGap != 3*time.Hour || !p.Next.Equal(last.Add(3*time.Hour)) {
+		t.Errorf("expected next feed 3h after last, got gap %v next %v", p.ExpectedGap, p.Next)
+	}
+	if p.CurrentGap != time.Hour || p.Unusual {
+		t.Errorf("expected 1h current gap that is not unusual, got %v unusual=%v", p.CurrentGap, p.Unusual)
+	}
+}
+
+func TestPredict_WeightsRecentGaps(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	last := now.Add(-30 * time.Minute)
+
+	// Older feeds every 2h, then the last few every 4h.
+	tallies := everyNHours("🤱L", last, 4*time.Hour, 4)
+	tallies = append(tallies, everyNHours("🤱R", last.Add(-14*time.Hour), 2*time.Hour, 10)...)
+	tot := &totModels.Tot{Tallies: tallies}
+
+	p := e.Predict(tot, time.UTC, now, FeedCategory)
+	if !p.Valid {
+		t.Fatal("expected valid prediction")
+	}
+	// The unweighted mean of 3 4h gaps and 10 2h gaps is ~2h28m; recent gaps pull it toward 4h.
+	if p.ExpectedGap <= 2*time.Hour+45*time.Minute || p.ExpectedGap >= 4*time.Hour {
+		t.Errorf("expected gap weighted toward recent 4h gaps, got %v", p.ExpectedGap)
+	}
+}
+
+func TestPredict_Unusual(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	last := now.Add(-8 * time.Hour)
+
+	tallies := everyNHours(totConfig.TallyKindMap[11], last, 3*time.Hour, 5)
+	// A "Both" diaper records two tallies at once; the zero gap must be ignored.
+	both := last.Add(-10 * time.Minute)
+	tallies = append(tallies, totModels.Tally{Kind: totConfig.TallyKindMap[12], Time: &both})
+	// Slight variation so the standard deviation is non-zero.
+	jitter := last.Add(-15*time.Hour - 20*time.Minute)
+	tallies = append(tallies, totModels.Tally{Kind: totConfig.TallyKindMap[12], Time: &jitter})
+	tot := &totModels.Tot{Tallies: tallies}
+
+	p := e.Predict(tot, time.UTC, now, DiaperCategory)
+	if !p.Valid {
+		t.Fatal("expected valid prediction")
+	}
+	if !p.Unusual {
+		t.Errorf("expected 8h gap to be unusual against ~3h gaps, got %+v", p)
+	}
+}
+
+func TestPredict_NotEnoughHistory(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string][]totModels.Tally{
+		"Empty":      nil,
+		"Few gaps":   everyNHours("🍼3", now, 3*time.Hour, 3),
+		"Too old":    everyNHours("🍼3", now.Add(-80*time.Hour), 3*time.Hour, 8),
+		"Other kind": everyNHours("🛁", now, 3*time.Hour, 8),
+	}
+
+	for name, tallies := range tests {
+		if p := e.Predict(&totModels.Tot{Tallies: tallies}, time.UTC, now, FeedCategory); p.Valid {
+			t.Errorf("%s: expected no prediction, got %+v", name, p)
+		}
+	}
+}
+
+func TestTimeOfDayWeight(t *testing.T) {
+	base := time.Date(2023, 10, 27, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		other    time.Time
+		expected float64
+	}{
+		{base, 1.25},
+		{base.Add(12 * time.Hour), 0.25},
+		{base.Add(-6 * time.Hour), 0.75}, // Wraps around midnight.
+	}
+
+	for _, tt := range tests {
+		if got := timeOfDayWeight(base, tt.other); math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("for %v expected %v, got %v", tt.other, tt.expected, got)
+		}
+	}
+}
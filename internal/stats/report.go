@@ -0,0 +1,232 @@
+// report.go builds multi-day trend reports from the aggregation engine.
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+// Report holds per-day totals for every category over a range of days.
+type Report struct {
+	Days       []ReportDay // Most recent first; Days[0] is today.
+	Categories []ReportCategory
+}
+
+// ReportDay is a single day of a report.
+type ReportDay struct {
+	Start   time.Time
+	Partial bool  // Today, which is still in progress.
+	HasData bool  // False for days before the oldest saved tally.
+	Values  []int // Indexed like Report.Categories.
+}
+
+// ReportCategory summarizes one category across the complete days of a report.
+type ReportCategory struct {
+	Category Category
+	// Valid is false when no complete day has data, leaving the summary fields empty.
+	Valid          bool
+	Avg, Min, Max  int
+	MinDay, MaxDay time.Time
+	// Change is the week-over-week percentage change of the last seven complete days.
+	// It is nil when the previous week is not fully covered by history or was zero.
+	Change *int
+}
+
+// Report computes daily totals for the given categories over the last n days, including today.
+func (e *Engine) Report(tot *totModels.Tot, tzLocation *time.Location, now time.Time, days int, categories []Category) (Report, error) {
+	return e.dailyReport(tot, tzLocation, now, days, true, categories)
+}
+
+// RangeReport computes daily totals for the days containing from through the day containing to,
+// which must not be after now. Only today, if the range includes it, is partial.
+func (e *Engine) RangeReport(tot *totModels.Tot, tzLocation *time.Location, from, to, now time.Time, categories []Category) (Report, error) {
+	first := DayStart(from.In(tzLocation), tot.DayStartsAt)
+	last := DayStart(to.In(tzLocation), tot.DayStartsAt)
+	today := DayStart(now.In(tzLocation), tot.DayStartsAt)
+	if last.After(today) || first.After(last) {
+		return Report{}, fmt.Errorf("stats: invalid report range %s to %s", first.Format(time.DateOnly), last.Format(time.DateOnly))
+	}
+
+	// Count calendar days, which stays exact across DST changes.
+	date := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC) }
+	days := int(date(last).Sub(date(first)).Hours()/24) + 1
+
+	if last.Equal(today) {
+		return e.dailyReport(tot, tzLocation, now, days, true, categories)
+	}
+
+	// The most recent day of a report is open-ended, so drop tallies after the range.
+	end := dayStartBefore(last, tot.DayStartsAt, -1)
+	bounded := *tot
+	if i := slices.IndexFunc(tot.Tallies, func(t totModels.Tally) bool { return t.Time.Before(end) }); i >= 0 {
+		bounded.Tallies = tot.Tallies[i:]
+	} else {
+		bounded.Tallies = nil
+	}
+	return e.dailyReport(&bounded, tzLocation, end.Add(-time.Nanosecond), days, false, categories)
+}
+
+// dailyReport computes n days of totals ending with the day containing now. The last day is
+// partial when it is still in progress.
+func (e *Engine) dailyReport(tot *totModels.Tot, tzLocation *time.Location, now time.Time, days int, partial bool, categories []Category) (Report, error) {
+	spec := Spec{Windows: make([]Window, 0, days+2)}
+	for _, c := range categories {
+		spec.Metrics = append(spec.Metrics, Metric{Name: c.Name, Category: c, Aggregate: c.Aggregate()})
+	}
+	for i := range days {
+		spec.Windows = append(spec.Windows, Window{Name: dayWindowName(i), Kind: Days, Offset: i, Days: 1})
+	}
+	spec.Windows = append(spec.Windows,
+		Window{Name: "thisWeek", Kind: Days, Offset: 1, Days: 7},
+		Window{Name: "lastWeek", Kind: Days, Offset: 8, Days: 7},
+	)
+
+	res, err := e.Compute(tot, tzLocation, now, spec)
+	if err != nil {
+		return Report{}, err
+	}
+
+	now = now.In(tzLocation)
+	todayStart := DayStart(now, tot.DayStartsAt)
+	var historyStart *time.Time
+	if len(tot.Tallies) > 0 {
+		oldest := *tot.Tallies[0].Time
+		for i := range tot.Tallies {
+			if tot.Tallies[i].Time.Before(oldest) {
+				oldest = *tot.Tallies[i].Time
+			}
+		}
+		start := dayOf(NewZones(tot, tzLocation).In(oldest), tot.DayStartsAt)
+		historyStart = &start
+	}
+	covered := func(start time.Time) bool {
+		return historyStart != nil && !dayOf(start, tot.DayStartsAt).Before(*historyStart)
+	}
+
+	report := Report{Days: make([]ReportDay, days), Categories: make([]ReportCategory, len(categories))}
+	for i := range days {
+		start := dayStartBefore(todayStart, tot.DayStartsAt, i)
+		day := ReportDay{Start: start, Partial: i == 0 && partial, HasData: covered(start), Values: make([]int, len(categories))}
+		for c, category := range categories {
+			day.Values[c] = res.Get(category.Name, dayWindowName(i)).Number
+		}
+		report.Days[i] = day
+	}
+
+	lastWeekCovered := covered(dayStartBefore(todayStart, tot.DayStartsAt, 14))
+	for c, category := range categories {
+		rc := summarizeDays(category, report.Days, c)
+		thisWeek := res.Get(category.Name, "thisWeek").Number
+		lastWeek := res.Get(category.Name, "lastWeek").Number
+		if lastWeekCovered && lastWeek > 0 {
+			change := (thisWeek - lastWeek) * 100 / lastWeek
+			rc.Change = &change
+		}
+		report.Categories[c] = rc
+	}
+	return report, nil
+}
+
+// summarizeDays finds the average, minimum and maximum of value c across the complete days with data.
+func summarizeDays(category Category, days []ReportDay, c int) ReportCategory {
+	rc := ReportCategory{Category: category}
+	sum, n := 0, 0
+	for _, day := range days {
+		if day.Partial || !day.HasData {
+			continue
+		}
+		v := day.Values[c]
+		if n == 0 || v < rc.Min {
+			rc.Min, rc.MinDay = v, day.Start
+		}
+		if n == 0 || v > rc.Max {
+			rc.Max, rc.MaxDay = v, day.Start
+		}
+		sum += v
+		n++
+	}
+	if n > 0 {
+		rc.Valid = true
+		rc.Avg = sum / n
+	}
+	return rc
+}
+
+func dayWindowName(offset int) string {
+	return "day" + strconv.Itoa(offset)
+}
+
+// TimelineEvent is a tally matched to the category it belongs to.
+type TimelineEvent struct {
+	At       time.Time
+	Kind     string
+	Category Category
+}
+
+// Timeline returns the tallies in [since, until] matched to the first category they belong to,
+// oldest first. Tallies that match none of the categories are skipped.
+func (e *Engine) Timeline(tot *totModels.Tot, since, until time.Time, categories []Category) []TimelineEvent {
+	var events []TimelineEvent
+	for i := len(tot.Tallies) - 1; i >= 0; i-- {
+		tally := &tot.Tallies[i]
+		if tally.Time.Before(since) || tally.Time.After(until) {
+			continue
+		}
+		for _, c := range categories {
+			if c.Match(tally.Kind) {
+				events = append(events, TimelineEvent{At: *tally.Time, Kind: tally.Kind, Category: c})
+				break
+			}
+		}
+	}
+	return events
+}
+
+// RunningTotal is a tally with its day's totals up to and including it.
+type RunningTotal struct {
+	Tally    totModels.Tally
+	DayStart time.Time
+	Values   []int // Indexed like the categories passed to RunningTotals.
+}
+
+// RunningTotals returns the tallies at or after since, oldest first, each with the running totals
+// of its day in the zone in effect at the time. Tallies earlier on since's day still count toward the totals.
+func (e *Engine) RunningTotals(tot *totModels.Tot, tzLocation *time.Location, since time.Time, categories []Category) ([]RunningTotal, error) {
+	zones := NewZones(tot, tzLocation)
+	from := DayStart(zones.In(since), tot.DayStartsAt)
+	var totals []RunningTotal
+	var day time.Time
+	values := make([]int, len(categories))
+
+	for i := len(tot.Tallies) - 1; i >= 0; i-- {
+		tally := tot.Tallies[i]
+		if tally.Time == nil || tally.Time.Before(from) {
+			continue
+		}
+		// Compare dates, since the same day can start at different instants on either side of a trip.
+		if start := DayStart(zones.In(*tally.Time), tot.DayStartsAt); day.IsZero() || !dayOf(start, tot.DayStartsAt).Equal(dayOf(day, tot.DayStartsAt)) {
+			day = start
+			values = make([]int, len(categories))
+		}
+		for c, category := range categories {
+			if !category.Match(tally.Kind) {
+				continue
+			}
+			amount := 1
+			if category.Amount != nil {
+				var err error
+				if amount, err = category.Amount(tally.Kind); err != nil {
+					return nil, err
+				}
+			}
+			values[c] += amount
+		}
+		if !tally.Time.Before(since) {
+			totals = append(totals, RunningTotal{Tally: tally, DayStart: day, Values: slices.Clone(values)})
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,196 @@
+package stats
+
+import (
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+func TestReport(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+
+	tAt := func(d int) *time.Time {
+		tm := now.AddDate(0, 0, -d)
+		return &tm
+	}
+
+	tallies := []totModels.Tally{{Kind: "🍼1", Time: tAt(0)}}
+	// Previous week (days 8-14): 2 oz per day. This week (days 1-7): 3 oz per day.
+	for d := 1; d <= 14; d++ {
+		amount := "🍼3"
+		if d > 7 {
+			amount = "🍼2"
+		}
+		tallies = append(tallies, totModels.Tally{Kind: amount, Time: tAt(d)})
+	}
+	tallies = append(tallies, totModels.Tally{Kind: totConfig.TallyKindMap[11], Time: tAt(2)})
+	tot := &totModels.Tot{Tallies: tallies}
+
+	r, err := e.Report(tot, tz, now, 7, []Category{MilkCategory, PeeCategory})
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	if len(r.Days) != 7 {
+		t.Fatalf("expected 7 days, got %d", len(r.Days))
+	}
+	if !r.Days[0].Partial || r.Days[1].Partial {
+		t.Error("expected only today to be partial")
+	}
+	if r.Days[0].Values[0] != 1 || r.Days[1].Values[0] != 3 {
+		t.Errorf("unexpected milk values: %v, %v", r.Days[0].Values, r.Days[1].Values)
+	}
+
+	milk := r.Categories[0]
+	if !milk.Valid || milk.Avg != 3 || milk.Min != 3 || milk.Max != 3 {
+		t.Errorf("unexpected milk summary: %+v", milk)
+	}
+	if milk.Change == nil || *milk.Change != 50 {
+		t.Errorf("expected +50%% week over week, got %v", milk.Change)
+	}
+
+	pee := r.Categories[1]
+	if pee.Max != 1 || !pee.MaxDay.Equal(time.Date(2023, 10, 25, 0, 0, 0, 0, tz)) || pee.Min != 0 {
+		t.Errorf("unexpected pee summary: %+v", pee)
+	}
+	if pee.Change != nil {
+		t.Errorf("expected no change for empty previous week, got %d", *pee.Change)
+	}
+}
+
+func TestReport_LimitedHistory(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+	t2 := now.AddDate(0, 0, -2)
+
+	tot := &totModels.Tot{Tallies: []totModels.Tally{{Kind: "🍼4", Time: &t2}}}
+
+	r, err := e.Report(tot, tz, now, 30, []Category{MilkCategory})
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	if !r.Days[2].HasData || r.Days[3].HasData {
+		t.Error("expected history to start two days ago")
+	}
+	// Only days 1 and 2 are complete days with data.
+	if milk := r.Categories[0]; milk.Avg != 2 || milk.Min != 0 || milk.Max != 4 || milk.Change != nil {
+		t.Errorf("unexpected milk summary: %+v", milk)
+	}
+
+	empty, err := e.Report(&totModels.Tot{}, tz, now, 7, []Category{MilkCategory})
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if empty.Categories[0].Valid || empty.Days[1].HasData {
+		t.Error("expected no data for a tot without tallies")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	t1 := now.Add(-1 * time.Hour)
+	t2 := now.Add(-2 * time.Hour)
+	t3 := now.Add(-30 * time.Hour)
+
+	tot := &totModels.Tot{
+		Tallies: []totModels.Tally{
+			{Kind: totConfig.TallyKindMap[11], Time: &t1},
+			{Kind: "🍼4", Time: &t2},
+			{Kind: totConfig.TallyKindMap[12], Time: &t3},
+		},
+	}
+
+	events := e.Timeline(tot, now.Add(-24*time.Hour), now, []Category{MilkCategory, PeeCategory})
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Category.Name != "milk" || events[1].Category.Name != "pee" {
+		t.Errorf("expected oldest first with categories, got %+v", events)
+	}
+}
+
+func TestRunningTotals(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	at := func(day, hour int) *time.Time {
+		tm := time.Date(2023, 10, day, hour, 0, 0, 0, time.UTC)
+		return &tm
+	}
+	// Newest first, as stored. Days start at 6 AM.
+	tot := &totModels.Tot{DayStartsAt: 6, Tallies: []totModels.Tally{
+		{Kind: "🍼4", Time: at(27, 9)},
+		{Kind: "🚽", Time: at(27, 8)},
+		{Kind: "🍼2", Time: at(27, 5)}, // Still the 26th's day.
+		{Kind: "🍼3", Time: at(26, 7)},
+		{Kind: "🍼1", Time: at(25, 7)},
+	}}
+
+	totals, err := e.RunningTotals(tot, time.UTC, *at(27, 0), []Category{MilkCategory, PeeCategory})
+	if err != nil {
+		t.Fatalf("RunningTotals failed: %v", err)
+	}
+	want := []struct {
+		kind   string
+		values [2]int
+	}{{"🍼2", [2]int{5, 0}}, {"🚽", [2]int{0, 1}}, {"🍼4", [2]int{4, 1}}}
+	if len(totals) != len(want) {
+		t.Fatalf("expected %d totals, got %+v", len(want), totals)
+	}
+	for i, w := range want {
+		if totals[i].Tally.Kind != w.kind || totals[i].Values[0] != w.values[0] || totals[i].Values[1] != w.values[1] {
+			t.Errorf("total %d: expected %s %v, got %s %v", i, w.kind, w.values, totals[i].Tally.Kind, totals[i].Values)
+		}
+	}
+	if !totals[0].DayStart.Equal(*at(26, 6)) || !totals[2].DayStart.Equal(*at(27, 6)) {
+		t.Errorf("unexpected day starts: %v %v", totals[0].DayStart, totals[2].DayStart)
+	}
+}
+
+func TestRangeReport(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	chicago, _ := time.LoadLocation("America/Chicago")
+	now := time.Date(2023, 11, 10, 12, 0, 0, 0, chicago)
+	at := func(month time.Month, day int) *time.Time {
+		tm := time.Date(2023, month, day, 10, 0, 0, 0, chicago)
+		return &tm
+	}
+	tot := &totModels.Tot{Tallies: []totModels.Tally{
+		{Kind: "🍼2", Time: at(11, 10)},
+		{Kind: "🍼4", Time: at(11, 6)},
+		{Kind: "🍼3", Time: at(11, 4)},
+		{Kind: "🍼1", Time: at(11, 1)},
+	}}
+
+	// The range spans the end of DST on Nov 5 and ends before today.
+	report, err := e.RangeReport(tot, chicago, *at(11, 3), *at(11, 6), now, []Category{MilkCategory})
+	if err != nil {
+		t.Fatalf("RangeReport failed: %v", err)
+	}
+	if len(report.Days) != 4 || report.Days[0].Partial {
+		t.Fatalf("expected 4 complete days, got %+v", report.Days)
+	}
+	values := []int{report.Days[0].Values[0], report.Days[1].Values[0], report.Days[2].Values[0], report.Days[3].Values[0]}
+	if values[0] != 4 || values[1] != 0 || values[2] != 3 || values[3] != 0 || report.Days[3].Start.Day() != 3 {
+		t.Errorf("unexpected days: %v starting %v", values, report.Days[3].Start)
+	}
+	if rc := report.Categories[0]; !rc.Valid || rc.Max != 4 || rc.Min != 0 {
+		t.Errorf("expected the last day to count toward the summary, got %+v", rc)
+	}
+
+	report, _ = e.RangeReport(tot, chicago, *at(11, 9), now, now, []Category{MilkCategory})
+	if len(report.Days) != 2 || !report.Days[0].Partial || report.Days[0].Values[0] != 2 {
+		t.Errorf("expected today to be partial, got %+v", report.Days)
+	}
+
+	if _, err := e.RangeReport(tot, chicago, *at(11, 9), *at(11, 11), now, nil); err == nil {
+		t.Error("expected error for a range ending in the future, got nil")
+	}
+	if _, err := e.RangeReport(tot, chicago, *at(11, 6), *at(11, 3), now, nil); err == nil {
+		t.Error("expected error for a reversed range, got nil")
+	}
+}
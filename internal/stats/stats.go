@@ -2,8 +2,6 @@
 package stats
 
 import (
-	"fmt"
-	"strconv"
 	"strings"
 	"time"
 	totConfig "tot-tally/internal/config"
@@ -20,215 +18,75 @@ func NewEngine(cfg *totConfig.Config) *Engine {
 	return &Engine{config: cfg}
 }
 
+// dashboardSpec describes the values shown in the dashboard stats grid.
+var dashboardSpec = Spec{
+	Metrics: []Metric{
+		{Name: "milk", Category: MilkCategory, Aggregate: Sum},
+		{Name: "nurse", Category: NurseCategory, Aggregate: Count},
+		{Name: "pee", Category: PeeCategory, Aggregate: Count},
+		{Name: "poo", Category: PooCategory, Aggregate: Count},
+		{Name: "milkGap", Category: MilkCategory, Aggregate: AvgGap},
+		{Name: "nurseGap", Category: NurseCategory, Aggregate: AvgGap},
+		{Name: "peeGap", Category: PeeCategory, Aggregate: AvgGap},
+		{Name: "pooGap", Category: PooCategory, Aggregate: AvgGap},
+	},
+	Windows: []Window{
+		{Name: "last12Hours", Kind: Rolling, Duration: 12 * time.Hour},
+		{Name: "last24Hours", Kind: Rolling, Duration: 24 * time.Hour},
+		{Name: "today", Kind: Days, Offset: 0, Days: 1},
+		{Name: "yesterday", Kind: Days, Offset: 1, Days: 1},
+		{Name: "twoDaysAgo", Kind: Days, Offset: 2, Days: 1},
+		{Name: "threeDaysAgo", Kind: Days, Offset: 3, Days: 1},
+		{Name: "threeDayAvg", Kind: Days, Offset: 1, Days: 3, Average: true},
+	},
+}
+
 // GenerateStats performs the calculation of trends and daily totals.
 func (e *Engine) GenerateStats(tot *totModels.Tot, tzLocation *time.Location, now time.Time) (totModels.GeneratedStats, error) {
-	now = now.In(tzLocation)
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, tzLocation)
-	yesterdayStart := todayStart.AddDate(0, 0, -1)
-	twoDaysAgoStart := todayStart.AddDate(0, 0, -2)
-	threeDaysAgoStart := todayStart.AddDate(0, 0, -3)
-	twelveHoursAgo := now.Add(-12 * time.Hour)
-	twentyFourHoursAgo := now.Add(-24 * time.Hour)
-
-	// Determine if we have enough history (oldest tally >= 4 days old)
-	hasEnoughHistory := false
-	if len(tot.Tallies) > 0 {
-		oldestTally := tot.Tallies[len(tot.Tallies)-1]
-		fourDaysAgo := now.AddDate(0, 0, -4)
-		if oldestTally.Time.Before(fourDaysAgo) {
-			hasEnoughHistory = true
-		}
-	}
-
-	var (
-		todayMilk, todayNurse, todayPee, todayPoo                             int
-		yesterdayMilk, yesterdayNurse, yesterdayPee, yesterdayPoo             int
-		twoDaysAgoMilk, twoDaysAgoNurse, twoDaysAgoPee, twoDaysAgoPoo         int
-		threeDaysAgoMilk, threeDaysAgoNurse, threeDaysAgoPee, threeDaysAgoPoo int
-		last12Milk, last12Nurse, last12Pee, last12Poo                         int
-		last24Milk, last24Nurse, last24Pee, last24Poo                         int
-		threeDaySumMilk, threeDaySumNurse, threeDaySumPee, threeDaySumPoo     int
-	)
-
-	milkTimesGap := make([]*time.Time, 0, e.config.MaxTallies)
-	nurseTimesGap := make([]*time.Time, 0, e.config.MaxTallies)
-	peeTimesGap := make([]*time.Time, 0, e.config.MaxTallies)
-	pooTimesGap := make([]*time.Time, 0, e.config.MaxTallies)
-
-	for i := range tot.Tallies {
-		tally := &tot.Tallies[i]
-		tLocal := tally.Time.In(tzLocation)
-
-		isLast12 := !tLocal.Before(twelveHoursAgo)
-		isLast24 := !tLocal.Before(twentyFourHoursAgo)
-		isToday := !tLocal.Before(todayStart)
-		isYesterday := tLocal.Before(todayStart) && !tLocal.Before(yesterdayStart)
-		isTwoDaysAgo := tLocal.Before(yesterdayStart) && !tLocal.Before(twoDaysAgoStart)
-		isThreeDaysAgo := tLocal.Before(twoDaysAgoStart) && !tLocal.Before(threeDaysAgoStart)
-		isThreeDayRange := tLocal.Before(todayStart) && !tLocal.Before(threeDaysAgoStart)
-
-		milkAmount := 0
-		isMilk := false
-		if amountStr, found := strings.CutPrefix(tally.Kind, "🍼"); found {
-			isMilk = true
-			var err error
-			milkAmount, err = strconv.Atoi(amountStr)
-			if err != nil {
-				return totModels.GeneratedStats{}, fmt.Errorf("stats: invalid milk amount %q: %w", amountStr, err)
-			}
-			if isThreeDayRange {
-				milkTimesGap = append(milkTimesGap, tally.Time)
-			}
-		}
-
-		isNurse := tally.Kind == "🤱L" || tally.Kind == "🤱R"
-		if isNurse && isThreeDayRange {
-			nurseTimesGap = append(nurseTimesGap, tally.Time)
-		}
-		isPee := tally.Kind == totConfig.TallyKindMap[11] || tally.Kind == totConfig.TallyKindMap[13]
-		if isPee && isThreeDayRange {
-			peeTimesGap = append(peeTimesGap, tally.Time)
-		}
-		isPoo := tally.Kind == totConfig.TallyKindMap[12] || tally.Kind == totConfig.TallyKindMap[13]
-		if isPoo && isThreeDayRange {
-			pooTimesGap = append(pooTimesGap, tally.Time)
-		}
-
-		if isLast12 {
-			if isMilk {
-				last12Milk += milkAmount
-			}
-			if isNurse {
-				last12Nurse++
-			}
-			if isPee {
-				last12Pee++
-			}
-			if isPoo {
-				last12Poo++
-			}
-		}
-		if isLast24 {
-			if isMilk {
-				last24Milk += milkAmount
-			}
-			if isNurse {
-				last24Nurse++
-			}
-			if isPee {
-				last24Pee++
-			}
-			if isPoo {
-				last24Poo++
-			}
-		}
-		if isToday {
-			if isMilk {
-				todayMilk += milkAmount
-			}
-			if isNurse {
-				todayNurse++
-			}
-			if isPee {
-				todayPee++
-			}
-			if isPoo {
-				todayPoo++
-			}
-		}
-		if isYesterday {
-			if isMilk {
-				yesterdayMilk += milkAmount
-			}
-			if isNurse {
-				yesterdayNurse++
-			}
-			if isPee {
-				yesterdayPee++
-			}
-			if isPoo {
-				yesterdayPoo++
-			}
-		}
-		if isTwoDaysAgo {
-			if isMilk {
-				twoDaysAgoMilk += milkAmount
-			}
-			if isNurse {
-				twoDaysAgoNurse++
-			}
-			if isPee {
-				twoDaysAgoPee++
-			}
-			if isPoo {
-				twoDaysAgoPoo++
-			}
-		}
-		if isThreeDaysAgo {
-			if isMilk {
-				threeDaysAgoMilk += milkAmount
-			}
-			if isNurse {
-				threeDaysAgoNurse++
-			}
-			if isPee {
-				threeDaysAgoPee++
-			}
-			if isPoo {
-				threeDaysAgoPoo++
-			}
-		}
-		if isThreeDayRange {
-			if isMilk {
-				threeDaySumMilk += milkAmount
-			}
-			if isNurse {
-				threeDaySumNurse++
-			}
-			if isPee {
-				threeDaySumPee++
-			}
-			if isPoo {
-				threeDaySumPoo++
-			}
-		}
+	res, err := e.Compute(tot, tzLocation, now, dashboardSpec)
+	if err != nil {
+		return totModels.GeneratedStats{}, err
 	}
 
-	formatAvg := func(sum int, days int) string {
-		return strconv.Itoa(sum / days)
+	get := func(metric, window string) string {
+		return res.Get(metric, window).String()
 	}
 
-	res := totModels.GeneratedStats{
-		Last12HoursMilk: strconv.Itoa(last12Milk), Last12HoursNurse: strconv.Itoa(last12Nurse),
-		Last12HoursPee: strconv.Itoa(last12Pee), Last12HoursPoo: strconv.Itoa(last12Poo),
-		Last24HoursMilk: strconv.Itoa(last24Milk), Last24HoursNurse: strconv.Itoa(last24Nurse),
-		Last24HoursPee: strconv.Itoa(last24Pee), Last24HoursPoo: strconv.Itoa(last24Poo),
-		TodayMilk: strconv.Itoa(todayMilk), TodayNurse: strconv.Itoa(todayNurse),
-		TodayPee: strconv.Itoa(todayPee), TodayPoo: strconv.Itoa(todayPoo),
-		YesterdayMilk: strconv.Itoa(yesterdayMilk), YesterdayNurse: strconv.Itoa(yesterdayNurse),
-		YesterdayPee: strconv.Itoa(yesterdayPee), YesterdayPoo: strconv.Itoa(yesterdayPoo),
-		TwoDaysAgoMilk: strconv.Itoa(twoDaysAgoMilk), TwoDaysAgoNurse: strconv.Itoa(twoDaysAgoNurse),
-		TwoDaysAgoPee: strconv.Itoa(twoDaysAgoPee), TwoDaysAgoPoo: strconv.Itoa(twoDaysAgoPoo),
-		ThreeDaysAgoMilk: strconv.Itoa(threeDaysAgoMilk), ThreeDaysAgoNurse: strconv.Itoa(threeDaysAgoNurse),
-		ThreeDaysAgoPee: strconv.Itoa(threeDaysAgoPee), ThreeDaysAgoPoo: strconv.Itoa(threeDaysAgoPoo),
-		ThreeDayAvgMilk: formatAvg(threeDaySumMilk, 3), ThreeDayAvgNurse: formatAvg(threeDaySumNurse, 3),
-		ThreeDayAvgPee: formatAvg(threeDaySumPee, 3), ThreeDayAvgPoo: formatAvg(threeDaySumPoo, 3),
-		AvgGapMilk: e.FormatAvgGap(milkTimesGap), AvgGapNurse: e.FormatAvgGap(nurseTimesGap),
-		AvgGapPee: e.FormatAvgGap(peeTimesGap), AvgGapPoo: e.FormatAvgGap(pooTimesGap),
-	}
+	return totModels.GeneratedStats{
+		Last12HoursMilk: get("milk", "last12Hours"), Last12HoursNurse: get("nurse", "last12Hours"),
+		Last12HoursPee: get("pee", "last12Hours"), Last12HoursPoo: get("poo", "last12Hours"),
+		Last24HoursMilk: get("milk", "last24Hours"), Last24HoursNurse: get("nurse", "last24Hours"),
+		Last24HoursPee: get("pee", "last24Hours"), Last24HoursPoo: get("poo", "last24Hours"),
+		TodayMilk: get("milk", "today"), TodayNurse: get("nurse", "today"),
+		TodayPee: get("pee", "today"), TodayPoo: get("poo", "today"),
+		YesterdayMilk: get("milk", "yesterday"), YesterdayNurse: get("nurse", "yesterday"),
+		YesterdayPee: get("pee", "yesterday"), YesterdayPoo: get("poo", "yesterday"),
+		TwoDaysAgoMilk: get("milk", "twoDaysAgo"), TwoDaysAgoNurse: get("nurse", "twoDaysAgo"),
+		TwoDaysAgoPee: get("pee", "twoDaysAgo"), TwoDaysAgoPoo: get("poo", "twoDaysAgo"),
+		ThreeDaysAgoMilk: get("milk", "threeDaysAgo"), ThreeDaysAgoNurse: get("nurse", "threeDaysAgo"),
+		ThreeDaysAgoPee: get("pee", "threeDaysAgo"), ThreeDaysAgoPoo: get("poo", "threeDaysAgo"),
+		ThreeDayAvgMilk: get("milk", "threeDayAvg"), ThreeDayAvgNurse: get("nurse", "threeDayAvg"),
+		ThreeDayAvgPee: get("pee", "threeDayAvg"), ThreeDayAvgPoo: get("poo", "threeDayAvg"),
+		AvgGapMilk: get("milkGap", "threeDayAvg"), AvgGapNurse: get("nurseGap", "threeDayAvg"),
+		AvgGapPee: get("peeGap", "threeDayAvg"), AvgGapPoo: get("pooGap", "threeDayAvg"),
+	}, nil
+}
 
-	if !hasEnoughHistory {
-		res.ThreeDayAvgMilk = "---"
-		res.ThreeDayAvgNurse = "---"
-		res.ThreeDayAvgPee = "---"
-		res.ThreeDayAvgPoo = "---"
-		res.AvgGapMilk = "---"
-		res.AvgGapNurse = "---"
-		res.AvgGapPee = "---"
-		res.AvgGapPoo = "---"
+// DayStart returns the beginning of the day containing t, where days begin at the given
+// local hour rather than midnight. Boundaries follow the wall clock of t's location, so
+// days spanning a DST change are 23 or 25 hours long.
+func DayStart(t time.Time, hour int) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, t.Location())
+	if t.Before(start) {
+		start = time.Date(t.Year(), t.Month(), t.Day()-1, hour, 0, 0, 0, t.Location())
 	}
+	return start
+}
 
-	return res, nil
+// dayStartBefore returns the start of the day n days before the day beginning at start.
+// It rebuilds the boundary from the calendar date instead of subtracting 24h multiples.
+func dayStartBefore(start time.Time, hour, n int) time.Time {
+	return time.Date(start.Year(), start.Month(), start.Day()-n, hour, 0, 0, 0, start.Location())
 }
 
 // FormatAvgGap calculates the mean time between events.
@@ -239,13 +97,7 @@ func (e *Engine) FormatAvgGap(times []*time.Time) string {
 	}
 	totalDuration := times[0].Sub(*times[n-1])
 	avgSecs := int64(totalDuration.Seconds()) / int64(n-1)
-	avgDur := time.Duration(avgSecs) * time.Second
-	hours := int(avgDur.Hours())
-	mins := int(avgDur.Minutes()) % 60
-	if hours == 0 {
-		return fmt.Sprintf("%dm", mins)
-	}
-	return fmt.Sprintf("%dh %dm", hours, mins)
+	return FormatGap(time.Duration(avgSecs) * time.Second)
 }
 
 // RecalculateStats rebuilds latest activity markers.
@@ -297,3 +149,26 @@ func (e *Engine) RecalculateStats(tot *totModels.Tot) {
 		}
 	}
 }
+
+// NewerStats returns the newer of each latest activity marker in kept and recalculated. Tallies
+// past MaxTallies are dropped on save, so markers rebuilt from the remaining tallies can be older
+// than the saved ones, or missing. Equal markers come from kept.
+func NewerStats(kept, recalculated totModels.Stats) totModels.Stats {
+	newer := func(a, b *time.Time) *time.Time {
+		if a == nil || (b != nil && b.After(*a)) {
+			return b
+		}
+		return a
+	}
+	merged := totModels.Stats{
+		LastMilk: newer(kept.LastMilk, recalculated.LastMilk), LastSnack: newer(kept.LastSnack, recalculated.LastSnack),
+		LastMeal: newer(kept.LastMeal, recalculated.LastMeal), LastPee: newer(kept.LastPee, recalculated.LastPee),
+		LastPoo: newer(kept.LastPoo, recalculated.LastPoo), LastBath: newer(kept.LastBath, recalculated.LastBath),
+		LastBrush: newer(kept.LastBrush, recalculated.LastBrush),
+		LastNurse: kept.LastNurse, LastNurseSide: kept.LastNurseSide,
+	}
+	if newer(kept.LastNurse, recalculated.LastNurse) != kept.LastNurse {
+		merged.LastNurse, merged.LastNurseSide = recalculated.LastNurse, recalculated.LastNurseSide
+	}
+	return merged
+}
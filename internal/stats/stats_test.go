@@ -85,6 +85,25 @@ func TestRecalculateStats(t *testing.T) {
 	}
 }
 
+func TestNewerStats(t *testing.T) {
+	now := time.Now()
+	earlier, earliest := now.Add(-time.Hour), now.Add(-2*time.Hour)
+
+	kept := totModels.Stats{LastBath: &earliest, LastPee: &earlier, LastNurse: &earlier, LastNurseSide: "L"}
+	recalculated := totModels.Stats{LastPee: &now, LastNurse: &earliest, LastNurseSide: "R", LastMilk: &now}
+	merged := NewerStats(kept, recalculated)
+
+	if merged.LastBath != &earliest {
+		t.Error("expected a marker without a tally kept")
+	}
+	if merged.LastPee != &now || merged.LastMilk != &now {
+		t.Error("expected newer recalculated markers")
+	}
+	if merged.LastNurse != &earlier || merged.LastNurseSide != "L" {
+		t.Errorf("expected the newer nursing marker with its side, got %v %s", merged.LastNurse, merged.LastNurseSide)
+	}
+}
+
 func TestGenerateStats_Detailed(t *testing.T) {
 	cfg := &totConfig.Config{MaxTallies: 100}
 	e := NewEngine(cfg)
@@ -393,3 +412,132 @@ func TestGenerateStats_HistoryBuffer(t *testing.T) {
 		t.Error("Expected avg gap to be populated for sufficient history")
 	}
 }
+
+func TestDayStart(t *testing.T) {
+	tz := time.UTC
+	tests := []struct {
+		name     string
+		t        time.Time
+		hour     int
+		expected time.Time
+	}{
+		{"Midnight boundary", time.Date(2023, 10, 27, 0, 0, 0, 0, tz), 0, time.Date(2023, 10, 27, 0, 0, 0, 0, tz)},
+		{"After boundary", time.Date(2023, 10, 27, 7, 0, 0, 0, tz), 6, time.Date(2023, 10, 27, 6, 0, 0, 0, tz)},
+		{"Exactly at boundary", time.Date(2023, 10, 27, 6, 0, 0, 0, tz), 6, time.Date(2023, 10, 27, 6, 0, 0, 0, tz)},
+		{"Before boundary", time.Date(2023, 10, 27, 5, 59, 0, 0, tz), 6, time.Date(2023, 10, 26, 6, 0, 0, 0, tz)},
+		{"Before boundary across month", time.Date(2023, 11, 1, 2, 0, 0, 0, tz), 6, time.Date(2023, 10, 31, 6, 0, 0, 0, tz)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DayStart(tt.t, tt.hour)
+			if !result.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerateStats_DayStartsAt(t *testing.T) {
+	cfg := &totConfig.Config{MaxTallies: 100}
+	e := NewEngine(cfg)
+	tz := time.UTC
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, tz)
+
+	night := time.Date(2023, 10, 27, 2, 0, 0, 0, tz)    // Before 6am: belongs to yesterday
+	morning := time.Date(2023, 10, 27, 6, 0, 0, 0, tz)  // Exactly 6am: belongs to today
+	lateEve := time.Date(2023, 10, 26, 23, 0, 0, 0, tz) // Yesterday
+	early := time.Date(2023, 10, 26, 5, 59, 0, 0, tz)   // Two days ago
+	wayBack := time.Date(2023, 10, 22, 12, 0, 0, 0, tz) // History buffer
+
+	tot := &totModels.Tot{
+		DayStartsAt: 6,
+		Tallies: []totModels.Tally{
+			{Kind: "🍼4", Time: &morning},
+			{Kind: "🍼3", Time: &night},
+			{Kind: "🍼2", Time: &lateEve},
+			{Kind: "🍼1", Time: &early},
+			{Kind: "🍼0", Time: &wayBack},
+		},
+	}
+
+	s, err := e.GenerateStats(tot, tz, now)
+	if err != nil {
+		t.Fatalf("GenerateStats failed: %v", err)
+	}
+
+	if s.TodayMilk != "4" || s.YesterdayMilk != "5" || s.TwoDaysAgoMilk != "1" {
+		t.Errorf("Daily milk buckets mismatch: Today=%s, Yesterday=%s, 2d=%s", s.TodayMilk, s.YesterdayMilk, s.TwoDaysAgoMilk)
+	}
+	// (5 + 1 + 0) / 3 = 2
+	if s.ThreeDayAvgMilk != "2" {
+		t.Errorf("Expected ThreeDayAvgMilk 2, got %s", s.ThreeDayAvgMilk)
+	}
+	// Gaps only include yesterday and two days ago: night -> early = 20h 1m over 2 gaps.
+	if s.AvgGapMilk != "10h 0m" {
+		t.Errorf("Expected AvgGapMilk 10h 0m, got %s", s.AvgGapMilk)
+	}
+
+	// Before 6am the logical "today" is still the previous calendar day.
+	s, err = e.GenerateStats(tot, tz, time.Date(2023, 10, 27, 5, 0, 0, 0, tz))
+	if err != nil {
+		t.Fatalf("GenerateStats failed: %v", err)
+	}
+	if s.YesterdayMilk != "1" {
+		t.Errorf("Expected YesterdayMilk 1 before day start, got %s", s.YesterdayMilk)
+	}
+}
+
+func TestGenerateStats_DST(t *testing.T) {
+	cfg := &totConfig.Config{MaxTallies: 100}
+	e := NewEngine(cfg)
+	tz, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tAt := func(month time.Month, day, hour, min int) *time.Time {
+		tm := time.Date(2023, month, day, hour, min, 0, 0, tz)
+		return &tm
+	}
+
+	t.Run("Spring forward day is 23 hours", func(t *testing.T) {
+		// Clocks jump from 2:00 to 3:00 on 12 Mar 2023.
+		tot := &totModels.Tot{
+			Tallies: []totModels.Tally{
+				{Kind: "🍼1", Time: tAt(time.March, 13, 0, 30)},  // Today
+				{Kind: "🍼2", Time: tAt(time.March, 12, 23, 30)}, // Yesterday
+				{Kind: "🍼4", Time: tAt(time.March, 12, 0, 30)},  // Yesterday, before the jump
+				{Kind: "🍼8", Time: tAt(time.March, 11, 23, 30)}, // Two days ago
+			},
+		}
+		s, err := e.GenerateStats(tot, tz, *tAt(time.March, 13, 12, 0))
+		if err != nil {
+			t.Fatalf("GenerateStats failed: %v", err)
+		}
+		if s.TodayMilk != "1" || s.YesterdayMilk != "6" || s.TwoDaysAgoMilk != "8" {
+			t.Errorf("Daily milk buckets mismatch: Today=%s, Yesterday=%s, 2d=%s", s.TodayMilk, s.YesterdayMilk, s.TwoDaysAgoMilk)
+		}
+	})
+
+	t.Run("Fall back day is 25 hours with late day start", func(t *testing.T) {
+		// Clocks repeat 1:00-2:00 on 5 Nov 2023; days start at 6am.
+		repeated := time.Date(2023, time.November, 5, 1, 30, 0, 0, tz).Add(time.Hour) // Second 1:30 (EST)
+		tot := &totModels.Tot{
+			DayStartsAt: 6,
+			Tallies: []totModels.Tally{
+				{Kind: "🍼1", Time: tAt(time.November, 5, 6, 0)},  // Today
+				{Kind: "🍼2", Time: &repeated},                    // Yesterday (before 6am)
+				{Kind: "🍼4", Time: tAt(time.November, 4, 6, 0)},  // Yesterday
+				{Kind: "🍼8", Time: tAt(time.November, 4, 5, 59)}, // Two days ago
+			},
+		}
+		s, err := e.GenerateStats(tot, tz, *tAt(time.November, 5, 12, 0))
+		if err != nil {
+			t.Fatalf("GenerateStats failed: %v", err)
+		}
+		if s.TodayMilk != "1" || s.YesterdayMilk != "6" || s.TwoDaysAgoMilk != "8" {
+			t.Errorf("Daily milk buckets mismatch: Today=%s, Yesterday=%s, 2d=%s", s.TodayMilk, s.YesterdayMilk, s.TwoDaysAgoMilk)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+// zones.go resolves the timezone in effect at a point in a tot's history, so travel does not
+// move past tallies to other days.
+package stats
+
+import (
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+// Zones maps instants to the wall clock of the zone in effect at the time.
+type Zones struct {
+	current *time.Location
+	changes []totModels.TimezoneChange
+	locs    map[string]*time.Location
+}
+
+// NewZones resolves the tot's timezone changes. current is the tot's timezone now, which is
+// used for everything after the last change and for tots that never changed.
+func NewZones(tot *totModels.Tot, current *time.Location) Zones {
+	z := Zones{current: current, changes: tot.TimezoneChanges, locs: map[string]*time.Location{}}
+	for _, c := range tot.TimezoneChanges {
+		for _, name := range []string{c.From, c.To} {
+			if _, ok := z.locs[name]; !ok {
+				if loc, err := time.LoadLocation(name); err == nil {
+					z.locs[name] = loc
+				}
+			}
+		}
+	}
+	return z
+}
+
+// Location returns the zone in effect at t.
+func (z Zones) Location(t time.Time) *time.Location {
+	for i := len(z.changes) - 1; i >= 0; i-- {
+		if !t.Before(z.changes[i].At) {
+			return z.lookup(z.changes[i].To)
+		}
+	}
+	if len(z.changes) > 0 {
+		return z.lookup(z.changes[0].From)
+	}
+	return z.current
+}
+
+// In returns t on the wall clock of the zone in effect at t.
+func (z Zones) In(t time.Time) time.Time {
+	return t.In(z.Location(t))
+}
+
+func (z Zones) lookup(name string) *time.Location {
+	if loc, ok := z.locs[name]; ok {
+		return loc
+	}
+	return z.current
+}
+
+// dayOf returns the date of the tot day t fell on where it happened, as midnight UTC so days
+// from different zones compare directly.
+func dayOf(t time.Time, hour int) time.Time {
+	start := DayStart(t, hour)
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+}
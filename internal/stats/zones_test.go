@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totModels "tot-tally/internal/models"
+)
+
+// tripTot flies from Chicago to Tokyo on the 26th and on to London on the 27th of Oct 2023.
+// Each bottle is logged at the local time in the comment.
+func tripTot() *totModels.Tot {
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2023, 10, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	return &totModels.Tot{
+		Timezone: "Europe/London",
+		TimezoneChanges: []totModels.TimezoneChange{
+			{At: *at(26, 6), From: "America/Chicago", To: "Asia/Tokyo"},
+			{At: *at(27, 12), From: "Asia/Tokyo", To: "Europe/London"},
+		},
+		Tallies: []totModels.Tally{
+			{Kind: "🍼5", Time: at(27, 19)}, // 27th 8 PM London.
+			{Kind: "🍼1", Time: at(26, 22)}, // 27th 7 AM Tokyo.
+			{Kind: "🍼4", Time: at(26, 11)}, // 26th 8 PM Tokyo.
+			{Kind: "🍼2", Time: at(25, 15)}, // 25th 10 AM Chicago.
+			{Kind: "🍼3", Time: at(25, 1)},  // 24th 8 PM Chicago.
+		},
+	}
+}
+
+func TestZones(t *testing.T) {
+	tot := tripTot()
+	london, _ := time.LoadLocation("Europe/London")
+	z := NewZones(tot, london)
+
+	for _, tc := range []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2023, 10, 20, 0, 0, 0, 0, time.UTC), "America/Chicago"},
+		{time.Date(2023, 10, 26, 6, 0, 0, 0, time.UTC), "Asia/Tokyo"},
+		{time.Date(2023, 10, 27, 11, 59, 0, 0, time.UTC), "Asia/Tokyo"},
+		{time.Date(2023, 10, 28, 0, 0, 0, 0, time.UTC), "Europe/London"},
+	} {
+		if got := z.Location(tc.at).String(); got != tc.want {
+			t.Errorf("Location(%v) = %s, want %s", tc.at, got, tc.want)
+		}
+	}
+
+	// Without changes, everything is in the current zone.
+	if got := NewZones(&totModels.Tot{}, london).Location(time.Time{}); got != london {
+		t.Errorf("expected the current zone, got %v", got)
+	}
+}
+
+func TestReport_Travel(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	london, _ := time.LoadLocation("Europe/London")
+	now := time.Date(2023, 10, 28, 10, 0, 0, 0, time.UTC)
+
+	r, err := e.Report(tripTot(), london, now, 5, []Category{MilkCategory})
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	// Most recent first: the 28th, 27th, 26th, 25th and 24th, each by its local date.
+	want := []int{0, 6, 4, 2, 3}
+	for i, w := range want {
+		if got := r.Days[i].Values[0]; got != w {
+			t.Errorf("day %d: expected %d oz, got %d", i, w, got)
+		}
+	}
+	if !r.Days[4].HasData {
+		t.Error("expected the first day of the trip history to be covered")
+	}
+
+	// Had the whole history moved to London, the 24th would be empty and the 26th and 25th would merge.
+	moved := tripTot()
+	moved.TimezoneChanges = nil
+	r, _ = e.Report(moved, london, now, 5, []Category{MilkCategory})
+	if r.Days[4].Values[0] != 0 || r.Days[3].Values[0] != 5 {
+		t.Errorf("expected the untracked history to shift, got %v %v", r.Days[3].Values, r.Days[4].Values)
+	}
+}
+
+func TestCompute_TravelYesterdayUnchanged(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	chicago, _ := time.LoadLocation("America/Chicago")
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	spec := Spec{
+		Metrics: []Metric{{Name: "milk", Category: MilkCategory, Aggregate: Sum}},
+		Windows: []Window{
+			{Name: "today", Kind: Days, Offset: 0, Days: 1},
+			{Name: "yesterday", Kind: Days, Offset: 1, Days: 1},
+		},
+	}
+
+	// Still in Chicago on the evening of the 25th.
+	tot := tripTot()
+	tot.Tallies = tot.Tallies[3:]
+	tot.Timezone, tot.TimezoneChanges = "America/Chicago", nil
+	before, err := e.Compute(tot, chicago, time.Date(2023, 10, 26, 4, 0, 0, 0, time.UTC), spec)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if before.Get("milk", "today").Number != 2 || before.Get("milk", "yesterday").Number != 3 {
+		t.Fatalf("unexpected totals before the trip: %+v", before)
+	}
+
+	// Landed in Tokyo on the 26th: the 25th is yesterday, with the same total.
+	tot.Timezone = "Asia/Tokyo"
+	tot.TimezoneChanges = tripTot().TimezoneChanges[:1]
+	after, _ := e.Compute(tot, tokyo, time.Date(2023, 10, 26, 7, 0, 0, 0, time.UTC), spec)
+	if after.Get("milk", "today").Number != 0 || after.Get("milk", "yesterday").Number != 2 {
+		t.Errorf("unexpected totals after the trip: %+v", after)
+	}
+}
+
+func TestRunningTotals_Travel(t *testing.T) {
+	e := NewEngine(&totConfig.Config{})
+	london, _ := time.LoadLocation("Europe/London")
+
+	totals, err := e.RunningTotals(tripTot(), london, time.Date(2023, 10, 26, 0, 0, 0, 0, time.UTC), []Category{MilkCategory})
+	if err != nil {
+		t.Fatalf("RunningTotals failed: %v", err)
+	}
+	// The 27th starts in Tokyo and ends in London, but is one day.
+	want := []int{4, 1, 6}
+	if len(totals) != len(want) {
+		t.Fatalf("expected %d totals, got %+v", len(want), totals)
+	}
+	for i, w := range want {
+		if totals[i].Values[0] != w {
+			t.Errorf("total %d: expected %d, got %d", i, w, totals[i].Values[0])
+		}
+	}
+}
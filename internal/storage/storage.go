@@ -1,4 +1,4 @@
-// storage.go handles atomic file-based persistence and IP-based rate limiting.
+// storage.go handles atomic file-based persistence, public link references and IP-based rate limiting.
 package storage
 
 import (
@@ -31,10 +31,16 @@ func NewRepository(cfg *totConfig.Config, pool *totShards.Pool) *Repository {
 
 // SaveTot writes the record to disk atomically using Write-Then-Rename.
 func (r *Repository) SaveTot(tot *totModels.Tot) error {
+	tot.UpdatedAt = time.Now().UTC()
+	return r.SaveTotWithoutActivity(tot)
+}
+
+// SaveTotWithoutActivity writes the record like SaveTot but leaves UpdatedAt alone.
+// Background workers use it so their bookkeeping doesn't keep inactive tots from cleanup.
+func (r *Repository) SaveTotWithoutActivity(tot *totModels.Tot) error {
 	if len(tot.Tallies) > r.config.MaxTallies {
 		tot.Tallies = tot.Tallies[:r.config.MaxTallies]
 	}
-	tot.UpdatedAt = time.Now().UTC()
 
 	finalPath := filepath.Join(r.config.TotDirectory, filepath.Base(tot.ID)+".json")
 	tmpPath := finalPath + ".tmp"
@@ -84,9 +90,100 @@ func (r *Repository) LoadTot(totID string) (*totModels.Tot, error) {
 	return tot, nil
 }
 
+// DeleteTot removes a tot's file. Its public links are left to the caller.
+func (r *Repository) DeleteTot(totID string) error {
+	filename := filepath.Join(r.config.TotDirectory, filepath.Base(totID)+".json")
+	if err := os.Remove(filename); err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("tot does not exist")
+		}
+		return fmt.Errorf("storage: failed to delete tot file: %w", err)
+	}
+	return nil
+}
+
+// TotStamp identifies a version of a tot's file, so background services can tell whether it
+// changed since they last read it without reading it again.
+type TotStamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// StatTot returns the stamp of a tot's file. Saves replace the file, so any save changes it.
+func (r *Repository) StatTot(totID string) (TotStamp, error) {
+	info, err := os.Stat(filepath.Join(r.config.TotDirectory, filepath.Base(totID)+".json"))
+	if err != nil {
+		return TotStamp{}, fmt.Errorf("storage: failed to stat tot file: %w", err)
+	}
+	return TotStamp{ModTime: info.ModTime(), Size: info.Size()}, nil
+}
+
+// ListTotIDs returns the IDs of every tot file on disk.
+func (r *Repository) ListTotIDs() ([]string, error) {
+	entries, err := os.ReadDir(r.config.TotDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to read tot directory: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// SaveLink maps a public link reference to a tot ID, so shared URLs need not contain the tot ID.
+func (r *Repository) SaveLink(ref, totID string) error {
+	finalPath := r.linkPath(ref)
+	tmpPath := finalPath + ".tmp"
+
+	if err := os.WriteFile(tmpPath, []byte(totID), 0644); err != nil {
+		return fmt.Errorf("storage: failed to write link tmp: %w", err)
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("storage: failed to swap link file: %w", err)
+	}
+	return nil
+}
+
+// LoadLink returns the tot ID a public link reference points to.
+func (r *Repository) LoadLink(ref string) (string, error) {
+	data, err := os.ReadFile(r.linkPath(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.New("link does not exist")
+		}
+		return "", fmt.Errorf("storage: failed to read link: %w", err)
+	}
+	return string(data), nil
+}
+
+// DeleteLink removes a public link reference. Removing a missing reference is not an error.
+func (r *Repository) DeleteLink(ref string) error {
+	if err := os.Remove(r.linkPath(ref)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("storage: failed to delete link: %w", err)
+	}
+	return nil
+}
+
+// linkPath hashes the reference so arbitrary input from URLs can't escape the link directory.
+func (r *Repository) linkPath(ref string) string {
+	return filepath.Join(r.config.LinkDirectory, fmt.Sprintf("%x", sha256.Sum256([]byte(ref))))
+}
+
+// IPLimit is how many tots an IP address has created, and when it last did.
+type IPLimit struct {
+	Hash        string // SHA-256 of the address, which names the limit file.
+	Count       int
+	LastCreated time.Time
+}
+
 // CheckAndIncrementIPLimit manages the file-based IP counter.
 func (r *Repository) CheckAndIncrementIPLimit(ip string) error {
-	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(ip)))
+	hash := ipHash(ip)
 	finalPath := filepath.Join(r.config.LimitDirectory, hash)
 	tmpPath := finalPath + ".tmp"
 
@@ -94,19 +191,12 @@ func (r *Repository) CheckAndIncrementIPLimit(ip string) error {
 	mut.Lock()
 	defer mut.Unlock()
 
-	count := 0
-	if data, err := os.ReadFile(finalPath); err == nil {
-		lines := strings.Split(string(data), "\n")
-		if len(lines) > 0 {
-			count, _ = strconv.Atoi(lines[0])
-		}
-	}
-
-	if count >= r.config.MaxTotsPerIP {
+	limit := r.readIPLimit(ip)
+	if limit.Count >= r.config.MaxTotsPerIP {
 		return errors.New("limit reached")
 	}
 
-	content := fmt.Sprintf("%d\n%d", count+1, time.Now().UnixMilli())
+	content := fmt.Sprintf("%d\n%d", limit.Count+1, time.Now().UnixMilli())
 	if err := os.WriteFile(tmpPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("storage: failed to write limit tmp: %w", err)
 	}
@@ -117,6 +207,178 @@ func (r *Repository) CheckAndIncrementIPLimit(ip string) error {
 	return nil
 }
 
+// LoadIPLimit returns the counter for an IP address. An address without one has a zero Count.
+func (r *Repository) LoadIPLimit(ip string) IPLimit {
+	mut := r.pool.GetShardMutex(ipHash(ip))
+	mut.Lock()
+	defer mut.Unlock()
+	return r.readIPLimit(ip)
+}
+
+// ResetIPLimit removes the counter for an IP address, reporting whether it had one.
+func (r *Repository) ResetIPLimit(ip string) (bool, error) {
+	hash := ipHash(ip)
+	mut := r.pool.GetShardMutex(hash)
+	mut.Lock()
+	defer mut.Unlock()
+
+	if err := os.Remove(filepath.Join(r.config.LimitDirectory, hash)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("storage: failed to delete limit file: %w", err)
+	}
+	return true, nil
+}
+
+// readIPLimit parses a limit file, "count\nunix-millis". Missing or malformed parts read as zero.
+func (r *Repository) readIPLimit(ip string) IPLimit {
+	limit := IPLimit{Hash: ipHash(ip)}
+	data, err := os.ReadFile(filepath.Join(r.config.LimitDirectory, limit.Hash))
+	if err != nil {
+		return limit
+	}
+	lines := strings.Split(string(data), "\n")
+	limit.Count, _ = strconv.Atoi(lines[0])
+	if len(lines) > 1 {
+		if ms, err := strconv.ParseInt(lines[1], 10, 64); err == nil {
+			limit.LastCreated = time.UnixMilli(ms)
+		}
+	}
+	return limit
+}
+
+// ipHash names the limit file of an IP address, so addresses are never stored.
+func ipHash(ip string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(ip)))
+}
+
+// Quarantined describes a data file moved into QuarantineDirectory. It is saved beside the file
+// with a ".reason" suffix.
+type Quarantined struct {
+	Name   string    `json:"-"`    // The file's name in QuarantineDirectory.
+	Path   string    `json:"path"` // Where the file was, as an absolute path.
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// QuarantineFile moves a damaged data file into QuarantineDirectory with a note of where it came
+// from and why, instead of deleting it. It returns the file's new path. The note records the
+// absolute path, so a restore finds it whatever directory it runs from.
+func (r *Repository) QuarantineFile(path, reason string) (string, error) {
+	original, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("storage: failed to resolve %s: %w", path, err)
+	}
+	if err := os.MkdirAll(r.config.QuarantineDirectory, 0755); err != nil {
+		return "", fmt.Errorf("storage: failed to create quarantine directory: %w", err)
+	}
+	now := time.Now().UTC()
+	note, err := json.Marshal(Quarantined{Path: original, Reason: reason, At: now})
+	if err != nil {
+		return "", fmt.Errorf("storage: failed to encode quarantine note: %w", err)
+	}
+
+	// Creating the note exclusively claims the name, so files quarantined together never collide.
+	name := now.Format("20060102T150405.000") + "-" + filepath.Base(path)
+	dest := filepath.Join(r.config.QuarantineDirectory, name)
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(dest+".reason", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = file.Write(note)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(dest + ".reason")
+				return "", fmt.Errorf("storage: failed to write quarantine note: %w", err)
+			}
+			break
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("storage: failed to write quarantine note: %w", err)
+		}
+		dest = filepath.Join(r.config.QuarantineDirectory, fmt.Sprintf("%s.%d", name, i))
+	}
+	if err := os.Rename(path, dest); err != nil {
+		os.Remove(dest + ".reason")
+		return "", fmt.Errorf("storage: failed to quarantine file: %w", err)
+	}
+	return dest, nil
+}
+
+// ListQuarantined returns every quarantined file, oldest first. A file whose note can't be read
+// is listed with only its Name.
+func (r *Repository) ListQuarantined() ([]Quarantined, error) {
+	entries, err := os.ReadDir(r.config.QuarantineDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("storage: failed to read quarantine directory: %w", err)
+	}
+
+	var files []Quarantined
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".reason")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		q, err := r.LoadQuarantined(name)
+		if err != nil {
+			q = Quarantined{Name: name}
+		}
+		files = append(files, q)
+	}
+	return files, nil
+}
+
+// LoadQuarantined reads the note of a quarantined file.
+func (r *Repository) LoadQuarantined(name string) (Quarantined, error) {
+	q := Quarantined{Name: filepath.Base(name)}
+	path := filepath.Join(r.config.QuarantineDirectory, q.Name)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return q, fmt.Errorf("storage: no quarantined file %q", q.Name)
+		}
+		return q, fmt.Errorf("storage: failed to open quarantined file: %w", err)
+	}
+	data, err := os.ReadFile(path + ".reason")
+	if err != nil {
+		return q, fmt.Errorf("storage: failed to read quarantine note: %w", err)
+	}
+	if err := json.Unmarshal(data, &q); err != nil {
+		return q, fmt.Errorf("storage: failed to decode quarantine note: %w", err)
+	}
+	return q, nil
+}
+
+// RestoreQuarantined moves a quarantined file to dest, or back where it came from when dest is
+// empty, and removes its note. An existing file at dest is only overwritten when replace is set.
+// It returns where the file went.
+func (r *Repository) RestoreQuarantined(name, dest string, replace bool) (string, error) {
+	q, err := r.LoadQuarantined(name)
+	if err != nil {
+		return "", err
+	}
+	if dest == "" {
+		dest = q.Path
+	}
+	if dest == "" {
+		return "", fmt.Errorf("storage: quarantined file %q has no original path", q.Name)
+	}
+	if _, err := os.Stat(dest); err == nil && !replace {
+		return "", fmt.Errorf("storage: %s already exists", dest)
+	}
+
+	path := filepath.Join(r.config.QuarantineDirectory, q.Name)
+	if err := os.Rename(path, dest); err != nil {
+		return "", fmt.Errorf("storage: failed to restore quarantined file: %w", err)
+	}
+	os.Remove(path + ".reason")
+	return dest, nil
+}
+
 // GenerateID creates a new time-ordered UUID v7 string.
 // UUID v7 is preferred because it is time-ordered and industry standard.
 func (r *Repository) GenerateID() (string, error) {
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	totConfig "tot-tally/internal/config"
 	totModels "tot-tally/internal/models"
 	totShards "tot-tally/internal/shards"
@@ -295,3 +296,251 @@ func TestSaveTot_CreateError(t *testing.T) {
 		t.Error("Expected error when directory is a file, got nil")
 	}
 }
+
+func TestSaveTotWithoutActivity(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: tmpDir, MaxTallies: 10}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+
+	updated := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	tot := &totModels.Tot{ID: "quiet", UpdatedAt: updated}
+
+	if err := repo.SaveTotWithoutActivity(tot); err != nil {
+		t.Fatalf("SaveTotWithoutActivity failed: %v", err)
+	}
+
+	loaded, err := repo.LoadTot("quiet")
+	if err != nil {
+		t.Fatalf("LoadTot failed: %v", err)
+	}
+	if !loaded.UpdatedAt.Equal(updated) {
+		t.Errorf("expected UpdatedAt to be preserved, got %v", loaded.UpdatedAt)
+	}
+}
+
+func TestListTotIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: tmpDir, MaxTallies: 10}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+
+	_ = repo.SaveTot(&totModels.Tot{ID: "a"})
+	_ = repo.SaveTot(&totModels.Tot{ID: "b"})
+	_ = os.WriteFile(filepath.Join(tmpDir, "c.json.tmp"), []byte("{}"), 0644)
+	_ = os.Mkdir(filepath.Join(tmpDir, "d.json"), 0755)
+
+	ids, err := repo.ListTotIDs()
+	if err != nil {
+		t.Fatalf("ListTotIDs failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("expected [a b], got %v", ids)
+	}
+
+	cfg.TotDirectory = filepath.Join(tmpDir, "missing")
+	if _, err := repo.ListTotIDs(); err == nil {
+		t.Error("expected error for missing directory, got nil")
+	}
+}
+
+func TestStatTot(t *testing.T) {
+	cfg := &totConfig.Config{TotDirectory: t.TempDir(), MaxTallies: 10}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+
+	tot := &totModels.Tot{ID: "a"}
+	_ = repo.SaveTotWithoutActivity(tot)
+	before, err := repo.StatTot("a")
+	if err != nil {
+		t.Fatalf("StatTot failed: %v", err)
+	}
+	if again, _ := repo.StatTot("a"); again != before {
+		t.Errorf("expected the same stamp without a save, got %+v and %+v", before, again)
+	}
+	tot.Name = "👶"
+	_ = repo.SaveTotWithoutActivity(tot)
+	if after, _ := repo.StatTot("a"); after == before {
+		t.Error("expected a save to change the stamp")
+	}
+
+	if _, err := repo.StatTot("missing"); err == nil {
+		t.Error("expected error for a missing tot, got nil")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{LinkDirectory: tmpDir}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+
+	if err := repo.SaveLink("../../etc/passwd", "tot-1"); err != nil {
+		t.Fatalf("SaveLink failed: %v", err)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte("../../etc/passwd")))
+	if _, err := os.Stat(filepath.Join(tmpDir, hash)); err != nil {
+		t.Fatalf("expected link file to be named by hash: %v", err)
+	}
+
+	if totID, err := repo.LoadLink("../../etc/passwd"); err != nil || totID != "tot-1" {
+		t.Errorf("expected tot-1, got %q %v", totID, err)
+	}
+
+	if err := repo.DeleteLink("../../etc/passwd"); err != nil {
+		t.Fatalf("DeleteLink failed: %v", err)
+	}
+	if err := repo.DeleteLink("../../etc/passwd"); err != nil {
+		t.Errorf("expected deleting a missing link to succeed, got %v", err)
+	}
+	if _, err := repo.LoadLink("../../etc/passwd"); err == nil || err.Error() != "link does not exist" {
+		t.Errorf("expected link does not exist, got %v", err)
+	}
+
+	cfg.LinkDirectory = filepath.Join(tmpDir, "missing")
+	if err := repo.SaveLink("ref", "tot-1"); err == nil {
+		t.Error("expected error for missing directory, got nil")
+	}
+}
+
+func TestIPLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{LimitDirectory: tmpDir, MaxTotsPerIP: 5}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+
+	if limit := repo.LoadIPLimit("10.0.0.1"); limit.Count != 0 || !limit.LastCreated.IsZero() {
+		t.Errorf("expected no limit, got %+v", limit)
+	}
+	before := time.Now().Add(-time.Second)
+	_ = repo.CheckAndIncrementIPLimit("10.0.0.1")
+	_ = repo.CheckAndIncrementIPLimit("10.0.0.1")
+
+	limit := repo.LoadIPLimit("10.0.0.1")
+	if limit.Count != 2 || limit.LastCreated.Before(before) || limit.Hash != fmt.Sprintf("%x", sha256.Sum256([]byte("10.0.0.1"))) {
+		t.Errorf("unexpected limit %+v", limit)
+	}
+
+	if reset, err := repo.ResetIPLimit("10.0.0.1"); !reset || err != nil {
+		t.Fatalf("expected reset, got %v %v", reset, err)
+	}
+	if reset, err := repo.ResetIPLimit("10.0.0.1"); reset || err != nil {
+		t.Errorf("expected nothing to reset, got %v %v", reset, err)
+	}
+	if limit := repo.LoadIPLimit("10.0.0.1"); limit.Count != 0 {
+		t.Errorf("expected the count to restart, got %+v", limit)
+	}
+}
+
+func TestDeleteTot(t *testing.T) {
+	cfg := &totConfig.Config{TotDirectory: t.TempDir(), MaxTallies: 10}
+	repo := NewRepository(cfg, totShards.NewPool(1))
+	_ = repo.SaveTot(&totModels.Tot{ID: "a"})
+
+	if err := repo.DeleteTot("a"); err != nil {
+		t.Fatalf("DeleteTot failed: %v", err)
+	}
+	if _, err := repo.LoadTot("a"); err == nil {
+		t.Error("expected the tot to be gone")
+	}
+	if err := repo.DeleteTot("a"); err == nil || err.Error() != "tot does not exist" {
+		t.Errorf("expected tot does not exist, got %v", err)
+	}
+}
+
+func TestQuarantineFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: tmpDir, QuarantineDirectory: filepath.Join(tmpDir, "quarantine")}
+	repo := NewRepository(cfg, totShards.NewPool(4))
+
+	path := filepath.Join(tmpDir, "broken.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	dest, err := repo.QuarantineFile(path, "undecodable")
+	if err != nil {
+		t.Fatalf("QuarantineFile failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the file moved away, got %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "{" {
+		t.Errorf("expected the contents kept, got %q", data)
+	}
+
+	var note Quarantined
+	data, _ := os.ReadFile(dest + ".reason")
+	if err := json.Unmarshal(data, &note); err != nil || note.Path != path || note.Reason != "undecodable" || note.At.IsZero() {
+		t.Errorf("unexpected quarantine note %+v (%v)", note, err)
+	}
+
+	if _, err := repo.QuarantineFile(path, "undecodable"); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+	if entries, _ := os.ReadDir(cfg.QuarantineDirectory); len(entries) != 2 {
+		t.Errorf("expected a failed quarantine to leave no note, got %d files", len(entries))
+	}
+
+	// Files with the same name quarantined together are kept apart.
+	for range 3 {
+		os.WriteFile(path, []byte("{"), 0644)
+		repo.QuarantineFile(path, "undecodable")
+	}
+	if entries, _ := os.ReadDir(cfg.QuarantineDirectory); len(entries) != 8 {
+		t.Errorf("expected every file and note kept, got %d files", len(entries))
+	}
+}
+
+func TestRestoreQuarantined(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: tmpDir, QuarantineDirectory: filepath.Join(tmpDir, "quarantine")}
+	repo := NewRepository(cfg, totShards.NewPool(4))
+
+	if files, err := repo.ListQuarantined(); err != nil || len(files) != 0 {
+		t.Errorf("expected no quarantine before the first file, got %v (%v)", files, err)
+	}
+
+	path := filepath.Join(tmpDir, "a.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	dest, _ := repo.QuarantineFile(path, "unreadable")
+	files, err := repo.ListQuarantined()
+	if err != nil || len(files) != 1 || files[0].Name != filepath.Base(dest) || files[0].Path != path {
+		t.Fatalf("unexpected quarantine list %+v (%v)", files, err)
+	}
+
+	os.WriteFile(path, []byte("{}"), 0644)
+	if _, err := repo.RestoreQuarantined(files[0].Name, "", false); err == nil {
+		t.Error("expected an existing file to be kept")
+	}
+	other := filepath.Join(tmpDir, "b.json")
+	if got, err := repo.RestoreQuarantined(files[0].Name, other, false); err != nil || got != other {
+		t.Fatalf("expected a restore elsewhere, got %q (%v)", got, err)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "{" {
+		t.Errorf("expected the quarantined contents, got %q", data)
+	}
+	if entries, _ := os.ReadDir(cfg.QuarantineDirectory); len(entries) != 0 {
+		t.Errorf("expected the note removed, got %d files", len(entries))
+	}
+	if _, err := repo.LoadQuarantined(files[0].Name); err == nil {
+		t.Error("expected a restored file to be gone from quarantine")
+	}
+}
+
+func TestRestoreQuarantined_OtherDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: "tots", QuarantineDirectory: filepath.Join(tmpDir, "quarantine")}
+	repo := NewRepository(cfg, totShards.NewPool(4))
+
+	// Quarantined through the relative TotDirectory, as the server sees it.
+	t.Chdir(tmpDir)
+	os.MkdirAll("tots", 0755)
+	os.WriteFile(filepath.Join("tots", "a.json"), []byte("{"), 0644)
+	dest, err := repo.QuarantineFile(filepath.Join("tots", "a.json"), "unreadable")
+	if err != nil {
+		t.Fatalf("QuarantineFile failed: %v", err)
+	}
+
+	// Restored by an admin command started somewhere else.
+	t.Chdir(t.TempDir())
+	want := filepath.Join(tmpDir, "tots", "a.json")
+	if got, err := repo.RestoreQuarantined(filepath.Base(dest), "", false); err != nil || got != want {
+		t.Fatalf("expected a restore to %s, got %q (%v)", want, got, err)
+	}
+	if data, _ := os.ReadFile(want); string(data) != "{" {
+		t.Errorf("expected the quarantined contents back in place, got %q", data)
+	}
+}
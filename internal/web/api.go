@@ -0,0 +1,261 @@
+// api.go serves the versioned JSON API under /api/v1. The tot ID in the path is the credential,
+// as it is for the HTML pages.
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	totAlerts "tot-tally/internal/alerts"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totStats "tot-tally/internal/stats"
+)
+
+// loadAPITot loads the tot named in the path for a read-only request.
+func (s *Server) loadAPITot(req *http.Request) (*totModels.Tot, error) {
+	totID := req.PathValue("id")
+	if !isValidID(totID) {
+		return nil, errAPINotFound
+	}
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		if err.Error() == "tot does not exist" {
+			return nil, errAPINotFound
+		}
+		return nil, err
+	}
+	return tot, nil
+}
+
+// updateAPITot applies a change to the tot named in the path under its shard lock and commits it.
+// The change returns the event to publish and the response body.
+func (s *Server) updateAPITot(req *http.Request, change func(tot *totModels.Tot) (totCore.Event, any, error)) (any, error) {
+	totID := req.PathValue("id")
+	if !isValidID(totID) {
+		return nil, errAPINotFound
+	}
+	mut := s.shards.GetShardMutex(totID)
+	mut.Lock()
+	defer mut.Unlock()
+
+	tot, err := s.loadAPITot(req)
+	if err != nil {
+		return nil, err
+	}
+
+	event, body, err := change(tot)
+	if err != nil {
+		return nil, err
+	}
+	tzLoc, _ := time.LoadLocation(tot.Timezone)
+	if err := s.core.Commit(tot, tzLoc, event); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) apiGetTotHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	tot, err := s.loadAPITot(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, apiTot(tot), nil
+}
+
+func (s *Server) apiUpdateTotHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	var input totModels.APITotUpdate
+	if err := decodeJSON(req, &input); err != nil {
+		return 0, nil, err
+	}
+
+	var changed []string
+	if input.Timezone != nil {
+		if !totConfig.ValidTimezone(*input.Timezone) {
+			return 0, nil, newAPIError(http.StatusBadRequest, "invalid_timezone", "unsupported timezone")
+		}
+		changed = append(changed, "timezone")
+	}
+	if input.MilkSetting != nil {
+		if _, ok := totConfig.AllowedMilkSettings[*input.MilkSetting]; !ok {
+			return 0, nil, newAPIError(http.StatusBadRequest, "invalid_milk_setting", "milkSetting must be bottle, nursing or both")
+		}
+		changed = append(changed, "milk_setting")
+	}
+	if input.DayStartsAt != nil {
+		if *input.DayStartsAt < 0 || *input.DayStartsAt > 23 {
+			return 0, nil, newAPIError(http.StatusBadRequest, "invalid_day_starts_at", "dayStartsAt must be an hour from 0 to 23")
+		}
+		changed = append(changed, "day_starts_at")
+	}
+	if len(changed) == 0 {
+		return 0, nil, newAPIError(http.StatusBadRequest, "invalid_request", "no settings to update")
+	}
+
+	body, err := s.updateAPITot(req, func(tot *totModels.Tot) (totCore.Event, any, error) {
+		if input.Timezone != nil {
+			if err := s.core.ChangeTimezone(tot, *input.Timezone, time.Now()); err != nil {
+				return totCore.Event{}, nil, err
+			}
+		}
+		if input.MilkSetting != nil {
+			tot.MilkSetting = *input.MilkSetting
+		}
+		if input.DayStartsAt != nil {
+			tot.DayStartsAt = *input.DayStartsAt
+		}
+		event := totCore.Event{Type: totCore.EventSettingsUpdated, Setting: strings.Join(changed, ",")}
+		return event, apiTot(tot), nil
+	})
+	return http.StatusOK, body, err
+}
+
+func (s *Server) apiListTalliesHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	tot, err := s.loadAPITot(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	limit := len(tot.Tallies)
+	if l := req.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			return 0, nil, newAPIError(http.StatusBadRequest, "invalid_limit", "limit must be a positive number")
+		}
+		limit = min(n, limit)
+	}
+	return http.StatusOK, totModels.APITallyList{Tallies: apiTallies(tot.Tallies[:limit])}, nil
+}
+
+func (s *Server) apiAddTallyHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	var input totModels.APITallyInput
+	if err := decodeJSON(req, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Key == nil || input.Time != nil {
+		return 0, nil, newAPIError(http.StatusBadRequest, "invalid_request", "key is required and time cannot be set")
+	}
+
+	body, err := s.updateAPITot(req, func(tot *totModels.Tot) (totCore.Event, any, error) {
+		before := len(tot.Tallies)
+		if err := s.core.AddTally(tot, strconv.FormatInt(*input.Key, 10)); err != nil {
+			return totCore.Event{}, nil, newAPIError(http.StatusBadRequest, "invalid_key", "unknown tally key")
+		}
+		added := append([]totModels.Tally(nil), tot.Tallies[:len(tot.Tallies)-before]...)
+		event := totCore.Event{Type: totCore.EventTallyAdded, Tallies: added}
+		return event, totModels.APITallyList{Tallies: apiTallies(added)}, nil
+	})
+	return http.StatusCreated, body, err
+}
+
+func (s *Server) apiEditTallyHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	var input totModels.APITallyInput
+	if err := decodeJSON(req, &input); err != nil {
+		return 0, nil, err
+	}
+
+	body, err := s.updateAPITot(req, func(tot *totModels.Tot) (totCore.Event, any, error) {
+		i := totCore.TallyIndex(tot, req.PathValue("tally"))
+		if i < 0 {
+			return totCore.Event{}, nil, newAPIError(http.StatusNotFound, "tally_not_found", "tally not found")
+		}
+
+		current := apiTally(tot.Tallies[i])
+		at, key := current.Time, current.Key
+		if input.Time != nil {
+			at = *input.Time
+		}
+		if input.Key != nil {
+			key = *input.Key
+		}
+
+		edited, err := s.core.EditTally(tot, i, at, strconv.FormatInt(key, 10))
+		if err != nil {
+			return totCore.Event{}, nil, newAPIError(http.StatusBadRequest, "invalid_tally", strings.TrimPrefix(err.Error(), "core: "))
+		}
+		event := totCore.Event{Type: totCore.EventTallyEdited, Tallies: []totModels.Tally{edited}}
+		return event, apiTally(edited), nil
+	})
+	return http.StatusOK, body, err
+}
+
+// apiDeleteTallyHandler deletes a tally by ID, or undoes the most recent one for "latest".
+func (s *Server) apiDeleteTallyHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	body, err := s.updateAPITot(req, func(tot *totModels.Tot) (totCore.Event, any, error) {
+		i := 0
+		if id := req.PathValue("tally"); id != "latest" {
+			i = totCore.TallyIndex(tot, id)
+		}
+
+		removed, ok := s.core.RemoveTally(tot, i)
+		if !ok {
+			return totCore.Event{}, nil, newAPIError(http.StatusNotFound, "tally_not_found", "tally not found")
+		}
+		event := totCore.Event{Type: totCore.EventTallyUndone, Tallies: []totModels.Tally{removed}}
+		return event, apiTally(removed), nil
+	})
+	return http.StatusOK, body, err
+}
+
+func (s *Server) apiStatsHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	tot, err := s.loadAPITot(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	now := time.Now()
+	stats := totModels.APIStats{
+		Latest:      tot.Stats,
+		Summary:     tot.GeneratedStats,
+		Predictions: map[string]totModels.APIPrediction{},
+		Alerts:      []string{},
+	}
+	for _, category := range []totStats.Category{totStats.FeedCategory, totStats.DiaperCategory} {
+		var prediction totModels.APIPrediction
+		if p := s.stats.Predict(tot, tz, now, category); p.Valid {
+			next := p.Next.UTC()
+			prediction = totModels.APIPrediction{Next: &next, ExpectedGapMinutes: int(p.ExpectedGap.Minutes()), Unusual: p.Unusual}
+		}
+		stats.Predictions[category.Name] = prediction
+	}
+	for _, o := range totAlerts.Evaluate(tot, now) {
+		stats.Alerts = append(stats.Alerts, o.Message())
+	}
+	return http.StatusOK, stats, nil
+}
+
+// openAPIHandler serves the OpenAPI description of the API.
+func (s *Server) openAPIHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	return http.StatusOK, json.RawMessage(s.openAPI), nil
+}
+
+func (s *Server) apiNotFoundHandler(w http.ResponseWriter, req *http.Request) (int, any, error) {
+	return 0, nil, newAPIError(http.StatusNotFound, "not_found", "no such endpoint")
+}
+
+func apiTot(tot *totModels.Tot) totModels.APITot {
+	return totModels.APITot{
+		ID: tot.ID, Name: tot.Name, Timezone: tot.Timezone, MilkSetting: tot.MilkSetting,
+		DayStartsAt: tot.DayStartsAt, CreatedAt: tot.CreatedAt, UpdatedAt: tot.UpdatedAt,
+	}
+}
+
+func apiTally(t totModels.Tally) totModels.APITally {
+	tally := totModels.APITally{Kind: t.Kind, Key: totCore.TallyKey(t.Kind)}
+	if t.Time != nil {
+		tally.ID, tally.Time = totCore.TallyID(t), t.Time.UTC()
+	}
+	return tally
+}
+
+func apiTallies(tallies []totModels.Tally) []totModels.APITally {
+	out := make([]totModels.APITally, len(tallies))
+	for i, t := range tallies {
+		out[i] = apiTally(t)
+	}
+	return out
+}
@@ -0,0 +1,225 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+// apiRequest sends a request through the full mux and decodes the JSON response into out.
+func apiRequest(t *testing.T, mux http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent && rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("%s %s: expected JSON response, got %q", method, path, rr.Header().Get("Content-Type"))
+	}
+	if out != nil {
+		if err := json.Unmarshal(rr.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", method, path, rr.Body.String(), err)
+		}
+	}
+	return rr.Code
+}
+
+func TestAPI_Tot(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	var tot totModels.APITot
+	if code := apiRequest(t, mux, "GET", "/api/v1/tots/"+id, "", &tot); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if tot.ID != id || tot.Name != "👶" || tot.MilkSetting != "both" {
+		t.Errorf("unexpected tot: %+v", tot)
+	}
+
+	body := `{"timezone": "America/Chicago", "dayStartsAt": 6}`
+	if code := apiRequest(t, mux, "PATCH", "/api/v1/tots/"+id, body, &tot); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if tot.Timezone != "America/Chicago" || tot.DayStartsAt != 6 || tot.MilkSetting != "both" {
+		t.Errorf("unexpected updated tot: %+v", tot)
+	}
+
+	for body, code := range map[string]string{
+		`{"timezone": "Mars/Olympus"}`: "invalid_timezone",
+		`{"milkSetting": "formula"}`:   "invalid_milk_setting",
+		`{"dayStartsAt": 24}`:          "invalid_day_starts_at",
+		`{}`:                           "invalid_request",
+		`{"name": "🦖"}`:                "invalid_json",
+		`{`:                            "invalid_json",
+	} {
+		var apiErr totModels.APIError
+		if status := apiRequest(t, mux, "PATCH", "/api/v1/tots/"+id, body, &apiErr); status != http.StatusBadRequest || apiErr.Error.Code != code {
+			t.Errorf("%s: expected 400 %s, got %d %+v", body, code, status, apiErr)
+		}
+	}
+
+	saved, _ := s.store.LoadTot(id)
+	if saved.Timezone != "America/Chicago" || saved.DayStartsAt != 6 {
+		t.Errorf("expected only the valid update to be saved, got %s %d", saved.Timezone, saved.DayStartsAt)
+	}
+}
+
+func TestAPI_Tallies(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	listener := &recordingListener{}
+	s.core.AddListener(listener)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	base := "/api/v1/tots/" + id + "/tallies"
+
+	var added totModels.APITallyList
+	if code := apiRequest(t, mux, "POST", base, `{"key": 13}`, &added); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if len(added.Tallies) != 2 || added.Tallies[0].Kind != "🚽" || added.Tallies[1].Key != 12 {
+		t.Fatalf("unexpected added tallies: %+v", added)
+	}
+	apiRequest(t, mux, "POST", base, `{"key": 4}`, nil)
+
+	var list totModels.APITallyList
+	apiRequest(t, mux, "GET", base+"?limit=2", "", &list)
+	if len(list.Tallies) != 2 || list.Tallies[0].Kind != "🍼4" {
+		t.Errorf("unexpected tally list: %+v", list)
+	}
+
+	// Move the pee an hour earlier and change it to a poo.
+	earlier := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	var edited totModels.APITally
+	body := `{"key": 12, "time": "` + earlier.Format(time.RFC3339) + `"}`
+	if code := apiRequest(t, mux, "PATCH", base+"/"+added.Tallies[0].ID, body, &edited); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if edited.Kind != "💩" || !edited.Time.Equal(earlier) || edited.ID == added.Tallies[0].ID {
+		t.Errorf("unexpected edited tally: %+v", edited)
+	}
+
+	var removed totModels.APITally
+	if code := apiRequest(t, mux, "DELETE", base+"/latest", "", &removed); code != http.StatusOK || removed.Kind != "🍼4" {
+		t.Errorf("expected 🍼4 to be undone, got %d %+v", code, removed)
+	}
+	if code := apiRequest(t, mux, "DELETE", base+"/"+edited.ID, "", &removed); code != http.StatusOK || removed.ID != edited.ID {
+		t.Errorf("expected edited tally to be deleted, got %d %+v", code, removed)
+	}
+
+	saved, _ := s.store.LoadTot(id)
+	if len(saved.Tallies) != 1 || saved.Tallies[0].Kind != "💩" {
+		t.Errorf("unexpected saved tallies: %+v", saved.Tallies)
+	}
+
+	var types []string
+	for _, e := range listener.events {
+		types = append(types, string(e.Type))
+	}
+	if strings.Join(types, " ") != "tally.added tally.added tally.edited tally.undone tally.undone" {
+		t.Errorf("unexpected events: %v", types)
+	}
+}
+
+func TestAPI_TallyErrors(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	base := "/api/v1/tots/" + id + "/tallies"
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", base, `{"key": 99}`, http.StatusBadRequest, "invalid_key"},
+		{"POST", base, `{}`, http.StatusBadRequest, "invalid_request"},
+		{"POST", base, `{"key": 1, "time": "2023-10-27T12:00:00Z"}`, http.StatusBadRequest, "invalid_request"},
+		{"POST", base, `{"key": 1, "note": "` + strings.Repeat("x", 3000) + `"}`, http.StatusRequestEntityTooLarge, "too_large"},
+		{"GET", base + "?limit=0", "", http.StatusBadRequest, "invalid_limit"},
+		{"PATCH", base + "/123-1", `{"key": 2}`, http.StatusNotFound, "tally_not_found"},
+		{"DELETE", base + "/latest", "", http.StatusNotFound, "tally_not_found"},
+		{"GET", "/api/v1/tots/not-a-uuid", "", http.StatusNotFound, "not_found"},
+		{"GET", "/api/v1/tots/00000000-0000-0000-0000-000000000000/stats", "", http.StatusNotFound, "not_found"},
+		{"GET", "/api/v1/nothing", "", http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		var apiErr totModels.APIError
+		if status := apiRequest(t, mux, tt.method, tt.path, tt.body, &apiErr); status != tt.status || apiErr.Error.Code != tt.code {
+			t.Errorf("%s %s: expected %d %s, got %d %+v", tt.method, tt.path, tt.status, tt.code, status, apiErr)
+		}
+	}
+
+	// Bodies must be JSON.
+	req := httptest.NewRequest("POST", base, strings.NewReader("key=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for form body, got %d", rr.Code)
+	}
+
+	// Edits are validated by core.
+	apiRequest(t, mux, "POST", base, `{"key": 1}`, nil)
+	saved, _ := s.store.LoadTot(id)
+	tally := apiTally(saved.Tallies[0])
+	var apiErr totModels.APIError
+	future := `{"time": "` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+	if status := apiRequest(t, mux, "PATCH", base+"/"+tally.ID, future, &apiErr); status != http.StatusBadRequest || apiErr.Error.Code != "invalid_tally" {
+		t.Errorf("expected 400 invalid_tally for future time, got %d %+v", status, apiErr)
+	}
+}
+
+func TestAPI_Stats(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	tot.Alerts.Thresholds = map[string]int{"feed": 1}
+	tot.CreatedAt = time.Now().Add(-time.Hour)
+	s.store.SaveTot(tot)
+	apiRequest(t, mux, "POST", "/api/v1/tots/"+id+"/tallies", `{"key": 11}`, nil)
+
+	var stats totModels.APIStats
+	if code := apiRequest(t, mux, "GET", "/api/v1/tots/"+id+"/stats", "", &stats); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if stats.Latest.LastPee == nil || stats.Summary.TodayPee != "1" {
+		t.Errorf("unexpected stats: %+v %+v", stats.Latest, stats.Summary)
+	}
+	if p, ok := stats.Predictions["feed"]; !ok || p.Next != nil {
+		t.Errorf("expected an empty feed prediction, got %+v", stats.Predictions)
+	}
+	if len(stats.Alerts) != 1 || !strings.HasPrefix(stats.Alerts[0], "Feed overdue") {
+		t.Errorf("expected a feed alert, got %v", stats.Alerts)
+	}
+}
+
+func TestAPIWrapper_Errors(t *testing.T) {
+	handler := apiWrapper(func(w http.ResponseWriter, r *http.Request) (int, any, error) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		return 0, nil, http.ErrHandlerTimeout
+	})
+
+	for _, path := range []string{"/panic", "/error"} {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", path, nil))
+		var apiErr totModels.APIError
+		json.Unmarshal(rr.Body.Bytes(), &apiErr)
+		if rr.Code != http.StatusInternalServerError || apiErr.Error.Code != "internal" || strings.Contains(rr.Body.String(), "timeout") {
+			t.Errorf("%s: expected a generic 500, got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
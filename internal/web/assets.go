@@ -0,0 +1,114 @@
+// assets.go loads the templates and static files, embedded in the binary or from AssetsDir, and
+// serves static files under content-hashed URLs so browsers can cache them for good.
+package web
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	totAssets "tot-tally/assets"
+	totConfig "tot-tally/internal/config"
+)
+
+// staticHashLength is how many hex digits of a file's SHA-256 go in its URL.
+const staticHashLength = 10
+
+// assetsFS returns AssetsDir when set, so templates and styles can be edited without rebuilding,
+// and the embedded assets otherwise.
+func assetsFS(cfg *totConfig.Config) fs.FS {
+	if cfg.AssetsDir != "" {
+		return os.DirFS(cfg.AssetsDir)
+	}
+	return totAssets.FS
+}
+
+// parseTemplate parses one page template with the functions every page may use.
+func parseTemplate(fsys fs.FS, static *staticFiles, name string) *template.Template {
+	funcs := template.FuncMap{"static": static.URL}
+	return template.Must(template.New(name).Funcs(funcs).ParseFS(fsys, name))
+}
+
+// staticFiles serves the files under static/ and knows the hash of each one's content.
+type staticFiles struct {
+	fsys   fs.FS
+	hashes map[string]string // File name, e.g. "style.css", to its content hash.
+}
+
+// newStaticFiles hashes every file under static/ in fsys. Files added or changed in AssetsDir
+// afterwards are still served, just without long caching until a restart.
+func newStaticFiles(fsys fs.FS) (*staticFiles, error) {
+	sub, err := fs.Sub(fsys, "static")
+	if err != nil {
+		return nil, fmt.Errorf("web: static files: %w", err)
+	}
+	s := &staticFiles{fsys: sub, hashes: map[string]string{}}
+	err = fs.WalkDir(sub, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(sub, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		s.hashes[name] = hex.EncodeToString(sum[:])[:staticHashLength]
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("web: static files: %w", err)
+	}
+	return s, nil
+}
+
+// URL returns the content-hashed URL of a static file, e.g. "/static/style.3f9a1c0b2d.css".
+// Unknown files keep their plain URL.
+func (s *staticFiles) URL(name string) string {
+	hash, ok := s.hashes[name]
+	if !ok {
+		return "/static/" + name
+	}
+	ext := path.Ext(name)
+	return "/static/" + strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// resolve returns the file a requested name refers to, and whether the name carries its current
+// hash. An outdated hash still finds the file, e.g. for a page cached before a deploy.
+func (s *staticFiles) resolve(name string) (string, bool) {
+	if _, ok := s.hashes[name]; ok {
+		return name, false
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(base, '.')
+	if i < 0 {
+		return name, false
+	}
+	file := base[:i] + ext
+	hash, ok := s.hashes[file]
+	if !ok {
+		return name, false
+	}
+	return file, base[i+1:] == hash
+}
+
+// ServeHTTP serves /static/{file...} and root files such as /favicon.ico. Hashed URLs change with
+// the content, so they are cached for a year; plain ones are revalidated on each use.
+func (s *staticFiles) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	file, current := s.resolve(cmp.Or(req.PathValue("file"), strings.TrimPrefix(req.URL.Path, "/")))
+	if current {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if hash, ok := s.hashes[file]; ok {
+		w.Header().Set("ETag", `"`+hash+`"`)
+	}
+	http.ServeFileFS(w, req, s.fsys, file)
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFiles(t *testing.T) {
+	static, err := newStaticFiles(fstest.MapFS{
+		"static/style.css":   {Data: []byte("body {}")},
+		"static/favicon.ico": {Data: []byte("icon")},
+	})
+	if err != nil {
+		t.Fatalf("newStaticFiles failed: %v", err)
+	}
+	url := static.URL("style.css")
+	if !regexp.MustCompile(`^/static/style\.[0-9a-f]{10}\.css$`).MatchString(url) {
+		t.Fatalf("unexpected static URL %q", url)
+	}
+	if got := static.URL("missing.js"); got != "/static/missing.js" {
+		t.Errorf("expected unknown files to keep their URL, got %q", got)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /favicon.ico", static)
+	mux.Handle("GET /static/{file...}", static)
+	tests := []struct {
+		path  string
+		code  int
+		cache string
+		body  string
+	}{
+		{url, http.StatusOK, "public, max-age=31536000, immutable", "body {}"},
+		{"/static/style.0123456789.css", http.StatusOK, "no-cache", "body {}"},
+		{"/static/style.css", http.StatusOK, "no-cache", "body {}"},
+		{"/favicon.ico", http.StatusOK, "no-cache", "icon"},
+		{"/static/missing.css", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+		if rr.Code != tt.code || rr.Header().Get("Cache-Control") != tt.cache {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, rr.Code, rr.Header().Get("Cache-Control"), tt.code, tt.cache)
+		}
+		if tt.body != "" && rr.Body.String() != tt.body {
+			t.Errorf("%s: unexpected body %q", tt.path, rr.Body.String())
+		}
+	}
+
+	// Revalidation is answered from the hash.
+	req := httptest.NewRequest("GET", "/static/style.css", nil)
+	req.Header.Set("If-None-Match", `"`+strings.Split(url, ".")[1]+`"`)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rr.Code)
+	}
+}
+
+func TestNewServer_Assets(t *testing.T) {
+	s := setupServer(t)
+	rr := httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rr.Body.String(), `href="`+s.static.URL("style.css")+`"`) {
+		t.Error("expected the page to link the hashed stylesheet")
+	}
+
+	// AssetsDir replaces the embedded copies.
+	dir := t.TempDir()
+	for _, name := range []string{"index.html", "tot.html", "report.html", "quicklog.html", "mail.html", "summary.html", "openapi.json", "static/style.css"} {
+		data, err := os.ReadFile(filepath.Join("../../assets", name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if name == "static/style.css" {
+			data = []byte("/* edited */")
+		}
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+	s.config.AssetsDir = dir
+	s = NewServer(s.config, s.core, s.store, s.stats, s.shards)
+	rr = httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", s.static.URL("style.css"), nil))
+	if rr.Body.String() != "/* edited */" {
+		t.Errorf("expected the stylesheet from AssetsDir, got %q", rr.Body.String())
+	}
+}
@@ -1,4 +1,5 @@
-// cleaner.go includes a background maintenance task for deleting old data files.
+// cleaner.go includes a background maintenance task for deleting old data files and quarantining
+// damaged ones.
 package web
 
 import (
@@ -10,6 +11,9 @@ import (
 	"strings"
 	"time"
 	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totShards "tot-tally/internal/shards"
 	totStorage "tot-tally/internal/storage"
 )
 
@@ -17,11 +21,13 @@ import (
 type Cleaner struct {
 	config *totConfig.Config
 	store  *totStorage.Repository
+	pool   *totShards.Pool
+	core   *totCore.Service
 }
 
 // NewCleaner initializes the maintenance service.
-func NewCleaner(cfg *totConfig.Config, store *totStorage.Repository) *Cleaner {
-	return &Cleaner{config: cfg, store: store}
+func NewCleaner(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool, c *totCore.Service) *Cleaner {
+	return &Cleaner{config: cfg, store: store, pool: pool, core: c}
 }
 
 // StartBackgroundCleaner initiates a daily goroutine that prunes old data.
@@ -32,8 +38,7 @@ func (c *Cleaner) StartBackgroundCleaner(ctx context.Context) {
 
 		for {
 			slog.Info("background cleanup starting")
-			c.cleanFolder(c.config.TotDirectory, c.config.CleanupAge, true)
-			c.cleanFolder(c.config.LimitDirectory, c.config.CleanupAge, false)
+			c.Run(false)
 
 			select {
 			case <-ticker.C:
@@ -46,16 +51,107 @@ func (c *Cleaner) StartBackgroundCleaner(ctx context.Context) {
 	}()
 }
 
-func (c *Cleaner) cleanFolder(dir string, maxAge time.Duration, isTot bool) {
+// Removal is a file cleanup deletes, when expired, or moves to QuarantineDirectory, when damaged.
+type Removal struct {
+	Path       string
+	Reason     string // "expired", "unreadable" or "malformed".
+	Quarantine string // Where a damaged file was moved; empty when deleted or in a dry run.
+}
+
+// Run prunes expired tots and IP limits once, quarantines damaged ones, and returns what was
+// done. A dry run changes nothing and returns what would have been done.
+func (c *Cleaner) Run(dryRun bool) []Removal {
+	if dryRun {
+		removals := c.scanFolder(c.config.TotDirectory, c.config.CleanupAge, true)
+		return append(removals, c.scanFolder(c.config.LimitDirectory, c.config.CleanupAge, false)...)
+	}
+
+	removals := c.cleanFolder(c.config.TotDirectory, c.config.CleanupAge, true)
+	removals = append(removals, c.cleanFolder(c.config.LimitDirectory, c.config.CleanupAge, false)...)
+	quarantined := 0
+	for _, r := range removals {
+		if r.Quarantine != "" {
+			quarantined++
+		}
+	}
+	held, _ := c.store.ListQuarantined()
+	slog.Info("cleanup finished", "expired", len(removals)-quarantined, "quarantined", quarantined, "held", len(held))
+	return removals
+}
+
+// cleanFolder deletes expired files and quarantines damaged ones. A damaged file that can't be
+// quarantined is left where it is, and out of the result, rather than deleted.
+func (c *Cleaner) cleanFolder(dir string, maxAge time.Duration, isTot bool) []Removal {
+	var done []Removal
+	for _, r := range c.scanFolder(dir, maxAge, isTot) {
+		if r.Reason == "expired" {
+			removed, err := c.removeExpired(r.Path, maxAge, isTot)
+			switch {
+			case err != nil:
+				slog.Error("cleanup failed to remove expired file", "path", r.Path, "err", err)
+			case removed:
+				slog.Info("cleanup removed expired file", "path", r.Path)
+				done = append(done, r)
+			}
+			continue
+		}
+		dest, err := c.store.QuarantineFile(r.Path, r.Reason)
+		if err != nil {
+			slog.Error("cleanup failed to quarantine "+r.Reason+" file", "path", r.Path, "err", err)
+			continue
+		}
+		r.Quarantine = dest
+		slog.Warn("cleanup quarantined "+r.Reason+" file", "path", r.Path, "quarantine", dest)
+		done = append(done, r)
+	}
+	return done
+}
+
+// removeExpired deletes an expired file and reports whether it did. Tots are deleted through core,
+// so their quick-log, feed and mail links go with them. Expiry is checked again under the tot's
+// lock, and a tot used since the scan is kept.
+func (c *Cleaner) removeExpired(path string, maxAge time.Duration, isTot bool) (bool, error) {
+	if !isTot {
+		return true, os.Remove(path)
+	}
+	id := strings.TrimSuffix(filepath.Base(path), ".json")
+	mut := c.pool.GetShardMutex(id)
+	mut.Lock()
+	defer mut.Unlock()
+
+	tot, err := c.store.LoadTot(id)
+	if err != nil {
+		return false, err
+	}
+	if !expired(tot, time.Now(), maxAge) {
+		return false, nil
+	}
+	return true, c.core.DeleteTot(tot)
+}
+
+// expired reports whether a tot has gone maxAge without activity, counting from its creation if
+// it was never updated.
+func expired(tot *totModels.Tot, now time.Time, maxAge time.Duration) bool {
+	lastActive := tot.UpdatedAt
+	if lastActive.IsZero() {
+		lastActive = tot.CreatedAt
+	}
+	return now.Sub(lastActive) > maxAge
+}
+
+// scanFolder lists the files in dir that cleanup removes. Leftover .tmp files may be writes in
+// progress, so they are left to the integrity checker.
+func (c *Cleaner) scanFolder(dir string, maxAge time.Duration, isTot bool) []Removal {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		slog.Error("cleanup directory read failed", "dir", dir, "err", err)
-		return
+		return nil
 	}
 
+	var removals []Removal
 	now := time.Now()
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
 			continue
 		}
 		path := filepath.Join(dir, entry.Name())
@@ -63,34 +159,30 @@ func (c *Cleaner) cleanFolder(dir string, maxAge time.Duration, isTot bool) {
 		if isTot {
 			tot, err := c.store.LoadTot(strings.TrimSuffix(entry.Name(), ".json"))
 			if err != nil {
-				slog.Warn("cleanup removing unreadable tot", "path", path)
-				os.Remove(path)
+				removals = append(removals, Removal{Path: path, Reason: "unreadable"})
 				continue
 			}
-			lastActive := tot.UpdatedAt
-			if lastActive.IsZero() {
-				lastActive = tot.CreatedAt
-			}
-			if now.Sub(lastActive) > maxAge {
-				slog.Info("cleanup removing expired tot", "id", tot.ID)
-				os.Remove(path)
+			if expired(tot, now, maxAge) {
+				removals = append(removals, Removal{Path: path, Reason: "expired"})
 			}
 		} else {
 			data, err := os.ReadFile(path)
 			if err != nil {
-				os.Remove(path)
+				removals = append(removals, Removal{Path: path, Reason: "unreadable"})
 				continue
 			}
 			lines := strings.Split(string(data), "\n")
-			if len(lines) >= 2 {
-				ts, err := strconv.ParseInt(lines[1], 10, 64)
-				if err != nil || now.Sub(time.UnixMilli(ts)) > maxAge {
-					slog.Info("cleanup removing expired limit", "file", entry.Name())
-					os.Remove(path)
-				}
-			} else {
-				os.Remove(path)
+			if len(lines) < 2 {
+				removals = append(removals, Removal{Path: path, Reason: "malformed"})
+				continue
+			}
+			ts, err := strconv.ParseInt(lines[1], 10, 64)
+			if err != nil {
+				removals = append(removals, Removal{Path: path, Reason: "malformed"})
+			} else if now.Sub(time.UnixMilli(ts)) > maxAge {
+				removals = append(removals, Removal{Path: path, Reason: "expired"})
 			}
 		}
 	}
+	return removals
 }
@@ -9,11 +9,18 @@ import (
 	"testing"
 	"time"
 	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
 	totModels "tot-tally/internal/models"
 	totShards "tot-tally/internal/shards"
+	totStats "tot-tally/internal/stats"
 	totStorage "tot-tally/internal/storage"
 )
 
+// newTestCleaner wires a Cleaner to store as web.go does.
+func newTestCleaner(cfg *totConfig.Config, store *totStorage.Repository) *Cleaner {
+	return NewCleaner(cfg, store, totShards.NewPool(1), totCore.NewService(cfg, store, totStats.NewEngine(cfg)))
+}
+
 func TestCleaner_CleanFolder(t *testing.T) {
 	tmpDir := t.TempDir()
 	totDir := filepath.Join(tmpDir, "tots")
@@ -24,20 +31,31 @@ func TestCleaner_CleanFolder(t *testing.T) {
 	cfg := &totConfig.Config{
 		TotDirectory:   totDir,
 		LimitDirectory: limitDir,
+		LinkDirectory:  filepath.Join(tmpDir, "links"),
 		CleanupAge:     24 * time.Hour,
 	}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	// Create an old tot (manually to avoid UpdatedAt update in SaveTot)
 	oldTot := &totModels.Tot{
 		ID:        "old-tot",
 		CreatedAt: time.Now().Add(-48 * time.Hour),
 		UpdatedAt: time.Now().Add(-48 * time.Hour),
+		QuickLog:  totModels.QuickLogSettings{Ref: "old-ref", Secret: "secret"},
+		ReadToken: "old-token",
+		Mail:      totModels.MailSettings{Ref: "old-mail", Secret: "secret"},
 	}
 	f, _ := os.Create(filepath.Join(totDir, "old-tot.json"))
 	json.NewEncoder(f).Encode(oldTot)
 	f.Close()
+	links := []string{"old-ref", "old-token", "old-mail"}
+	_ = os.Mkdir(cfg.LinkDirectory, 0755)
+	for _, ref := range links {
+		if err := store.SaveLink(ref, "old-tot"); err != nil {
+			t.Fatalf("SaveLink failed: %v", err)
+		}
+	}
 
 	// Create a new tot
 	newTot := &totModels.Tot{
@@ -61,9 +79,18 @@ func TestCleaner_CleanFolder(t *testing.T) {
 	if _, err := os.Stat(filepath.Join(totDir, "old-tot.json")); !os.IsNotExist(err) {
 		t.Error("old tot should have been deleted")
 	}
+	for _, ref := range links {
+		if _, err := store.LoadLink(ref); err == nil {
+			t.Errorf("link %s of the old tot should have been deleted", ref)
+		}
+	}
 	if _, err := os.Stat(filepath.Join(totDir, "new-tot.json")); os.IsNotExist(err) {
 		t.Error("new tot should NOT have been deleted")
 	}
+	// A tot used between the scan and its removal is kept.
+	if removed, err := cleaner.removeExpired(filepath.Join(totDir, "new-tot.json"), cfg.CleanupAge, true); removed || err != nil {
+		t.Errorf("expected an active tot to be kept, got %v %v", removed, err)
+	}
 	if _, err := os.Stat(oldLimitPath); !os.IsNotExist(err) {
 		t.Error("old limit should have been deleted")
 	}
@@ -74,25 +101,55 @@ func TestCleaner_CleanFolder(t *testing.T) {
 
 func TestCleaner_CleanFolder_UnreadableTot(t *testing.T) {
 	tmpDir := t.TempDir()
-	cfg := &totConfig.Config{TotDirectory: tmpDir, CleanupAge: 24 * time.Hour}
+	cfg := &totConfig.Config{TotDirectory: tmpDir, QuarantineDirectory: filepath.Join(tmpDir, "quarantine"), CleanupAge: 24 * time.Hour}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	path := filepath.Join(tmpDir, "unreadable.json")
 	_ = os.WriteFile(path, []byte("invalid json"), 0644)
+	// A write in progress is not mistaken for a damaged tot.
+	_ = os.WriteFile(filepath.Join(tmpDir, "busy.json.tmp"), []byte("{"), 0644)
 
-	cleaner.cleanFolder(tmpDir, cfg.CleanupAge, true)
+	removals := cleaner.cleanFolder(tmpDir, cfg.CleanupAge, true)
 
 	if _, err := os.Stat(path); !os.IsNotExist(err) {
-		t.Error("unreadable tot should have been deleted")
+		t.Error("unreadable tot should have been moved")
+	}
+	if len(removals) != 1 || removals[0].Reason != "unreadable" {
+		t.Fatalf("expected one unreadable tot, got %v", removals)
+	}
+	if data, err := os.ReadFile(removals[0].Quarantine); err != nil || string(data) != "invalid json" {
+		t.Errorf("expected the tot kept in quarantine, got %q (%v)", data, err)
+	}
+	held, _ := store.ListQuarantined()
+	if len(held) != 1 || held[0].Path != path || held[0].Reason != "unreadable" {
+		t.Errorf("expected a note of where the tot came from, got %+v", held)
+	}
+}
+
+func TestCleaner_CleanFolder_QuarantineFails(t *testing.T) {
+	tmpDir := t.TempDir()
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	_ = os.WriteFile(blocked, nil, 0644)
+	cfg := &totConfig.Config{TotDirectory: tmpDir, QuarantineDirectory: filepath.Join(blocked, "quarantine"), CleanupAge: 24 * time.Hour}
+	cleaner := newTestCleaner(cfg, totStorage.NewRepository(cfg, totShards.NewPool(1)))
+
+	path := filepath.Join(tmpDir, "unreadable.json")
+	_ = os.WriteFile(path, []byte("invalid json"), 0644)
+
+	if removals := cleaner.cleanFolder(tmpDir, cfg.CleanupAge, true); len(removals) != 0 {
+		t.Errorf("expected nothing done, got %v", removals)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected a tot that can't be quarantined to be left alone: %v", err)
 	}
 }
 
 func TestCleaner_CleanFolder_MalformedLimit(t *testing.T) {
 	tmpDir := t.TempDir()
-	cfg := &totConfig.Config{LimitDirectory: tmpDir, CleanupAge: 24 * time.Hour}
+	cfg := &totConfig.Config{LimitDirectory: tmpDir, QuarantineDirectory: t.TempDir(), CleanupAge: 24 * time.Hour}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	// Short file (only 1 line)
 	path1 := filepath.Join(tmpDir, "short")
@@ -105,17 +162,20 @@ func TestCleaner_CleanFolder_MalformedLimit(t *testing.T) {
 	cleaner.cleanFolder(tmpDir, cfg.CleanupAge, false)
 
 	if _, err := os.Stat(path1); !os.IsNotExist(err) {
-		t.Error("short limit should have been deleted")
+		t.Error("short limit should have been moved")
 	}
 	if _, err := os.Stat(path2); !os.IsNotExist(err) {
-		t.Error("bad timestamp limit should have been deleted")
+		t.Error("bad timestamp limit should have been moved")
+	}
+	if held, _ := store.ListQuarantined(); len(held) != 2 || held[0].Reason != "malformed" {
+		t.Errorf("expected both limits quarantined, got %+v", held)
 	}
 }
 
 func TestCleaner_CleanFolder_ReadDirError(t *testing.T) {
 	cfg := &totConfig.Config{TotDirectory: "/nonexistent", CleanupAge: 24 * time.Hour}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	// This should just return without panicking and log an error
 	cleaner.cleanFolder("/nonexistent", cfg.CleanupAge, true)
@@ -129,7 +189,7 @@ func TestCleaner_StartBackgroundCleaner(t *testing.T) {
 		CleanupAge:     24 * time.Hour,
 	}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Stop it immediately
@@ -143,7 +203,7 @@ func TestCleaner_CleanFolder_LastActiveZero(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &totConfig.Config{TotDirectory: tmpDir, CleanupAge: 24 * time.Hour}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	// Tot with no UpdatedAt, and CreatedAt is old
 	tot := &totModels.Tot{
@@ -166,7 +226,7 @@ func TestCleaner_CleanFolder_Empty(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &totConfig.Config{TotDirectory: tmpDir}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	cleaner.cleanFolder(tmpDir, 24*time.Hour, true)
 }
@@ -175,7 +235,7 @@ func TestCleaner_CleanFolder_WithDir(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &totConfig.Config{TotDirectory: tmpDir}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	_ = os.Mkdir(filepath.Join(tmpDir, "subdir"), 0755)
 
@@ -184,12 +244,46 @@ func TestCleaner_CleanFolder_WithDir(t *testing.T) {
 
 func TestCleaner_CleanFolder_LimitReadError(t *testing.T) {
 	tmpDir := t.TempDir()
-	cfg := &totConfig.Config{LimitDirectory: tmpDir, CleanupAge: 24 * time.Hour}
+	cfg := &totConfig.Config{LimitDirectory: tmpDir, QuarantineDirectory: t.TempDir(), CleanupAge: 24 * time.Hour}
 	store := totStorage.NewRepository(cfg, totShards.NewPool(1))
-	cleaner := NewCleaner(cfg, store)
+	cleaner := newTestCleaner(cfg, store)
 
 	path := filepath.Join(tmpDir, "noread")
 	_ = os.WriteFile(path, []byte(""), 0000)
 
 	cleaner.cleanFolder(tmpDir, cfg.CleanupAge, false)
 }
+
+func TestCleaner_Run_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &totConfig.Config{TotDirectory: tmpDir, LimitDirectory: tmpDir + "/limits", QuarantineDirectory: tmpDir + "/quarantine", CleanupAge: 24 * time.Hour}
+	_ = os.Mkdir(cfg.LimitDirectory, 0755)
+	cleaner := newTestCleaner(cfg, totStorage.NewRepository(cfg, totShards.NewPool(1)))
+
+	old := time.Now().Add(-48 * time.Hour)
+	f, _ := os.Create(filepath.Join(tmpDir, "old-tot.json"))
+	json.NewEncoder(f).Encode(&totModels.Tot{ID: "old-tot", CreatedAt: old, UpdatedAt: old})
+	f.Close()
+	_ = os.WriteFile(filepath.Join(cfg.LimitDirectory, "bad-limit"), []byte("1"), 0644)
+
+	want := []Removal{{Path: filepath.Join(tmpDir, "old-tot.json"), Reason: "expired"}, {Path: filepath.Join(cfg.LimitDirectory, "bad-limit"), Reason: "malformed"}}
+	removals := cleaner.Run(true)
+	if len(removals) != 2 || removals[0] != want[0] || removals[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, removals)
+	}
+	for _, r := range removals {
+		if _, err := os.Stat(r.Path); err != nil {
+			t.Errorf("expected a dry run to keep %s", r.Path)
+		}
+	}
+
+	removals = cleaner.Run(false)
+	if len(removals) != 2 || removals[0].Quarantine != "" || removals[1].Quarantine == "" {
+		t.Fatalf("expected the expired tot deleted and the bad limit quarantined, got %v", removals)
+	}
+	for _, r := range want {
+		if _, err := os.Stat(r.Path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", r.Path)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// feeds.go serves read-only subscription feeds (iCalendar and Atom). Feeds are addressed by the tot's read token
+// rather than its ID, so a calendar app never holds the URL that can change the tot.
+package web
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	totAtom "tot-tally/internal/atom"
+	totCore "tot-tally/internal/core"
+	totIcal "tot-tally/internal/ical"
+	totModels "tot-tally/internal/models"
+)
+
+var errFeedNotFound = errors.New("feed does not exist")
+
+// loadReadOnlyTot returns the tot a read token belongs to.
+func (s *Server) loadReadOnlyTot(token string) (*totModels.Tot, error) {
+	totID, err := s.store.LoadLink(token)
+	if err != nil {
+		if err.Error() == "link does not exist" {
+			return nil, errFeedNotFound
+		}
+		return nil, err
+	}
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		if err.Error() == "tot does not exist" {
+			return nil, errFeedNotFound
+		}
+		return nil, err
+	}
+	// Quick-log references share the link index but must not grant read access.
+	if tot.ReadToken != token {
+		return nil, errFeedNotFound
+	}
+	return tot, nil
+}
+
+// calendarHandler serves an iCalendar feed of recent tallies, e.g. /{token}/calendar.ics.
+func (s *Server) calendarHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	tot, err := s.loadReadOnlyTot(req.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, errFeedNotFound) {
+			http.NotFound(w, req)
+			return "", nil
+		}
+		return "", err
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	now := time.Now()
+	cal := totIcal.Calendar{Name: "Tot-Tally " + tot.Name, Location: tz, Events: s.calendarEvents(tot, now)}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tot-tally.ics"`)
+	return "", cal.Encode(w, now)
+}
+
+// calendarEvents turns recent tallies into events. Nursing tallies within NurseSessionGap of each
+// other are merged into one session spanning the first to the last, e.g. left then right side.
+func (s *Server) calendarEvents(tot *totModels.Tot, now time.Time) []totIcal.Event {
+	since := now.AddDate(0, 0, -s.config.CalendarDays)
+	var events []totIcal.Event
+	session := -1 // Index of the open nursing session.
+
+	// Tallies are stored newest first; sessions are easier to build oldest first.
+	for _, t := range slices.Backward(tot.Tallies) {
+		if t.Time == nil || t.Time.Before(since) {
+			continue
+		}
+		if !strings.HasPrefix(t.Kind, "🤱") {
+			events = append(events, totIcal.Event{UID: totCore.TallyID(t) + "@tot-tally", Start: *t.Time, Summary: tot.Name + " " + t.Kind})
+			continue
+		}
+		if session >= 0 && t.Time.Sub(events[session].End) <= s.config.NurseSessionGap {
+			events[session].End = *t.Time
+			events[session].Summary += " " + t.Kind
+			continue
+		}
+		events = append(events, totIcal.Event{UID: totCore.TallyID(t) + "@tot-tally", Start: *t.Time, End: *t.Time, Summary: tot.Name + " " + t.Kind})
+		session = len(events) - 1
+	}
+	return events
+}
+
+// atomHandler serves an Atom feed of the most recent tallies, e.g. /{token}/feed.atom.
+// Each entry carries its day's running totals, so readers can follow along without the dashboard.
+func (s *Server) atomHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	tot, err := s.loadReadOnlyTot(req.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, errFeedNotFound) {
+			http.NotFound(w, req)
+			return "", nil
+		}
+		return "", err
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	// IDs are derived from a hash of the tot ID, so they survive token rotation without revealing it.
+	sum := sha256.Sum256([]byte(tot.ID))
+	idPrefix := fmt.Sprintf("urn:tot-tally:%x", sum[:8])
+	feed := totAtom.Feed{
+		ID:     idPrefix,
+		Title:  "Tot-Tally " + tot.Name,
+		Self:   requestBaseURL(req) + req.URL.Path,
+		Author: "Tot-Tally",
+	}
+
+	var tallies []totModels.Tally
+	for _, t := range tot.Tallies {
+		if t.Time != nil {
+			tallies = append(tallies, t)
+		}
+	}
+	if len(tallies) > 0 {
+		since := *tallies[min(s.config.FeedEntries, len(tallies))-1].Time
+		categories := reportCategories(tot.MilkSetting)
+		display := totDisplay(tot)
+		totals, err := s.stats.RunningTotals(tot, tz, since, categories)
+		if err != nil {
+			return "", err
+		}
+		for _, total := range slices.Backward(totals) {
+			var parts []string
+			for c, category := range categories {
+				if v := total.Values[c]; v > 0 {
+					parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %d %s", category.Emoji, v, category.Unit)))
+				}
+			}
+			at := total.Tally.Time.In(tz)
+			feed.Entries = append(feed.Entries, totAtom.Entry{
+				ID:      idPrefix + ":" + totCore.TallyID(total.Tally),
+				Title:   fmt.Sprintf("%s %s at %s", tot.Name, total.Tally.Kind, display.Format(at, display.Layout(s.config.TimeFormat))),
+				Updated: at,
+				Summary: fmt.Sprintf("%s so far: %s", display.Format(total.DayStart, display.DateLayout(true, false)), strings.Join(parts, " · ")),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	return "", feed.Encode(w, tot.CreatedAt.In(tz))
+}
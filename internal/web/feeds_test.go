@@ -0,0 +1,144 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	id, _ := s.core.CreateTot("👶", "America/Chicago", "both")
+
+	post := func(form url.Values) {
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		if _, err := s.updateTotHandler(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	if rr := get("/" + id + "/calendar.ics"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected the tot ID not to serve the feed, got %d", rr.Code)
+	}
+
+	post(url.Values{"rotate_read_token": {"true"}})
+	data, _ := s.getTotPageData(id, "", "")
+	if data.CalendarPath == "" || strings.Contains(data.CalendarPath, id) {
+		t.Fatalf("unexpected calendar path %q", data.CalendarPath)
+	}
+
+	tot, _ := s.store.LoadTot(id)
+	s.core.AddTally(tot, "11")
+	s.store.SaveTot(tot)
+
+	rr := get(data.CalendarPath)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/calendar; charset=utf-8" {
+		t.Fatalf("expected calendar, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "TZID:America/Chicago") || !strings.Contains(body, "SUMMARY:👶 🚽") {
+		t.Errorf("unexpected calendar:\n%s", body)
+	}
+
+	// Quick-log references resolve through the same index but are not read tokens.
+	post(url.Values{"add_quicklog": {"11"}})
+	tot, _ = s.store.LoadTot(id)
+	if rr := get("/" + tot.QuickLog.Ref + "/calendar.ics"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected quick-log reference to be rejected, got %d", rr.Code)
+	}
+
+	post(url.Values{"revoke_read_token": {"true"}})
+	if rr := get(data.CalendarPath); rr.Code != http.StatusNotFound {
+		t.Errorf("expected revoked feed to 404, got %d", rr.Code)
+	}
+}
+
+func TestCalendarEvents(t *testing.T) {
+	s := setupServer(t)
+	now := time.Date(2023, 10, 27, 12, 0, 0, 0, time.UTC)
+	at := func(minutesAgo int) *time.Time {
+		t := now.Add(-time.Duration(minutesAgo) * time.Minute)
+		return &t
+	}
+	tot := &totModels.Tot{Name: "👶", Tallies: []totModels.Tally{
+		{Time: at(10), Kind: "🤱L"},
+		{Time: at(100), Kind: "🤱R"},
+		{Time: at(110), Kind: "🚽"},
+		{Time: at(120), Kind: "🤱L"},
+		{Time: at(30 * 24 * 60), Kind: "💩"},
+	}}
+
+	events := s.calendarEvents(tot, now)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if e := events[0]; e.Summary != "👶 🤱L 🤱R" || !e.Start.Equal(*at(120)) || !e.End.Equal(*at(100)) {
+		t.Errorf("expected a 20 minute nursing session, got %+v", e)
+	}
+	if e := events[1]; e.Summary != "👶 🚽" || !e.End.IsZero() {
+		t.Errorf("expected a point event, got %+v", e)
+	}
+	if e := events[2]; e.Summary != "👶 🤱L" || !e.Start.Equal(e.End) {
+		t.Errorf("expected a separate nursing session after the gap, got %+v", e)
+	}
+}
+
+func TestAtomHandler(t *testing.T) {
+	s := setupServer(t)
+	s.config.FeedEntries = 2
+	id, _ := s.core.CreateTot("👶", "UTC", "bottle")
+	tot, _ := s.store.LoadTot(id)
+	s.core.RotateReadToken(tot)
+	at := func(hour int) *time.Time {
+		t := time.Now().UTC().Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour)
+		return &t
+	}
+	tot.Tallies = []totModels.Tally{
+		{Time: at(-20), Kind: "🍼4"},
+		{Time: at(-21), Kind: "🚽"},
+		{Time: at(-22), Kind: "🍼3"},
+	}
+	s.store.SaveTot(tot)
+
+	rr := httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", "http://tots.example.com/"+tot.ReadToken+"/feed.atom", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("expected Atom feed, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	if strings.Count(body, "<entry>") != 2 || strings.Contains(body, id) {
+		t.Errorf("expected 2 entries without the tot ID:\n%s", body)
+	}
+	// The oldest listed tally still counts the earlier bottle in its day's totals.
+	for _, want := range []string{
+		"so far: 🍼 7 oz · 🚽 1</summary>",
+		"so far: 🍼 3 oz · 🚽 1</summary>",
+		`<link rel="self" href="http://tots.example.com/` + tot.ReadToken + `/feed.atom">`,
+		"<updated>" + at(-20).Format(time.RFC3339) + "</updated>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in:\n%s", want, body)
+		}
+	}
+
+	first := body[strings.Index(body, "<entry>"):]
+	first = first[strings.Index(first, "<id>")+4 : strings.Index(first, "</id>")]
+	s.core.RotateReadToken(tot)
+	s.store.SaveTot(tot)
+	rr = httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", "/"+tot.ReadToken+"/feed.atom", nil))
+	if !strings.Contains(rr.Body.String(), "<id>"+first+"</id>") {
+		t.Errorf("expected entry IDs to be stable across token rotation, missing %s", first)
+	}
+}
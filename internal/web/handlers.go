@@ -2,61 +2,75 @@
 package web
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"maps"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	totAlerts "tot-tally/internal/alerts"
+	totCharts "tot-tally/internal/charts"
 	totConfig "tot-tally/internal/config"
 	totCore "tot-tally/internal/core"
+	totI18n "tot-tally/internal/i18n"
 	totModels "tot-tally/internal/models"
 	totShards "tot-tally/internal/shards"
 	totStats "tot-tally/internal/stats"
 	totStorage "tot-tally/internal/storage"
+	totWebhooks "tot-tally/internal/webhooks"
 )
 
 // Server handles all HTTP requests and routes.
 type Server struct {
-	config        *totConfig.Config
-	core          *totCore.Service
-	store         *totStorage.Repository
-	stats         *totStats.Engine
-	shards        *totShards.Pool
-	templateIndex *template.Template
-	templateTot   *template.Template
+	config           *totConfig.Config
+	core             *totCore.Service
+	store            *totStorage.Repository
+	stats            *totStats.Engine
+	shards           *totShards.Pool
+	templateIndex    *template.Template
+	templateTot      *template.Template
+	templateReport   *template.Template
+	templateQuickLog *template.Template
+	templateMail     *template.Template
+	templateSummary  *template.Template
+	static           *staticFiles
+	openAPI          []byte
 }
 
 // NewServer initializes the HTTP router with its dependencies.
 func NewServer(cfg *totConfig.Config, c *totCore.Service, s *totStorage.Repository, e *totStats.Engine, p *totShards.Pool) *Server {
-	// Try to find templates. In tests, they might be in a different relative path.
-	paths := []string{"assets/", "../../assets/", "../assets/"}
-	var indexPath, totPath string
-	for _, p := range paths {
-		if _, err := os.Stat(p + "index.html"); err == nil {
-			indexPath = p + "index.html"
-			totPath = p + "tot.html"
-			break
-		}
+	fsys := assetsFS(cfg)
+	static, err := newStaticFiles(fsys)
+	if err != nil {
+		panic(err.Error())
 	}
-
-	if indexPath == "" {
-		// Fallback to original paths if not found in common test locations
-		indexPath = "assets/index.html"
-		totPath = "assets/tot.html"
+	openAPI, err := fs.ReadFile(fsys, "openapi.json")
+	if err != nil {
+		panic(fmt.Sprintf("web: failed to read OpenAPI document: %v", err))
 	}
 
 	return &Server{
-		config:        cfg,
-		core:          c,
-		store:         s,
-		stats:         e,
-		shards:        p,
-		templateIndex: template.Must(template.ParseFiles(indexPath)),
-		templateTot:   template.Must(template.ParseFiles(totPath)),
+		config:           cfg,
+		core:             c,
+		store:            s,
+		stats:            e,
+		shards:           p,
+		templateIndex:    parseTemplate(fsys, static, "index.html"),
+		templateTot:      parseTemplate(fsys, static, "tot.html"),
+		templateReport:   parseTemplate(fsys, static, "report.html"),
+		templateQuickLog: parseTemplate(fsys, static, "quicklog.html"),
+		templateMail:     parseTemplate(fsys, static, "mail.html"),
+		templateSummary:  parseTemplate(fsys, static, "summary.html"),
+		static:           static,
+		openAPI:          openAPI,
 	}
 }
 
@@ -68,12 +82,15 @@ func (s *Server) homeHandler(w http.ResponseWriter, req *http.Request) (string,
 		http.SetCookie(w, &http.Cookie{Name: "flash_msg", Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
 	}
 
-	msg := totConfig.FlashMessages[flashKey]
+	locale := totI18n.Negotiate(req.Header.Get("Accept-Language"))
+	msg, isError := flashMessage(locale, flashKey)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
 	err := s.templateIndex.Execute(w, totModels.HomePageData{
+		Locale:       locale,
 		FlashMessage: msg,
-		IsErrorFlash: strings.HasPrefix(msg, "Error:"),
+		IsErrorFlash: isError,
+		Timezones:    timezoneGroups(suggestTimezone(req), time.Now()),
 	})
 	return "", err
 }
@@ -90,10 +107,11 @@ func (s *Server) getTotHandler(w http.ResponseWriter, req *http.Request) (string
 		http.SetCookie(w, &http.Cookie{Name: "flash_msg", Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
 	}
 
-	data, err := s.getTotPageData(totID, flashKey)
+	data, err := s.getTotPageData(totID, flashKey, req.Header.Get("Accept-Language"))
 	if err != nil {
 		return totID, err
 	}
+	data.BaseURL = requestBaseURL(req)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	return totID, s.templateTot.Execute(w, data)
@@ -150,7 +168,7 @@ func (s *Server) createTotHandler(w http.ResponseWriter, req *http.Request) (str
 	if _, okA := totConfig.AllowedAvatars[name]; !okA {
 		return "", errors.New("invalid avatar")
 	}
-	if _, okT := totConfig.AllowedTimezones[tz]; !okT {
+	if !totConfig.ValidTimezone(tz) {
 		return "", errors.New("invalid timezone")
 	}
 	if _, okM := totConfig.AllowedMilkSettings[ms]; !okM {
@@ -161,6 +179,7 @@ func (s *Server) createTotHandler(w http.ResponseWriter, req *http.Request) (str
 	if err != nil {
 		return "", err
 	}
+	http.SetCookie(w, &http.Cookie{Name: timezoneCookie, Value: tz, Path: "/", MaxAge: 365 * 24 * 60 * 60, HttpOnly: true, SameSite: http.SameSiteLaxMode})
 
 	http.Redirect(w, req, "/"+newID, http.StatusSeeOther)
 	return newID, nil
@@ -178,51 +197,182 @@ func (s *Server) updateTotHandler(w http.ResponseWriter, req *http.Request) (str
 	}
 
 	tzLoc, _ := time.LoadLocation(tot.Timezone)
-	changed, flashKey := false, ""
+	changed, flashKey, confirm := false, "", ""
+	event := totCore.Event{Type: totCore.EventSettingsUpdated}
 
 	if val := req.FormValue("tally"); val != "" {
+		before := len(tot.Tallies)
 		if err := s.core.AddTally(tot, val); err == nil {
+			event = totCore.Event{Type: totCore.EventTallyAdded, Tallies: slices.Clone(tot.Tallies[:len(tot.Tallies)-before])}
 			changed, flashKey = true, "tally"
 		}
 	} else if req.FormValue("delete_tot") != "" {
 		if req.FormValue("confirm_delete") == "true" {
-			filename := filepath.Join(s.config.TotDirectory, filepath.Base(totID)+".json")
-			if err := os.Remove(filename); err != nil {
-				return totID, fmt.Errorf("web: failed to delete tot file: %w", err)
+			if err := s.core.DeleteTot(tot); err != nil {
+				return totID, fmt.Errorf("web: failed to delete tot: %w", err)
 			}
 			http.SetCookie(w, &http.Cookie{Name: "flash_msg", Value: "deleted", Path: "/", MaxAge: 30, HttpOnly: true})
 			http.Redirect(w, req, "/", http.StatusSeeOther)
 			return "", nil
 		}
 	} else if req.FormValue("undo") != "" {
-		if len(tot.Tallies) > 0 {
-			tot.Tallies = tot.Tallies[1:]
-			s.stats.RecalculateStats(tot)
+		if removed, ok := s.core.UndoTally(tot); ok {
+			event = totCore.Event{Type: totCore.EventTallyUndone, Tallies: []totModels.Tally{removed}}
 			changed, flashKey = true, "undo"
 		}
 	} else if tz := req.FormValue("timezone"); tz != "" {
-		if _, ok := totConfig.AllowedTimezones[tz]; ok {
-			tot.Timezone = tz
+		previous := tot.Timezone
+		at, err := parseTimezoneSince(req.FormValue("timezone_since"), tz, time.Now())
+		if err == nil {
+			err = s.core.ChangeTimezone(tot, tz, at)
+		}
+		if err != nil {
+			flashKey = "error_timezone"
+		} else if tot.Timezone != previous {
 			tzLoc, _ = time.LoadLocation(tot.Timezone)
+			event.Setting = "timezone"
 			changed, flashKey = true, "updated"
 		}
 	} else if ms := req.FormValue("milk_setting"); ms != "" {
 		if _, ok := totConfig.AllowedMilkSettings[ms]; ok {
 			tot.MilkSetting = ms
+			event.Setting = "milk_setting"
 			changed, flashKey = true, "updated"
 		}
-	}
-
-	if changed {
-		generated, err := s.stats.GenerateStats(tot, tzLoc, time.Now())
+	} else if req.FormValue("update_alerts") != "" {
+		settings, err := s.parseAlertSettings(req, tot.Alerts)
 		if err != nil {
+			flashKey = "error_alerts"
+		} else {
+			pending := tot.Alerts.Pending
+			tot.Alerts = settings
+			event.Setting = "alerts"
+			changed, flashKey = true, "updated"
+			if settings.Pending != "" && settings.Pending != pending {
+				if err := s.core.RequestMailConfirmation(tot, totCore.MailAlerts, settings.Pending, requestBaseURL(req)); err != nil {
+					return totID, err
+				}
+				confirm, flashKey = totCore.MailAlerts, "confirm_email"
+			}
+		}
+	} else if req.FormValue("update_digest") != "" {
+		email := strings.TrimSpace(req.FormValue("digest_email"))
+		if email != "" && (s.config.SMTPAddr == "" || totAlerts.ValidateTarget("email", email, false) != nil) {
+			flashKey = "error_digest"
+		} else {
+			event.Setting = "digest"
+			changed, flashKey = true, "updated"
+			// The digest only goes to a confirmed address; a new one waits for its confirmation link.
+			switch email {
+			case "":
+				tot.Digest.Email, tot.Digest.Pending = "", ""
+			case tot.Digest.Email:
+				tot.Digest.Pending = ""
+			case tot.Digest.Pending:
+				flashKey = "confirm_email"
+			default:
+				if err := s.core.RequestMailConfirmation(tot, totCore.MailDigest, email, requestBaseURL(req)); err != nil {
+					return totID, err
+				}
+				confirm, flashKey = totCore.MailDigest, "confirm_email"
+			}
+		}
+	} else if req.FormValue("add_webhook") != "" {
+		webhook, err := totWebhooks.New(strings.TrimSpace(req.FormValue("webhook_url")), strings.TrimSpace(req.FormValue("webhook_secret")), s.config.AllowPrivateTargets)
+		if err != nil || len(tot.Webhooks) >= s.config.MaxWebhooks {
+			flashKey = "error_webhook"
+		} else {
+			tot.Webhooks = append(tot.Webhooks, webhook)
+			event.Setting = "webhooks"
+			changed, flashKey = true, "updated"
+		}
+	} else if id := req.FormValue("delete_webhook"); id != "" {
+		if i := slices.IndexFunc(tot.Webhooks, func(w totModels.Webhook) bool { return w.ID == id }); i >= 0 {
+			tot.Webhooks = slices.Delete(tot.Webhooks, i, i+1)
+			event.Setting = "webhooks"
+			changed, flashKey = true, "updated"
+		}
+	} else if val := req.FormValue("add_quicklog"); val != "" {
+		if key, err := strconv.ParseInt(val, 10, 64); err == nil {
+			if err := s.core.CreateQuickLog(tot, key); err != nil {
+				return totID, err
+			}
+			event.Setting = "quick_log"
+			changed, flashKey = true, "updated"
+		}
+	} else if val := req.FormValue("revoke_quicklog"); val != "" {
+		if key, err := strconv.ParseInt(val, 10, 64); err == nil {
+			s.core.RevokeQuickLog(tot, key)
+			event.Setting = "quick_log"
+			changed, flashKey = true, "updated"
+		}
+	} else if req.FormValue("revoke_all_quicklogs") != "" {
+		if err := s.core.RevokeAllQuickLogs(tot); err != nil {
 			return totID, err
 		}
-		tot.GeneratedStats = generated
-		if err := s.store.SaveTot(tot); err != nil {
+		event.Setting = "quick_log"
+		changed, flashKey = true, "updated"
+	} else if req.FormValue("rotate_read_token") != "" {
+		if err := s.core.RotateReadToken(tot); err != nil {
+			return totID, err
+		}
+		event.Setting = "read_token"
+		changed, flashKey = true, "updated"
+	} else if req.FormValue("revoke_read_token") != "" {
+		if err := s.core.RevokeReadToken(tot); err != nil {
+			return totID, err
+		}
+		event.Setting = "read_token"
+		changed, flashKey = true, "updated"
+	} else if req.FormValue("update_locale") != "" {
+		if locale := req.FormValue("locale"); locale == "" || totI18n.Lookup(locale) != nil {
+			tot.Locale = locale
+			event.Setting = "locale"
+			changed, flashKey = true, "updated"
+		}
+	} else if req.FormValue("update_display") != "" {
+		display := totModels.DisplaySettings{
+			Clock: req.FormValue("display_clock"), DateOrder: req.FormValue("display_date_order"), History: req.FormValue("display_history"),
+		}
+		if totI18n.ValidClock(display.Clock) && totI18n.ValidDateOrder(display.DateOrder) && (display.History == "" || display.History == "relative") {
+			tot.Display = display
+			event.Setting = "display"
+			changed, flashKey = true, "updated"
+		} else {
+			flashKey = "error_display"
+		}
+	} else if kind := req.FormValue("add_journal"); kind != "" {
+		entry, err := parseJournalEntry(req, kind, tzLoc, time.Now())
+		if err == nil {
+			entry, err = s.core.AddJournalEntry(tot, entry)
+		}
+		if err != nil {
+			flashKey = "error_journal"
+		} else {
+			event = totCore.Event{Type: totCore.EventJournalAdded, Entries: []totModels.JournalEntry{entry}}
+			changed, flashKey = true, "journal"
+		}
+	} else if id := req.FormValue("delete_journal"); id != "" {
+		if removed, ok := s.core.RemoveJournalEntry(tot, id); ok {
+			event = totCore.Event{Type: totCore.EventJournalRemoved, Entries: []totModels.JournalEntry{removed}}
+			changed, flashKey = true, "journal_removed"
+		}
+	} else if ds := req.FormValue("day_starts_at"); ds != "" {
+		if hour, err := strconv.Atoi(ds); err == nil && hour >= 0 && hour < 24 {
+			tot.DayStartsAt = hour
+			event.Setting = "day_starts_at"
+			changed, flashKey = true, "updated"
+		}
+	}
+
+	if changed {
+		if err := s.core.Commit(tot, tzLoc, event); err != nil {
 			return totID, err
 		}
 	}
+	if confirm != "" {
+		s.sendConfirmation(tot, confirm)
+	}
 
 	if flashKey != "" {
 		http.SetCookie(w, &http.Cookie{Name: "flash_msg", Value: flashKey, Path: "/", MaxAge: 30, HttpOnly: true})
@@ -246,17 +396,126 @@ func (s *Server) exportTotHandler(w http.ResponseWriter, req *http.Request) (str
 	return totID, nil
 }
 
-func (s *Server) getTotPageData(totID, flashKey string) (totModels.TotPageData, error) {
+// totSubpageHandler dispatches the read-only pages nested under a tot, e.g. /{id}/report.
+// A single wildcard route is used because "/{id}/report" would conflict with "/export/{id}".
+// Feeds such as calendar.ics and feed.atom take the tot's read token in place of its ID.
+func (s *Server) totSubpageHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	switch req.PathValue("page") {
+	case "report":
+		return s.reportHandler(w, req)
+	case "summary":
+		return s.summaryHandler(w, req)
+	case "calendar.ics":
+		return s.calendarHandler(w, req)
+	case "feed.atom":
+		return s.atomHandler(w, req)
+	default:
+		return req.PathValue("id"), errors.New("page not found")
+	}
+}
+
+func (s *Server) reportHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	totID := req.PathValue("id")
+	if !isValidID(totID) {
+		return totID, errors.New("invalid tot id")
+	}
+
+	rangeName := req.URL.Query().Get("range")
+	if rangeName == "" {
+		rangeName = "week"
+	}
+	days, ok := totConfig.ReportRanges[rangeName]
+	if !ok {
+		return totID, fmt.Errorf("web: unknown report range %q", rangeName)
+	}
+
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		return totID, err
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	now := time.Now().In(tz)
+	categories := reportCategories(tot.MilkSetting)
+	report, err := s.stats.Report(tot, tz, now, days, categories)
+	if err != nil {
+		return totID, err
+	}
+
+	display := totDisplay(tot)
+	dayLayout := display.DateLayout(true, false)
+	data := totModels.ReportPageData{
+		ID: tot.ID, Name: tot.Name, Timezone: tot.Timezone, Range: rangeName,
+		Title:       strings.ToUpper(rangeName[:1]) + rangeName[1:] + "ly Report",
+		DayStartsAt: display.Hour(tot.DayStartsAt), GeneratedAt: display.Format(now, display.Layout(s.config.TimeFormat)),
+		MaxTallies: s.config.MaxTallies, CoveredFrom: s.coveredFrom(tot, tz, report, display, dayLayout),
+	}
+
+	for _, rc := range report.Categories {
+		col := totModels.ReportColumn{Label: rc.Category.Emoji, Unit: rc.Category.Unit, Avg: "---", Min: "---", Max: "---", Change: "---"}
+		if rc.Valid {
+			col.Avg = strconv.Itoa(rc.Avg)
+			col.Min = fmt.Sprintf("%d (%s)", rc.Min, display.Format(rc.MinDay, dayLayout))
+			col.Max = fmt.Sprintf("%d (%s)", rc.Max, display.Format(rc.MaxDay, dayLayout))
+		}
+		if rc.Change != nil {
+			col.Change = fmt.Sprintf("%+d%%", *rc.Change)
+		}
+		data.Columns = append(data.Columns, col)
+	}
+
+	for _, day := range report.Days {
+		row := totModels.ReportRow{Date: display.Format(day.Start, dayLayout), Partial: day.Partial, Values: make([]string, len(day.Values))}
+		for i, v := range day.Values {
+			row.Values[i] = "---"
+			if day.HasData {
+				row.Values[i] = strconv.Itoa(v)
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return totID, s.templateReport.Execute(w, data)
+}
+
+// reportCategories returns the categories shown in reports, hiding milk types the tot doesn't use.
+func reportCategories(milkSetting string) []totStats.Category {
+	categories := make([]totStats.Category, 0, len(totStats.Categories))
+	for _, c := range totStats.Categories {
+		if (c.Name == totStats.MilkCategory.Name && milkSetting == "nursing") ||
+			(c.Name == totStats.NurseCategory.Name && milkSetting == "bottle") {
+			continue
+		}
+		categories = append(categories, c)
+	}
+	return categories
+}
+
+// getTotPageData builds the dashboard in the tot's language, or the one preferred by acceptLanguage.
+func (s *Server) getTotPageData(totID, flashKey, acceptLanguage string) (totModels.TotPageData, error) {
 	tot, err := s.store.LoadTot(totID)
 	if err != nil {
 		return totModels.TotPageData{}, err
 	}
+	locale := totLocale(tot, acceptLanguage)
+	layout := locale.Layout(s.config.TimeFormat)
 
 	tz, _ := time.LoadLocation(tot.Timezone)
+	zones := totStats.NewZones(tot, tz)
 	formatted := make([]totModels.TotPageTally, len(tot.Tallies))
 	for i := range tot.Tallies {
 		t := &tot.Tallies[i]
-		formatted[i] = totModels.TotPageTally{Time: t.Time.In(tz).Format(s.config.TimeFormat), Kind: t.Kind}
+		// Tallies are shown on the clock they were logged by, marked when that was elsewhere.
+		local := zones.In(*t.Time)
+		display := locale.Format(local, layout)
+		if local.Location().String() != tz.String() {
+			display += " " + local.Format("MST")
+		}
+		formatted[i] = totModels.TotPageTally{Time: display, Kind: t.Kind}
+		if tot.Display.History == "relative" {
+			formatted[i].Time, formatted[i].Exact = formatRelativeTime(locale, t.Time), display
+		}
 	}
 
 	lastAmt := ""
@@ -267,44 +526,216 @@ func (s *Server) getTotPageData(totID, flashKey string) (totModels.TotPageData,
 		}
 	}
 
-	displayMilk := tot.MilkSetting
-	if len(displayMilk) > 0 {
-		displayMilk = strings.ToUpper(displayMilk[:1]) + displayMilk[1:]
+	charts, err := s.getTotPageCharts(tot, tz, locale)
+	if err != nil {
+		return totModels.TotPageData{}, err
+	}
+
+	var alertMessages []string
+	for _, o := range totAlerts.Evaluate(tot, time.Now()) {
+		alertMessages = append(alertMessages, o.MessageIn(locale))
+	}
+
+	alertSettings := totModels.TotPageAlertSettings{
+		Notifier: tot.Alerts.Notifier, Target: cmp.Or(tot.Alerts.Pending, tot.Alerts.Target), Pending: tot.Alerts.Pending,
+		EmailEnabled: s.config.SMTPAddr != "",
+	}
+	for _, kind := range totAlerts.Kinds {
+		hours := ""
+		if minutes := tot.Alerts.Thresholds[kind.Name]; minutes > 0 {
+			hours = strconv.FormatFloat(float64(minutes)/60, 'f', -1, 64)
+		}
+		alertSettings.Kinds = append(alertSettings.Kinds, totModels.TotPageAlertKind{Name: kind.Name, Label: locale.T("alert." + kind.Name), Hours: hours})
+	}
+
+	webhooks := make([]totModels.TotPageWebhook, len(tot.Webhooks))
+	webhookURLs := make(map[string]string, len(tot.Webhooks))
+	for i, w := range tot.Webhooks {
+		webhooks[i] = totModels.TotPageWebhook{ID: w.ID, URL: w.URL, Secret: w.Secret}
+		webhookURLs[w.ID] = w.URL
+	}
+	webhookLog := make([]totModels.TotPageWebhookDelivery, len(tot.WebhookLog))
+	for i, d := range tot.WebhookLog {
+		target, ok := webhookURLs[d.WebhookID]
+		if !ok {
+			target = locale.T("webhooks.removed")
+		}
+		result := strconv.Itoa(d.Status)
+		if d.Error != "" {
+			result = d.Error
+		}
+		webhookLog[i] = totModels.TotPageWebhookDelivery{
+			Time: locale.Format(d.At.In(tz), layout), Event: d.Event, URL: target,
+			Attempts: d.Attempts, Result: result, Failed: d.Error != "",
+		}
+	}
+
+	var quickLogs []totModels.TotPageQuickLog
+	var quickLogKinds []totModels.TotPageQuickLogKind
+	for _, key := range slices.Sorted(maps.Keys(totConfig.TallyKindMap)) {
+		kind := totConfig.TallyKindMap[key]
+		if path := quickLogPath(tot, key); path != "" {
+			quickLogs = append(quickLogs, totModels.TotPageQuickLog{Key: key, Kind: kind, Path: path})
+		} else {
+			quickLogKinds = append(quickLogKinds, totModels.TotPageQuickLogKind{Key: key, Kind: kind})
+		}
+	}
+
+	calendarPath, atomPath := "", ""
+	if tot.ReadToken != "" {
+		calendarPath, atomPath = "/"+tot.ReadToken+"/calendar.ics", "/"+tot.ReadToken+"/feed.atom"
 	}
 
+	flash, isErrorFlash := flashMessage(locale, flashKey)
+	now := time.Now()
 	return totModels.TotPageData{
+		Locale: locale, Languages: languageOptions(locale, tot.Locale), DayStartOptions: dayStartOptions(locale), Display: tot.Display,
 		ID: tot.ID, Name: tot.Name, Timezone: tot.Timezone, MilkSetting: tot.MilkSetting,
-		MilkSettingDisplay: displayMilk, FlashMessage: totConfig.FlashMessages[flashKey],
-		IsErrorFlash: strings.HasPrefix(totConfig.FlashMessages[flashKey], "Error:"),
-		Tallies:      formatted, GeneratedStats: tot.GeneratedStats, MaxTallies: s.config.MaxTallies,
+		MilkSettingDisplay: locale.T("milk.is." + tot.MilkSetting), DayStartsAt: tot.DayStartsAt, DayStartsAtDisplay: locale.Hour(tot.DayStartsAt),
+		FlashMessage: flash, IsErrorFlash: isErrorFlash,
+		Tallies: formatted, GeneratedStats: tot.GeneratedStats, Charts: charts, MaxTallies: s.config.MaxTallies,
+		Alerts: alertMessages, AlertSettings: alertSettings,
+		Webhooks: webhooks, WebhookLog: webhookLog,
+		QuickLogs: quickLogs, QuickLogKinds: quickLogKinds, CalendarPath: calendarPath, AtomPath: atomPath,
+		DigestEmail: cmp.Or(tot.Digest.Pending, tot.Digest.Email), DigestPending: tot.Digest.Pending, Timezones: timezoneGroups(tot.Timezone, now),
+		TimezoneChanges: timezoneChanges(locale, tot, layout),
+		Journal:         journalEntries(locale, tot, tz, layout), MaxJournalEntries: s.config.MaxJournalEntries,
+		Predictions: totModels.TotPagePredictions{
+			Feed:   formatPrediction(locale, s.stats.Predict(tot, tz, now, totStats.FeedCategory), "feed"),
+			Diaper: formatPrediction(locale, s.stats.Predict(tot, tz, now, totStats.DiaperCategory), "diaper"),
+		},
 		Stats: totModels.TotPageStats{
-			LastMilk: formatRelativeTime(tot.Stats.LastMilk), LastMilkAmount: lastAmt,
-			LastNurse: formatRelativeTime(tot.Stats.LastNurse), LastNurseSide: tot.Stats.LastNurseSide,
-			LastSnack: formatRelativeTime(tot.Stats.LastSnack), LastMeal: formatRelativeTime(tot.Stats.LastMeal),
-			LastPee: formatRelativeTime(tot.Stats.LastPee), LastPoo: formatRelativeTime(tot.Stats.LastPoo),
-			LastBath: formatRelativeTime(tot.Stats.LastBath), LastBrush: formatRelativeTime(tot.Stats.LastBrush),
+			LastMilk: formatRelativeTime(locale, tot.Stats.LastMilk), LastMilkAmount: lastAmt,
+			LastNurse: formatRelativeTime(locale, tot.Stats.LastNurse), LastNurseSide: tot.Stats.LastNurseSide,
+			LastSnack: formatRelativeTime(locale, tot.Stats.LastSnack), LastMeal: formatRelativeTime(locale, tot.Stats.LastMeal),
+			LastPee: formatRelativeTime(locale, tot.Stats.LastPee), LastPoo: formatRelativeTime(locale, tot.Stats.LastPoo),
+			LastBath: formatRelativeTime(locale, tot.Stats.LastBath), LastBrush: formatRelativeTime(locale, tot.Stats.LastBrush),
 		},
 	}, nil
 }
 
-func formatRelativeTime(t *time.Time) string {
+// getTotPageCharts renders the dashboard charts for the last week and the last 24 hours, titled
+// and labeled in the page's language.
+func (s *Server) getTotPageCharts(tot *totModels.Tot, tz *time.Location, locale *totI18n.Locale) (totModels.TotPageCharts, error) {
+	now := time.Now().In(tz)
+	categories := []totStats.Category{totStats.MilkCategory, totStats.NurseCategory, totStats.PeeCategory, totStats.PooCategory}
+	report, err := s.stats.Report(tot, tz, now, 7, categories)
+	if err != nil {
+		return totModels.TotPageCharts{}, err
+	}
+
+	// Reports list the most recent day first; charts read left to right.
+	labels := make([]string, len(report.Days))
+	series := make([]totCharts.Series, len(categories))
+	for c, category := range categories {
+		series[c] = totCharts.Series{Name: category.Emoji, Class: "chart-" + category.Name, Values: make([]int, len(report.Days))}
+	}
+	for i, day := range report.Days {
+		pos := len(report.Days) - 1 - i
+		labels[pos] = locale.Format(day.Start, "Mon")
+		for c := range categories {
+			series[c].Values[pos] = day.Values[c]
+		}
+	}
+
+	var charts totModels.TotPageCharts
+	if tot.MilkSetting != "nursing" {
+		charts.Milk = totCharts.BarChart(locale.T("chart.milk"), labels, series[0:1])
+	}
+	if tot.MilkSetting != "bottle" {
+		charts.Nurse = totCharts.BarChart(locale.T("chart.nurse"), labels, series[1:2])
+	}
+	charts.Diapers = totCharts.BarChart(locale.T("chart.diapers"), labels, series[2:4])
+
+	var events []totCharts.Event
+	for _, e := range s.stats.Timeline(tot, now.Add(-24*time.Hour), now, totStats.Categories) {
+		events = append(events, totCharts.Event{At: e.At.In(tz), Label: e.Kind, Class: "chart-" + e.Category.Name})
+	}
+	charts.Timeline = totCharts.Timeline(locale.T("chart.24h"), now.Add(-24*time.Hour), now, events)
+	return charts, nil
+}
+
+// parseAlertSettings reads the alert settings form. Thresholds are entered in hours and stored
+// in minutes; an empty or zero threshold disables that alert.
+func (s *Server) parseAlertSettings(req *http.Request, current totModels.AlertSettings) (totModels.AlertSettings, error) {
+	settings := totModels.AlertSettings{Thresholds: map[string]int{}, Overdue: current.Overdue}
+	for _, kind := range totAlerts.Kinds {
+		val := strings.TrimSpace(req.FormValue("alert_" + kind.Name))
+		if val == "" {
+			continue
+		}
+		hours, err := strconv.ParseFloat(val, 64)
+		if err != nil || hours < 0 || hours > 48 {
+			return current, fmt.Errorf("web: invalid %s threshold %q", kind.Name, val)
+		}
+		if minutes := int(hours * 60); minutes > 0 {
+			settings.Thresholds[kind.Name] = minutes
+		}
+	}
+
+	settings.Notifier = req.FormValue("alert_notifier")
+	settings.Target = strings.TrimSpace(req.FormValue("alert_target"))
+	if settings.Notifier == "" {
+		settings.Target = ""
+		return settings, nil
+	}
+	if settings.Notifier == "email" && s.config.SMTPAddr == "" {
+		return current, errors.New("web: email notifications are not configured")
+	}
+	if err := totAlerts.ValidateTarget(settings.Notifier, settings.Target, s.config.AllowPrivateTargets); err != nil {
+		return current, err
+	}
+	if settings.Notifier == "email" {
+		// Alerts only go to a confirmed address; a new one waits for its confirmation link.
+		address := settings.Target
+		settings.Target = ""
+		if current.Notifier == "email" {
+			settings.Target = current.Target
+		}
+		if address != settings.Target {
+			settings.Pending = address
+		}
+	}
+	return settings, nil
+}
+
+// formatPrediction renders a prediction as "Next feed expected ~2:40 PM", rounded to five minutes.
+func formatPrediction(l *totI18n.Locale, p totStats.Prediction, noun string) totModels.TotPagePrediction {
+	if !p.Valid {
+		return totModels.TotPagePrediction{}
+	}
+
+	next := l.Format(p.Next.Round(5*time.Minute), l.ClockTime)
+	res := totModels.TotPagePrediction{Next: l.T("predict."+noun, next)}
+	if p.CurrentGap > p.ExpectedGap {
+		res.Next = l.T("late."+noun, next)
+	}
+	if p.Unusual {
+		h := int(p.CurrentGap.Hours())
+		m := int(p.CurrentGap.Minutes()) % 60
+		res.Warning = l.T("unusual."+noun, h, m)
+	}
+	return res
+}
+
+func formatRelativeTime(l *totI18n.Locale, t *time.Time) string {
 	if t == nil || t.IsZero() {
-		return "not yet"
+		return l.T("time.not_yet")
 	}
 	d := time.Since(*t)
 	if d < time.Minute {
-		return "just now"
+		return l.T("time.just_now")
 	}
 	if d < time.Hour {
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+		return l.T("time.m_ago", int(d.Minutes()))
 	}
 	if d < 24*time.Hour {
 		h := int(d.Hours())
 		m := int(d.Minutes()) % 60
 		if m == 0 {
-			return fmt.Sprintf("%dh ago", h)
+			return l.T("time.h_ago", h)
 		}
-		return fmt.Sprintf("%dh %dm ago", h, m)
+		return l.T("time.hm_ago", h, m)
 	}
-	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	return l.T("time.d_ago", int(d.Hours()/24))
 }
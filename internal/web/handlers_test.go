@@ -11,7 +11,9 @@ import (
 	"time"
 	totConfig "tot-tally/internal/config"
 	totCore "tot-tally/internal/core"
+	totI18n "tot-tally/internal/i18n"
 	totModels "tot-tally/internal/models"
+	"tot-tally/internal/notify/notifytest"
 	totShards "tot-tally/internal/shards"
 	totStats "tot-tally/internal/stats"
 	totStorage "tot-tally/internal/storage"
@@ -22,8 +24,10 @@ func setupServer(t *testing.T) *Server {
 	cfg := totConfig.NewDefaultConfig()
 	cfg.TotDirectory = filepath.Join(tmpDir, "tots")
 	cfg.LimitDirectory = filepath.Join(tmpDir, "limits")
+	cfg.LinkDirectory = filepath.Join(tmpDir, "links")
 	_ = os.MkdirAll(cfg.TotDirectory, 0755)
 	_ = os.MkdirAll(cfg.LimitDirectory, 0755)
+	_ = os.MkdirAll(cfg.LinkDirectory, 0755)
 
 	pool := totShards.NewPool(4)
 	repo := totStorage.NewRepository(cfg, pool)
@@ -213,6 +217,50 @@ func TestUpdateTotHandler_Timezone(t *testing.T) {
 	}
 }
 
+func TestUpdateTotHandler_TimezoneSince(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	logged := time.Now().Add(-3 * time.Hour)
+	tot.Tallies = []totModels.Tally{{Time: &logged, Kind: "🚽"}}
+	s.store.SaveTotWithoutActivity(tot)
+
+	post := func(tz, since string) *http.Cookie {
+		form := url.Values{"timezone": {tz}, "timezone_since": {since}}
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		return rr.Result().Cookies()[0]
+	}
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if cookie := post("Asia/Tokyo", time.Now().Add(time.Hour).In(tokyo).Format(timezoneSinceLayout)); cookie.Value != "error_timezone" {
+		t.Errorf("expected error_timezone for a future switch, got %s", cookie.Value)
+	}
+	if cookie := post("Asia/Tokyo", "yesterday"); cookie.Value != "error_timezone" {
+		t.Errorf("expected error_timezone for an invalid time, got %s", cookie.Value)
+	}
+
+	landed := time.Now().Add(-time.Hour).In(tokyo).Truncate(time.Minute)
+	if cookie := post("Asia/Tokyo", landed.Format(timezoneSinceLayout)); cookie.Value != "updated" {
+		t.Fatalf("expected updated, got %s", cookie.Value)
+	}
+	tot, _ = s.store.LoadTot(id)
+	if tot.Timezone != "Asia/Tokyo" || len(tot.TimezoneChanges) != 1 || !tot.TimezoneChanges[0].At.Equal(landed) {
+		t.Fatalf("unexpected timezone change: %q %+v", tot.Timezone, tot.TimezoneChanges)
+	}
+
+	// The earlier tally keeps the clock it was logged by.
+	data, _ := s.getTotPageData(id, "", "")
+	if !strings.HasSuffix(data.Tallies[0].Time, " UTC") || len(data.TimezoneChanges) != 1 || data.TimezoneChanges[0].From != "UTC" {
+		t.Errorf("unexpected page data: %+v %+v", data.Tallies, data.TimezoneChanges)
+	}
+}
+
 func TestUpdateTotHandler_MilkSetting(t *testing.T) {
 	s := setupServer(t)
 	id, _ := s.core.CreateTot("👶", "UTC", "both")
@@ -236,6 +284,299 @@ func TestUpdateTotHandler_MilkSetting(t *testing.T) {
 	}
 }
 
+func TestUpdateTotHandler_DayStartsAt(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	for _, tc := range []struct {
+		value    string
+		expected int
+	}{
+		{"6", 6},
+		{"24", 6},  // Out of range is ignored
+		{"abc", 6}, // Malformed is ignored
+		{"0", 0},
+	} {
+		form := url.Values{}
+		form.Add("day_starts_at", tc.value)
+
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+
+		tot, _ := s.store.LoadTot(id)
+		if tot.DayStartsAt != tc.expected {
+			t.Errorf("for %q expected dayStartsAt %d, got %d", tc.value, tc.expected, tot.DayStartsAt)
+		}
+	}
+}
+
+func TestUpdateTotHandler_Alerts(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	post := func(form url.Values) *http.Cookie {
+		form.Set("update_alerts", "true")
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		return rr.Result().Cookies()[0]
+	}
+
+	post(url.Values{"alert_feed": {"3.5"}, "alert_diaper": {""}, "alert_notifier": {"ntfy"}, "alert_target": {"https://ntfy.sh/tot"}})
+	tot, _ := s.store.LoadTot(id)
+	if tot.Alerts.Thresholds["feed"] != 210 || len(tot.Alerts.Thresholds) != 1 {
+		t.Errorf("expected only a 210 minute feed threshold, got %v", tot.Alerts.Thresholds)
+	}
+	if tot.Alerts.Notifier != "ntfy" || tot.Alerts.Target != "https://ntfy.sh/tot" {
+		t.Errorf("unexpected notifier settings: %+v", tot.Alerts)
+	}
+
+	for name, form := range map[string]url.Values{
+		"negative":      {"alert_feed": {"-1"}},
+		"malformed":     {"alert_feed": {"abc"}},
+		"too long":      {"alert_feed": {"49"}},
+		"bad notifier":  {"alert_notifier": {"pager"}, "alert_target": {"https://example.com"}},
+		"bad url":       {"alert_notifier": {"webhook"}, "alert_target": {"ftp://example.com"}},
+		"email no smtp": {"alert_notifier": {"email"}, "alert_target": {"parent@example.com"}},
+	} {
+		if cookie := post(form); cookie.Value != "error_alerts" {
+			t.Errorf("%s: expected error_alerts flash, got %s", name, cookie.Value)
+		}
+	}
+	tot, _ = s.store.LoadTot(id)
+	if tot.Alerts.Thresholds["feed"] != 210 || tot.Alerts.Notifier != "ntfy" {
+		t.Errorf("expected invalid updates to be ignored, got %+v", tot.Alerts)
+	}
+
+	s.config.SMTPAddr = notifytest.NewSMTPServer(t).Addr
+	if cookie := post(url.Values{"alert_notifier": {"email"}, "alert_target": {"parent@example.com"}}); cookie.Value != "confirm_email" {
+		t.Errorf("expected confirm_email flash, got %s", cookie.Value)
+	}
+	tot, _ = s.store.LoadTot(id)
+	if tot.Alerts.Notifier != "email" || tot.Alerts.Target != "" || tot.Alerts.Pending != "parent@example.com" || len(tot.Alerts.Thresholds) != 0 {
+		t.Errorf("expected email notifier waiting for confirmation with alerts disabled, got %+v", tot.Alerts)
+	}
+
+	post(url.Values{"alert_target": {"https://example.com"}})
+	tot, _ = s.store.LoadTot(id)
+	if tot.Alerts.Notifier != "" || tot.Alerts.Target != "" {
+		t.Errorf("expected notifier to be cleared, got %+v", tot.Alerts)
+	}
+}
+
+func TestUpdateTotHandler_Digest(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	post := func(email string) *http.Cookie {
+		form := url.Values{"update_digest": {"true"}, "digest_email": {email}}
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		return rr.Result().Cookies()[0]
+	}
+
+	if cookie := post("parent@example.com"); cookie.Value != "error_digest" {
+		t.Errorf("expected error_digest without SMTP, got %s", cookie.Value)
+	}
+
+	s.config.SMTPAddr = notifytest.NewSMTPServer(t).Addr
+	if cookie := post("not-an-email"); cookie.Value != "error_digest" {
+		t.Errorf("expected error_digest for an invalid address, got %s", cookie.Value)
+	}
+	if cookie := post(" parent@example.com "); cookie.Value != "confirm_email" {
+		t.Errorf("expected confirm_email flash, got %s", cookie.Value)
+	}
+	if tot, _ := s.store.LoadTot(id); tot.Digest.Email != "" || tot.Digest.Pending != "parent@example.com" {
+		t.Errorf("expected the address to wait for confirmation, got %+v", tot.Digest)
+	}
+	if data, _ := s.getTotPageData(id, "", ""); data.DigestEmail != "parent@example.com" || data.DigestPending != "parent@example.com" {
+		t.Errorf("expected pending digest address, got %q %q", data.DigestEmail, data.DigestPending)
+	}
+
+	post("")
+	if tot, _ := s.store.LoadTot(id); tot.Digest.Email != "" || tot.Digest.Pending != "" {
+		t.Errorf("expected digest to be disabled, got %+v", tot.Digest)
+	}
+}
+
+type recordingListener struct {
+	events []totCore.Event
+}
+
+func (r *recordingListener) TotChanged(tot *totModels.Tot, event totCore.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestUpdateTotHandler_Events(t *testing.T) {
+	s := setupServer(t)
+	listener := &recordingListener{}
+	s.core.AddListener(listener)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	for _, form := range []url.Values{
+		{"tally": {"13"}},
+		{"undo": {"true"}},
+		{"timezone": {"America/Chicago"}},
+		{"timezone": {"Invalid/Zone"}},
+	} {
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		if _, err := s.updateTotHandler(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+	}
+
+	if len(listener.events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", listener.events)
+	}
+	if e := listener.events[0]; e.Type != totCore.EventTallyAdded || len(e.Tallies) != 2 {
+		t.Errorf("expected both diaper tallies to be added, got %+v", e)
+	}
+	if e := listener.events[1]; e.Type != totCore.EventTallyUndone || len(e.Tallies) != 1 || e.Tallies[0].Kind != "🚽" {
+		t.Errorf("expected pee tally to be undone, got %+v", e)
+	}
+	if e := listener.events[2]; e.Type != totCore.EventSettingsUpdated || e.Setting != "timezone" {
+		t.Errorf("expected timezone setting event, got %+v", e)
+	}
+}
+
+func TestUpdateTotHandler_Webhooks(t *testing.T) {
+	s := setupServer(t)
+	s.config.MaxWebhooks = 1
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	post := func(form url.Values) string {
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		return rr.Result().Cookies()[0].Value
+	}
+
+	if flash := post(url.Values{"add_webhook": {"true"}, "webhook_url": {"ftp://example.com"}}); flash != "error_webhook" {
+		t.Errorf("expected error_webhook for bad URL, got %s", flash)
+	}
+	if flash := post(url.Values{"add_webhook": {"true"}, "webhook_url": {"http://169.254.169.254/latest/meta-data/"}}); flash != "error_webhook" {
+		t.Errorf("expected error_webhook for a private address, got %s", flash)
+	}
+	post(url.Values{"add_webhook": {"true"}, "webhook_url": {"https://example.com/hook"}, "webhook_secret": {"shh"}})
+	if flash := post(url.Values{"add_webhook": {"true"}, "webhook_url": {"https://example.com/other"}}); flash != "error_webhook" {
+		t.Errorf("expected error_webhook beyond MaxWebhooks, got %s", flash)
+	}
+
+	tot, _ := s.store.LoadTot(id)
+	if len(tot.Webhooks) != 1 || tot.Webhooks[0].URL != "https://example.com/hook" || tot.Webhooks[0].Secret != "shh" {
+		t.Fatalf("unexpected webhooks: %+v", tot.Webhooks)
+	}
+
+	tot.WebhookLog = []totModels.WebhookDelivery{
+		{WebhookID: tot.Webhooks[0].ID, Event: "tally.added", At: time.Now(), Attempts: 1, Status: 200},
+		{WebhookID: "gone", Event: "tally.undone", At: time.Now(), Attempts: 5, Status: 500, Error: "webhooks: unexpected status 500"},
+	}
+	s.store.SaveTot(tot)
+	data, _ := s.getTotPageData(id, "", "")
+	if len(data.Webhooks) != 1 || len(data.WebhookLog) != 2 {
+		t.Fatalf("unexpected page data: %+v %+v", data.Webhooks, data.WebhookLog)
+	}
+	if l := data.WebhookLog[0]; l.URL != "https://example.com/hook" || l.Result != "200" || l.Failed {
+		t.Errorf("unexpected delivery: %+v", l)
+	}
+	if l := data.WebhookLog[1]; l.URL != "(removed)" || !l.Failed {
+		t.Errorf("unexpected delivery: %+v", l)
+	}
+
+	post(url.Values{"delete_webhook": {tot.Webhooks[0].ID}})
+	tot, _ = s.store.LoadTot(id)
+	if len(tot.Webhooks) != 0 {
+		t.Errorf("expected webhook to be removed, got %+v", tot.Webhooks)
+	}
+}
+
+func TestUpdateTotHandler_Journal(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	post := func(form url.Values) string {
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		return rr.Result().Cookies()[0].Value
+	}
+
+	local := func(d time.Duration) string { return time.Now().UTC().Add(-d).Format(timezoneSinceLayout) }
+	for _, form := range []url.Values{
+		{"add_journal": {"sleep"}, "journal_time": {local(3 * time.Hour)}, "journal_end": {local(time.Hour)}},
+		{"add_journal": {"medication"}, "journal_name": {"Vitamin D"}, "journal_dose": {"1 drop"}},
+		{"add_journal": {"growth"}, "journal_time": {local(2 * time.Hour)}, "journal_weight": {"5,25"}, "journal_head": {"38"}},
+	} {
+		if flash := post(form); flash != "journal" {
+			t.Errorf("%v: expected journal flash, got %s", form, flash)
+		}
+	}
+	for _, form := range []url.Values{
+		{"add_journal": {"sleep"}, "journal_time": {local(time.Hour)}, "journal_end": {local(3 * time.Hour)}},
+		{"add_journal": {"growth"}, "journal_weight": {"heavy"}},
+		{"add_journal": {"note"}, "journal_time": {"yesterday"}, "journal_text": {"Hi"}},
+		{"add_journal": {"feeding"}},
+	} {
+		if flash := post(form); flash != "error_journal" {
+			t.Errorf("%v: expected error_journal, got %s", form, flash)
+		}
+	}
+
+	data, _ := s.getTotPageData(id, "", "")
+	if len(data.Journal) != 3 {
+		t.Fatalf("expected 3 journal entries, got %+v", data.Journal)
+	}
+	for i, want := range []string{"Vitamin D 1 drop", "5.25 kg · head 38 cm", "2h 0m"} {
+		if data.Journal[i].Detail != want {
+			t.Errorf("entry %d: expected %q, got %q", i, want, data.Journal[i].Detail)
+		}
+	}
+
+	if flash := post(url.Values{"delete_journal": {data.Journal[0].ID}}); flash != "journal_removed" {
+		t.Errorf("expected journal_removed flash, got %s", flash)
+	}
+	if tot, _ := s.store.LoadTot(id); len(tot.Journal) != 2 || tot.Journal[0].Kind != "growth" {
+		t.Errorf("expected the medication removed, got %+v", tot.Journal)
+	}
+}
+
+func TestFormatHour(t *testing.T) {
+	tests := map[int]string{0: "12 AM", 6: "6 AM", 12: "12 PM", 18: "6 PM", 23: "11 PM"}
+	for hour, expected := range tests {
+		if result := totDisplay(&totModels.Tot{}).Hour(hour); result != expected {
+			t.Errorf("for %d expected %s, got %s", hour, expected, result)
+		}
+	}
+	if result := totDisplay(&totModels.Tot{Display: totModels.DisplaySettings{Clock: "24h"}}).Hour(18); result != "18:00" {
+		t.Errorf("expected 24-hour label, got %s", result)
+	}
+}
+
 func TestUpdateTotHandler_InvalidID(t *testing.T) {
 	s := setupServer(t)
 	req := httptest.NewRequest("POST", "/invalid-id", nil)
@@ -283,7 +624,7 @@ func TestFormatRelativeTime(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := formatRelativeTime(tt.t)
+		result := formatRelativeTime(totI18n.English, tt.t)
 		if result != tt.expected {
 			t.Errorf("for %v expected %s, got %s", tt.t, tt.expected, result)
 		}
@@ -359,7 +700,7 @@ func TestGetTotPageData(t *testing.T) {
 	s.core.AddTally(tot, "1") // 🍼1
 	s.store.SaveTot(tot)
 
-	data, err := s.getTotPageData(id, "tally")
+	data, err := s.getTotPageData(id, "tally", "")
 	if err != nil {
 		t.Fatalf("getTotPageData failed: %v", err)
 	}
@@ -631,6 +972,11 @@ func TestNewServer_Fallback(t *testing.T) {
 	_ = os.Mkdir(filepath.Join(nested, "assets"), 0755)
 	_ = os.WriteFile(filepath.Join(nested, "assets", "index.html"), []byte(""), 0644)
 	_ = os.WriteFile(filepath.Join(nested, "assets", "tot.html"), []byte(""), 0644)
+	_ = os.WriteFile(filepath.Join(nested, "assets", "report.html"), []byte(""), 0644)
+	_ = os.WriteFile(filepath.Join(nested, "assets", "quicklog.html"), []byte(""), 0644)
+	_ = os.WriteFile(filepath.Join(nested, "assets", "mail.html"), []byte(""), 0644)
+	_ = os.WriteFile(filepath.Join(nested, "assets", "summary.html"), []byte(""), 0644)
+	_ = os.WriteFile(filepath.Join(nested, "assets", "openapi.json"), []byte("{}"), 0644)
 
 	cfg := totConfig.NewDefaultConfig()
 	pool := totShards.NewPool(1)
@@ -718,3 +1064,174 @@ func TestExportTotHandler_NotFound(t *testing.T) {
 		t.Error("expected error for missing tot, got nil")
 	}
 }
+
+func TestReportHandler(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "America/New_York", "bottle")
+	tot, _ := s.store.LoadTot(id)
+	s.core.AddTally(tot, "4") // 🍼4
+	s.store.SaveTot(tot)
+
+	for _, rng := range []string{"", "week", "month"} {
+		req := httptest.NewRequest("GET", "/"+id+"/report?range="+rng, nil)
+		req.SetPathValue("id", id)
+		req.SetPathValue("page", "report")
+		rr := httptest.NewRecorder()
+
+		_, err := s.totSubpageHandler(rr, req)
+		if err != nil {
+			t.Fatalf("reportHandler failed for %q: %v", rng, err)
+		}
+
+		body := rr.Body.String()
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rr.Code)
+		}
+		if !strings.Contains(body, "🍼 (oz)") {
+			t.Error("expected milk column in report")
+		}
+		if strings.Contains(body, "<th>🤱</th>") {
+			t.Error("expected nursing column to be hidden for bottle setting")
+		}
+		if !strings.Contains(body, `<td class="mono">4</td>`) {
+			t.Error("expected today's milk total in report")
+		}
+		if strings.Contains(body, "aren't covered") {
+			t.Error("expected no coverage note while no tallies were dropped")
+		}
+	}
+
+	// At the tally limit, the month's days before the oldest saved tally are called out.
+	s.config.MaxTallies = 1
+	req := httptest.NewRequest("GET", "/"+id+"/report?range=month", nil)
+	req.SetPathValue("id", id)
+	req.SetPathValue("page", "report")
+	rr := httptest.NewRecorder()
+	if _, err := s.totSubpageHandler(rr, req); err != nil {
+		t.Fatalf("reportHandler failed: %v", err)
+	}
+	tz, _ := time.LoadLocation("America/New_York")
+	today := totStats.DayStart(time.Now().In(tz), tot.DayStartsAt).Format("Mon 02 Jan")
+	if !strings.Contains(rr.Body.String(), "tallies before "+today+" aren't covered") {
+		t.Errorf("expected a note that days before %s aren't covered", today)
+	}
+}
+
+func TestReportHandler_Errors(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	tests := []struct {
+		name, id, page, query string
+	}{
+		{"Unknown range", id, "report", "?range=year"},
+		{"Invalid ID", "invalid-id", "report", ""},
+		{"Missing tot", "123e4567-e89b-12d3-a456-426614174000", "report", ""},
+		{"Unknown page", id, "nope", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/"+tt.id+"/"+tt.page+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			req.SetPathValue("page", tt.page)
+			rr := httptest.NewRecorder()
+
+			if _, err := s.totSubpageHandler(rr, req); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestGetTotPageData_Charts(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "America/New_York", "nursing")
+	tot, _ := s.store.LoadTot(id)
+	s.core.AddTally(tot, "16") // 🤱L
+	s.core.AddTally(tot, "11") // 🚽
+	s.store.SaveTot(tot)
+
+	data, err := s.getTotPageData(id, "", "")
+	if err != nil {
+		t.Fatalf("getTotPageData failed: %v", err)
+	}
+
+	if data.Charts.Milk != "" {
+		t.Error("expected no milk chart for nursing setting")
+	}
+	if !strings.Contains(string(data.Charts.Nurse), "<svg") || !strings.Contains(string(data.Charts.Diapers), "<svg") {
+		t.Error("expected nursing and diaper charts")
+	}
+	if !strings.Contains(string(data.Charts.Timeline), `class="chart-nurse"`) || !strings.Contains(string(data.Charts.Timeline), `class="chart-pee"`) {
+		t.Error("expected timeline ticks for recent tallies")
+	}
+
+	// Titles and day names follow the page's language.
+	tot.WebhookLog = []totModels.WebhookDelivery{{WebhookID: "gone", Event: "tally.added", At: time.Now(), Status: 200}}
+	s.store.SaveTot(tot)
+	data, err = s.getTotPageData(id, "", "de")
+	if err != nil {
+		t.Fatalf("getTotPageData failed: %v", err)
+	}
+	tz, _ := time.LoadLocation(tot.Timezone)
+	today := totI18n.German.ShortWeekdays[totStats.DayStart(time.Now().In(tz), tot.DayStartsAt).Weekday()]
+	if nurse := string(data.Charts.Nurse); !strings.Contains(nurse, "Stillmahlzeiten pro Tag") || !strings.Contains(nurse, ">"+today+"</text>") {
+		t.Errorf("expected a German nursing chart, got %s", nurse)
+	}
+	if !strings.Contains(string(data.Charts.Timeline), "Letzte 24 Stunden") {
+		t.Error("expected a German timeline title")
+	}
+	if data.WebhookLog[0].URL != "(entfernt)" {
+		t.Errorf("expected a German placeholder for a removed webhook, got %q", data.WebhookLog[0].URL)
+	}
+}
+
+func TestGetTotPageData_Alerts(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	tot.CreatedAt = time.Now().Add(-5 * time.Hour)
+	tot.Alerts.Thresholds = map[string]int{"feed": 90, "diaper": 600}
+	s.store.SaveTot(tot)
+
+	data, err := s.getTotPageData(id, "", "")
+	if err != nil {
+		t.Fatalf("getTotPageData failed: %v", err)
+	}
+
+	if len(data.Alerts) != 1 || !strings.HasPrefix(data.Alerts[0], "Feed overdue") {
+		t.Errorf("expected a single feed alert, got %v", data.Alerts)
+	}
+	if kinds := data.AlertSettings.Kinds; len(kinds) != 4 || kinds[0].Hours != "1.5" || kinds[1].Hours != "10" || kinds[2].Hours != "" {
+		t.Errorf("unexpected alert settings: %+v", kinds)
+	}
+	if data.AlertSettings.EmailEnabled {
+		t.Error("expected email to be disabled without an SMTP relay")
+	}
+}
+
+func TestFormatPrediction(t *testing.T) {
+	last := time.Date(2023, 10, 27, 11, 38, 0, 0, time.UTC)
+
+	if got := formatPrediction(totI18n.English, totStats.Prediction{}, "feed"); got.Next != "" || got.Warning != "" {
+		t.Errorf("expected empty prediction, got %+v", got)
+	}
+
+	upcoming := totStats.Prediction{Valid: true, Last: last, Next: last.Add(3 * time.Hour), ExpectedGap: 3 * time.Hour, CurrentGap: time.Hour}
+	if got := formatPrediction(totI18n.English, upcoming, "feed"); got.Next != "Next feed expected ~2:40 PM" || got.Warning != "" {
+		t.Errorf("unexpected upcoming prediction: %+v", got)
+	}
+
+	overdue := totStats.Prediction{
+		Valid: true, Last: last, Next: last.Add(3 * time.Hour), ExpectedGap: 3 * time.Hour,
+		CurrentGap: 7*time.Hour + 5*time.Minute, Unusual: true,
+	}
+	got := formatPrediction(totI18n.English, overdue, "diaper")
+	if got.Next != "Diaper expected since ~2:40 PM" {
+		t.Errorf("unexpected overdue prediction: %s", got.Next)
+	}
+	if got.Warning != "⚠️ 7h 5m since last diaper, longer than usual" {
+		t.Errorf("unexpected warning: %s", got.Warning)
+	}
+}
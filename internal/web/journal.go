@@ -0,0 +1,106 @@
+// journal.go reads the journal forms on a tot's page and lists its entries: sleep, medications,
+// growth measurements and notes for the visit summary.
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+)
+
+// parseJournalEntry reads the journal form for kind. Times are local to the tot, as entered in a
+// datetime-local input; a blank time means now.
+func parseJournalEntry(req *http.Request, kind string, tz *time.Location, now time.Time) (totModels.JournalEntry, error) {
+	entry := totModels.JournalEntry{Kind: kind}
+	var err error
+	if entry.Time, err = parseLocalTime(req.FormValue("journal_time"), tz, now); err != nil {
+		return entry, err
+	}
+
+	switch kind {
+	case totConfig.JournalSleep:
+		end, err := parseLocalTime(req.FormValue("journal_end"), tz, now)
+		if err != nil {
+			return entry, err
+		}
+		entry.End = &end
+	case totConfig.JournalMedication:
+		entry.Name, entry.Dose = req.FormValue("journal_name"), req.FormValue("journal_dose")
+	case totConfig.JournalGrowth:
+		for _, m := range []struct {
+			field string
+			value *float64
+		}{{"journal_weight", &entry.WeightKg}, {"journal_length", &entry.LengthCm}, {"journal_head", &entry.HeadCm}} {
+			v := strings.TrimSpace(strings.ReplaceAll(req.FormValue(m.field), ",", "."))
+			if v == "" {
+				continue
+			}
+			if *m.value, err = strconv.ParseFloat(v, 64); err != nil {
+				return entry, fmt.Errorf("web: invalid %s %q: %w", m.field, v, err)
+			}
+		}
+	case totConfig.JournalNote:
+		entry.Text = req.FormValue("journal_text")
+	}
+	return entry, nil
+}
+
+// parseLocalTime reads a datetime-local value in tz, or returns now when it is blank.
+func parseLocalTime(value string, tz *time.Location, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	t, err := time.ParseInLocation(timezoneSinceLayout, value, tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("web: invalid time %q: %w", value, err)
+	}
+	return t, nil
+}
+
+// journalEntries lists the journal newest first, each with its details in the page's language.
+func journalEntries(l *totI18n.Locale, tot *totModels.Tot, tz *time.Location, layout string) []totModels.TotPageJournalEntry {
+	entries := make([]totModels.TotPageJournalEntry, len(tot.Journal))
+	for i, e := range tot.Journal {
+		var detail string
+		switch e.Kind {
+		case totConfig.JournalSleep:
+			if e.End != nil {
+				detail = l.Duration(e.End.Sub(e.Time))
+			}
+		case totConfig.JournalMedication:
+			detail = strings.TrimSpace(e.Name + " " + e.Dose)
+		case totConfig.JournalGrowth:
+			var parts []string
+			for _, m := range []struct {
+				key   string
+				value float64
+			}{{"journal.kg", e.WeightKg}, {"journal.length_cm", e.LengthCm}, {"journal.head_cm", e.HeadCm}} {
+				if m.value > 0 {
+					parts = append(parts, l.T(m.key, l.Number(m.value, decimals(m.value))))
+				}
+			}
+			detail = strings.Join(parts, " · ")
+		case totConfig.JournalNote:
+			detail = e.Text
+		}
+		entries[i] = totModels.TotPageJournalEntry{
+			ID: e.ID, Time: l.Format(e.Time.In(tz), layout), Kind: l.T("journal." + e.Kind), Detail: detail,
+		}
+	}
+	return entries
+}
+
+// decimals is how many decimal places a measurement was entered with, up to two.
+func decimals(v float64) int {
+	for n := range 2 {
+		if s := strconv.FormatFloat(v, 'f', -1, 64); !strings.Contains(s, ".") || len(s)-strings.Index(s, ".")-1 <= n {
+			return n
+		}
+	}
+	return 2
+}
@@ -0,0 +1,55 @@
+// locale.go picks the language of a page and translates the messages built for it.
+package web
+
+import (
+	"strings"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+)
+
+// totLocale returns the tot's chosen language, or the browser's when it follows each browser,
+// with the tot's clock and date order.
+func totLocale(tot *totModels.Tot, acceptLanguage string) *totI18n.Locale {
+	l := totI18n.Lookup(tot.Locale)
+	if l == nil {
+		l = totI18n.Negotiate(acceptLanguage)
+	}
+	return l.WithDisplay(tot.Display.Clock, tot.Display.DateOrder)
+}
+
+// totDisplay is English with the tot's clock and date order, for reports, feeds and emails,
+// which are not translated.
+func totDisplay(tot *totModels.Tot) *totI18n.Locale {
+	return totI18n.English.WithDisplay(tot.Display.Clock, tot.Display.DateOrder)
+}
+
+// flashMessage translates a flash cookie key such as "error_limit". Unknown keys show nothing.
+func flashMessage(l *totI18n.Locale, key string) (string, bool) {
+	if key == "" || !l.Has("flash."+key) {
+		return "", false
+	}
+	return l.T("flash." + key), strings.HasPrefix(key, "error_")
+}
+
+func languageOptions(l *totI18n.Locale, setting string) []totModels.TotPageLanguage {
+	options := []totModels.TotPageLanguage{{Name: l.T("language.auto"), Selected: setting == ""}}
+	for _, locale := range totI18n.Locales {
+		options = append(options, totModels.TotPageLanguage{Tag: locale.Tag, Name: locale.Name, Selected: setting == locale.Tag})
+	}
+	return options
+}
+
+func dayStartOptions(l *totI18n.Locale) []totModels.TotPageHour {
+	options := make([]totModels.TotPageHour, 24)
+	for hour := range options {
+		label := l.Hour(hour)
+		switch hour {
+		case 0:
+			label = l.T("day.midnight", label)
+		case 12:
+			label = l.T("day.noon", label)
+		}
+		options[hour] = totModels.TotPageHour{Value: hour, Label: label}
+	}
+	return options
+}
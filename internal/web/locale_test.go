@@ -0,0 +1,134 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+)
+
+func TestGetTotHandler_Locale(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	get := func(acceptLanguage string) string {
+		req := httptest.NewRequest("GET", "/"+id, nil)
+		req.SetPathValue("id", id)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		req.AddCookie(&http.Cookie{Name: "flash_msg", Value: "updated"})
+		rr := httptest.NewRecorder()
+		if _, err := s.getTotHandler(rr, req); err != nil {
+			t.Fatalf("getTotHandler failed: %v", err)
+		}
+		return rr.Body.String()
+	}
+
+	body := get("es-MX,es;q=0.9,en;q=0.8")
+	for _, want := range []string{`lang="es"`, "Ajustes actualizados", "Actualizar zona horaria", "00:00 (medianoche)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected Spanish page to contain %q", want)
+		}
+	}
+	if body := get("fr"); !strings.Contains(body, `lang="en"`) || !strings.Contains(body, "Settings Updated") {
+		t.Error("expected an unsupported language to fall back to English")
+	}
+
+	// A chosen language wins over each browser's.
+	post := func(locale string) string {
+		form := url.Values{"locale": {locale}, "update_locale": {"1"}}
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		tot, _ := s.store.LoadTot(id)
+		return tot.Locale
+	}
+	if got := post("de"); got != "de" {
+		t.Fatalf("expected locale de, got %q", got)
+	}
+	if body := get("es"); !strings.Contains(body, `lang="de"`) || !strings.Contains(body, "Zeitzone speichern") {
+		t.Error("expected the tot's language to override Accept-Language")
+	}
+	if got := post("xx"); got != "de" {
+		t.Errorf("expected an unknown locale to be ignored, got %q", got)
+	}
+	if got := post(""); got != "" {
+		t.Errorf("expected automatic locale, got %q", got)
+	}
+}
+
+func TestFlashMessage(t *testing.T) {
+	if msg, isErr := flashMessage(totI18n.German, "error_limit"); msg != "Fehler: Zu viele Anfragen!" || !isErr {
+		t.Errorf("unexpected flash %q (error %v)", msg, isErr)
+	}
+	if msg, isErr := flashMessage(totI18n.English, "tally"); msg != "Tally Added!" || isErr {
+		t.Errorf("unexpected flash %q (error %v)", msg, isErr)
+	}
+	if msg, _ := flashMessage(totI18n.English, "<script>"); msg != "" {
+		t.Errorf("expected unknown keys to show nothing, got %q", msg)
+	}
+}
+
+func TestUpdateTotHandler_Display(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	logged := time.Date(2023, 10, 26, 18, 30, 0, 0, time.UTC)
+	tot.Tallies = []totModels.Tally{{Time: &logged, Kind: "🚽"}}
+	s.store.SaveTotWithoutActivity(tot)
+
+	var flash string
+	post := func(clock, order, history string) totModels.DisplaySettings {
+		form := url.Values{"display_clock": {clock}, "display_date_order": {order}, "display_history": {history}, "update_display": {"1"}}
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if _, err := s.updateTotHandler(rr, req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+		flash = rr.Result().Cookies()[0].Value
+		tot, _ := s.store.LoadTot(id)
+		return tot.Display
+	}
+
+	if got := post("24h", "mdy", ""); got.Clock != "24h" || got.DateOrder != "mdy" || flash != "updated" {
+		t.Fatalf("unexpected display %+v (flash %s)", got, flash)
+	}
+	data, _ := s.getTotPageData(id, "", "")
+	if got := data.Tallies[0].Time; got != "Oct 26 18:30" {
+		t.Errorf("expected 24-hour month-first time, got %q", got)
+	}
+	if data, _ := s.getTotPageData(id, "", "de"); data.Tallies[0].Time != "Okt 26 18:30" {
+		t.Errorf("expected preferences to apply in German, got %q", data.Tallies[0].Time)
+	}
+
+	if got := post("", "", "relative"); got.History != "relative" {
+		t.Fatalf("unexpected display %+v", got)
+	}
+	data, _ = s.getTotPageData(id, "", "")
+	if tally := data.Tallies[0]; !strings.HasSuffix(tally.Time, "d ago") || tally.Exact != "26 Oct 06:30PM" {
+		t.Errorf("expected a relative time with the exact one, got %+v", tally)
+	}
+
+	for _, bad := range [][3]string{{"13h", "", ""}, {"", "ymd", ""}, {"", "", "sometimes"}} {
+		if got := post(bad[0], bad[1], bad[2]); got.History != "relative" {
+			t.Errorf("%v: expected invalid display to be ignored, got %+v", bad, got)
+		}
+		if flash != "error_display" {
+			t.Errorf("%v: expected error_display flash, got %s", bad, flash)
+		}
+	}
+	for _, l := range totI18n.Locales {
+		if msg, isErr := flashMessage(l, "error_display"); msg == "" || !isErr {
+			t.Errorf("%s: expected an error message for error_display, got %q", l.Tag, msg)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// mail.go sends confirmation emails and serves the signed links in emails that confirm an address
+// or unsubscribe it. Like quick-log links, they name the tot by a public reference, never its ID.
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+)
+
+var mailInvalid = totModels.MailPageData{Error: true, Message: "This link is not valid. The address may have changed since it was sent."}
+
+// mailPurposes describes what an address receives, e.g. "Send the daily digest to ...".
+var mailPurposes = map[string]string{
+	totCore.MailDigest: "the daily digest",
+	totCore.MailAlerts: "alerts",
+}
+
+// mailLinkHandler asks whether to confirm or unsubscribe the address a link was signed for, and
+// does so when the button is pressed. Opening a link never changes anything, so mail scanners
+// that follow links can't; mail clients' one-click unsubscribe POSTs directly.
+func (s *Server) mailLinkHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	ref, purpose, action, sig := req.PathValue("ref"), req.PathValue("purpose"), req.PathValue("action"), req.PathValue("sig")
+	noun, ok := mailPurposes[purpose]
+	if !ok {
+		return "", s.renderMail(w, http.StatusNotFound, mailInvalid)
+	}
+
+	totID, err := s.store.LoadLink(ref)
+	if err != nil {
+		if err.Error() != "link does not exist" {
+			return "", err
+		}
+		return "", s.renderMail(w, http.StatusNotFound, mailInvalid)
+	}
+
+	mut := s.shards.GetShardMutex(totID)
+	mut.Lock()
+	defer mut.Unlock()
+
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		if err.Error() != "tot does not exist" {
+			return totID, err
+		}
+		// The tot was deleted or cleaned up, so its reference is dangling.
+		if err := s.store.DeleteLink(ref); err != nil {
+			slog.Warn("failed to delete dangling link", "err", err)
+		}
+		return "", s.renderMail(w, http.StatusNotFound, mailInvalid)
+	}
+	address := totCore.MailLinkAddress(tot, purpose, action, sig)
+	if tot.Mail.Ref != ref || address == "" {
+		return "", s.renderMail(w, http.StatusNotFound, mailInvalid)
+	}
+
+	data := totModels.MailPageData{Name: tot.Name}
+	if req.Method != http.MethodPost {
+		if action == totCore.MailConfirm {
+			data.Message, data.Button = fmt.Sprintf("Send %s to %s?", noun, address), "Confirm"
+		} else {
+			data.Message, data.Button = fmt.Sprintf("Stop sending %s to %s?", noun, address), "Unsubscribe"
+		}
+		return "", s.renderMail(w, http.StatusOK, data)
+	}
+
+	totCore.ApplyMailLink(tot, purpose, action, sig)
+	tz, _ := time.LoadLocation(tot.Timezone)
+	if err := s.core.Commit(tot, tz, totCore.Event{Type: totCore.EventSettingsUpdated, Setting: purpose}); err != nil {
+		return totID, err
+	}
+	if action == totCore.MailConfirm {
+		data.Message = fmt.Sprintf("%s will get %s.", address, noun)
+	} else {
+		data.Message = fmt.Sprintf("%s won't get %s anymore.", address, noun)
+	}
+	return "", s.renderMail(w, http.StatusOK, data)
+}
+
+func (s *Server) renderMail(w http.ResponseWriter, status int, data totModels.MailPageData) error {
+	w.WriteHeader(status)
+	return s.templateMail.Execute(w, data)
+}
+
+// sendConfirmation mails the confirmation link to the address waiting for purpose. It sends in
+// the background so a slow relay never holds the tot's lock; failures are only logged.
+func (s *Server) sendConfirmation(tot *totModels.Tot, purpose string) {
+	address := tot.Digest.Pending
+	if purpose == totCore.MailAlerts {
+		address = tot.Alerts.Pending
+	}
+	confirm := totCore.MailLink(s.config, tot, purpose, totCore.MailConfirm, address)
+	unsubscribe := totCore.MailLink(s.config, tot, purpose, totCore.MailUnsubscribe, address)
+
+	subject := fmt.Sprintf("Confirm Tot-Tally %s for %s", mailPurposes[purpose], tot.Name)
+	body := fmt.Sprintf("Someone asked to send %s for %s to this address.\n\nConfirm: %s\n\n"+
+		"If it wasn't you, ignore this email and nothing else will be sent.\n\n--\nStop these emails: %s\n",
+		mailPurposes[purpose], tot.Name, confirm, unsubscribe)
+	mailer, id := totNotify.NewMailer(s.config), tot.ID
+	go func() {
+		if err := mailer.Send(address, subject, body, unsubscribe); err != nil {
+			slog.Warn("confirmation mail failed", "id", id, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	totCore "tot-tally/internal/core"
+	"tot-tally/internal/notify/notifytest"
+)
+
+func TestMailLinkHandler(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	srv := notifytest.NewSMTPServer(t)
+	s.config.SMTPAddr = srv.Addr
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	form := url.Values{"update_digest": {"true"}, "digest_email": {"parent@example.com"}}
+	req := httptest.NewRequest("POST", "http://tally.example.com/"+id, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", id)
+	if _, err := s.updateTotHandler(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("updateTotHandler failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srv.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	msgs := srv.Messages()
+	if len(msgs) != 1 || msgs[0].To[0] != "parent@example.com" {
+		t.Fatalf("expected a confirmation mail, got %+v", msgs)
+	}
+	confirm := regexp.MustCompile(`Confirm: http://tally\.example\.com(/m/\S+/digest/confirm/\S+)`).FindStringSubmatch(msgs[0].Data)
+	unsubscribe := regexp.MustCompile(`List-Unsubscribe: <http://tally\.example\.com(/m/\S+/digest/unsubscribe/\S+)>`).FindStringSubmatch(msgs[0].Data)
+	if confirm == nil || unsubscribe == nil || strings.Contains(msgs[0].Data, id) {
+		t.Fatalf("expected confirm and unsubscribe links without the tot ID, got %q", msgs[0].Data)
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader("List-Unsubscribe=One-Click")))
+		return rr
+	}
+
+	// Opening the link only asks, so a mail scanner following it confirms nothing.
+	rr := serve("GET", confirm[1])
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Send the daily digest to parent@example.com?") || rr.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Fatalf("expected confirmation prompt, got %d %s", rr.Code, rr.Body.String())
+	}
+	if tot, _ := s.store.LoadTot(id); tot.Digest.Email != "" {
+		t.Fatalf("expected GET not to confirm, got %+v", tot.Digest)
+	}
+
+	if rr := serve("POST", confirm[1]); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "will get the daily digest") {
+		t.Fatalf("expected confirmation, got %d %s", rr.Code, rr.Body.String())
+	}
+	tot, _ := s.store.LoadTot(id)
+	if tot.Digest.Email != "parent@example.com" || tot.Digest.Pending != "" {
+		t.Fatalf("expected the address to be confirmed, got %+v", tot.Digest)
+	}
+	if rr := serve("POST", confirm[1]); rr.Code != http.StatusNotFound {
+		t.Errorf("expected a used confirmation link to be invalid, got %d", rr.Code)
+	}
+
+	// Links are signed for the address, so the confirmation mail's unsubscribe link is the one
+	// every digest carries. It unsubscribes in one POST, as mail clients send it.
+	link := totCore.MailLink(s.config, tot, totCore.MailDigest, totCore.MailUnsubscribe, "parent@example.com")
+	path := strings.TrimPrefix(link, "http://tally.example.com")
+	if path != unsubscribe[1] {
+		t.Errorf("expected the unsubscribe link %s, got %s", path, unsubscribe[1])
+	}
+	if rr := serve("POST", path); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "won&#39;t get the daily digest") {
+		t.Fatalf("expected unsubscribe, got %d %s", rr.Code, rr.Body.String())
+	}
+	if tot, _ := s.store.LoadTot(id); tot.Digest.Email != "" {
+		t.Errorf("expected the digest to be disabled, got %+v", tot.Digest)
+	}
+
+	for _, bad := range []string{
+		"/m/unknown/digest/unsubscribe/sig",
+		"/m/" + tot.Mail.Ref + "/newsletter/unsubscribe/sig",
+		"/m/" + tot.Mail.Ref + "/alerts/confirm/" + strings.Split(confirm[1], "/")[5],
+		path,
+	} {
+		if rr := serve("GET", bad); rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "not valid") {
+			t.Errorf("%s: expected not found, got %d", bad, rr.Code)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package web
 
 import (
 	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"strings"
+	totModels "tot-tally/internal/models"
 
 	"github.com/google/uuid"
 )
@@ -83,6 +86,83 @@ func handlerWrapper(handler HandlerE) http.HandlerFunc {
 	}
 }
 
+// APIHandlerE is the JSON API handler signature. It returns the status and body to encode.
+type APIHandlerE = func(w http.ResponseWriter, r *http.Request) (int, any, error)
+
+// apiError is an error with the status and code it is reported to API clients with.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func newAPIError(status int, code, message string) *apiError {
+	return &apiError{status: status, code: code, message: message}
+}
+
+var errAPINotFound = newAPIError(http.StatusNotFound, "not_found", "tot not found")
+
+// apiWrapper is the JSON API counterpart of handlerWrapper: errors become JSON bodies with
+// a matching status code instead of a redirect home.
+func apiWrapper(handler APIHandlerE) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic recovered", "recover", r)
+				writeAPIError(w, newAPIError(http.StatusInternalServerError, "internal", "unexpected error"))
+			}
+		}()
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		req.Body = http.MaxBytesReader(w, req.Body, 2048)
+
+		status, body, err := handler(w, req)
+		slog.Debug("api request handled", "method", req.Method, "path", req.URL.Path, "status", status)
+		if err != nil {
+			apiErr, ok := errors.AsType[*apiError](err)
+			if !ok {
+				slog.Warn("api request error", "method", req.Method, "path", req.URL.Path, "err", err)
+				apiErr = newAPIError(http.StatusInternalServerError, "internal", "unexpected error")
+			}
+			writeAPIError(w, apiErr)
+			return
+		}
+		writeJSON(w, status, body)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, e *apiError) {
+	writeJSON(w, e.status, totModels.APIError{Error: totModels.APIErrorDetail{Code: e.code, Message: e.message}})
+}
+
+// decodeJSON reads a JSON request body into v, rejecting unknown fields and other content types.
+func decodeJSON(req *http.Request, v any) error {
+	if mediaType, _, _ := strings.Cut(req.Header.Get("Content-Type"), ";"); strings.TrimSpace(mediaType) != "application/json" {
+		return newAPIError(http.StatusUnsupportedMediaType, "unsupported_media_type", "request body must be application/json")
+	}
+
+	dec := json.NewDecoder(req.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			return newAPIError(http.StatusRequestEntityTooLarge, "too_large", "request body too large")
+		}
+		return newAPIError(http.StatusBadRequest, "invalid_json", "invalid JSON: "+err.Error())
+	}
+	return nil
+}
+
 // isValidID validates that the string is a valid standard UUID.
 func isValidID(id string) bool {
 	_, err := uuid.Parse(id)
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+	"tot-tally/pkg/client"
+)
+
+type openAPIDoc struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(t *testing.T, s *Server) openAPIDoc {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for openapi.json, got %d", rr.Code)
+	}
+
+	var doc openAPIDoc
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+// jsonFields returns the JSON property names of a struct type.
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for field := range typ.Fields() {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestOpenAPI_Paths(t *testing.T) {
+	s := setupServer(t)
+	doc := loadOpenAPI(t, s)
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != apiPrefix {
+		t.Fatalf("expected server URL %s, got %+v", apiPrefix, doc.Servers)
+	}
+
+	routes := map[string]bool{}
+	for _, route := range apiRoutes(s) {
+		key := strings.ToLower(route.method) + " " + route.path
+		routes[key] = true
+		if _, ok := doc.Paths[route.path][strings.ToLower(route.method)]; !ok {
+			t.Errorf("route %s %s is not documented", route.method, route.path)
+		}
+	}
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if method != "parameters" && !routes[method+" "+path] {
+				t.Errorf("documented operation %s %s has no route", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	doc := loadOpenAPI(t, setupServer(t))
+
+	// Each schema must match the server type that encodes it and the client type that decodes it.
+	tests := map[string][]any{
+		"Tot":            {totModels.APITot{}, client.Tot{}},
+		"TotUpdate":      {totModels.APITotUpdate{}, client.TotUpdate{}},
+		"Tally":          {totModels.APITally{}, client.Tally{}},
+		"TallyList":      {totModels.APITallyList{}},
+		"TallyInput":     {totModels.APITallyInput{}, client.TallyEdit{}},
+		"Stats":          {totModels.APIStats{}, client.Stats{}},
+		"LatestActivity": {totModels.Stats{}, client.LatestActivity{}},
+		"Summary":        {totModels.GeneratedStats{}},
+		"Prediction":     {totModels.APIPrediction{}, client.Prediction{}},
+		"Error":          {totModels.APIError{}},
+	}
+	if !slices.Equal(slices.Sorted(maps.Keys(tests)), slices.Sorted(maps.Keys(doc.Components.Schemas))) {
+		t.Errorf("schemas %v do not match tested types %v", slices.Sorted(maps.Keys(doc.Components.Schemas)), slices.Sorted(maps.Keys(tests)))
+	}
+
+	for name, types := range tests {
+		documented := slices.Sorted(maps.Keys(doc.Components.Schemas[name].Properties))
+		for _, v := range types {
+			if fields := jsonFields(reflect.TypeOf(v)); !slices.Equal(fields, documented) {
+				t.Errorf("schema %s documents %v but %T has %v", name, documented, v, fields)
+			}
+		}
+	}
+}
+
+func TestClient_RoundTrip(t *testing.T) {
+	s := setupServer(t)
+	srv := httptest.NewServer(newMux(s))
+	defer srv.Close()
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	ctx := context.Background()
+	c := client.New(srv.URL, id)
+
+	tot, err := c.Tot(ctx)
+	if err != nil || tot.ID != id {
+		t.Fatalf("Tot failed: %v %+v", err, tot)
+	}
+
+	setting := "nursing"
+	if tot, err := c.UpdateTot(ctx, client.TotUpdate{MilkSetting: &setting}); err != nil || tot.MilkSetting != "nursing" {
+		t.Errorf("UpdateTot failed: %v %+v", err, tot)
+	}
+
+	added, err := c.AddTally(ctx, client.KeyPeeAndPoo)
+	if err != nil || len(added) != 2 {
+		t.Fatalf("AddTally failed: %v %+v", err, added)
+	}
+
+	earlier := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	key := client.KeyNurseLeft
+	edited, err := c.EditTally(ctx, added[0].ID, client.TallyEdit{Key: &key, Time: &earlier})
+	if err != nil || edited.Kind != "🤱L" || !edited.Time.Equal(earlier) {
+		t.Errorf("EditTally failed: %v %+v", err, edited)
+	}
+
+	tallies, err := c.Tallies(ctx, 0)
+	if err != nil || len(tallies) != 2 || tallies[1].ID != edited.ID {
+		t.Errorf("Tallies failed: %v %+v", err, tallies)
+	}
+
+	stats, err := c.Stats(ctx)
+	if err != nil || stats.Latest.LastNurse == nil || stats.Summary["todayPoo"] != "1" {
+		t.Errorf("Stats failed: %v %+v", err, stats)
+	}
+
+	if undone, err := c.Undo(ctx); err != nil || undone.Kind != "💩" {
+		t.Errorf("Undo failed: %v %+v", err, undone)
+	}
+
+	_, err = c.DeleteTally(ctx, "123-4")
+	if apiErr, ok := err.(*client.Error); !ok || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "tally_not_found" {
+		t.Errorf("expected tally_not_found error, got %v", err)
+	}
+}
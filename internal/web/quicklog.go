@@ -0,0 +1,100 @@
+// quicklog.go serves the signed one-tap links used by NFC tags and smart buttons.
+// Links name the tot by a public reference, never its ID, since the ID grants full access.
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+)
+
+var quickLogInvalid = totModels.QuickLogPageData{Error: true, Message: "This link is not valid."}
+
+// quickLogHandler logs a tally for a valid link and renders a small confirmation page.
+// Invalid, revoked and unknown links all get the same not-found page.
+func (s *Server) quickLogHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	ref := req.PathValue("ref")
+	key, err := strconv.ParseInt(req.PathValue("key"), 10, 64)
+	if err != nil {
+		return "", s.renderQuickLog(w, http.StatusNotFound, quickLogInvalid)
+	}
+
+	totID, err := s.store.LoadLink(ref)
+	if err != nil {
+		if err.Error() != "link does not exist" {
+			return "", err
+		}
+		return "", s.renderQuickLog(w, http.StatusNotFound, quickLogInvalid)
+	}
+
+	mut := s.shards.GetShardMutex(totID)
+	mut.Lock()
+	defer mut.Unlock()
+
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		if err.Error() != "tot does not exist" {
+			return totID, err
+		}
+		// The tot was deleted or cleaned up, so its reference is dangling.
+		if err := s.store.DeleteLink(ref); err != nil {
+			slog.Warn("failed to delete dangling link", "err", err)
+		}
+		return "", s.renderQuickLog(w, http.StatusNotFound, quickLogInvalid)
+	}
+	if tot.QuickLog.Ref != ref || !totCore.VerifyQuickLog(tot, key, req.PathValue("sig")) {
+		return "", s.renderQuickLog(w, http.StatusNotFound, quickLogInvalid)
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	now := time.Now()
+	data := totModels.QuickLogPageData{Name: tot.Name, Kind: totConfig.TallyKindMap[key]}
+
+	added, err := s.core.QuickLogTally(tot, key, now)
+	if err != nil {
+		return totID, err
+	}
+	if added == nil {
+		data.Message = "Already logged just now."
+		return "", s.renderQuickLog(w, http.StatusOK, data)
+	}
+
+	event := totCore.Event{Type: totCore.EventTallyAdded, Tallies: added}
+	if err := s.core.Commit(tot, tz, event); err != nil {
+		return totID, err
+	}
+	display := totDisplay(tot)
+	data.Message = fmt.Sprintf("Logged at %s.", display.Format(now.In(tz), display.Layout(s.config.TimeFormat)))
+	return "", s.renderQuickLog(w, http.StatusOK, data)
+}
+
+func (s *Server) renderQuickLog(w http.ResponseWriter, status int, data totModels.QuickLogPageData) error {
+	w.WriteHeader(status)
+	return s.templateQuickLog.Execute(w, data)
+}
+
+// quickLogPath returns the URL path of the current link for a kind, or "" if it has none.
+func quickLogPath(tot *totModels.Tot, key int64) string {
+	sig := totCore.QuickLogSignature(tot, key)
+	if sig == "" {
+		return ""
+	}
+	return "/q/" + tot.QuickLog.Ref + "/" + strconv.FormatInt(key, 10) + "/" + sig
+}
+
+// requestBaseURL returns the scheme and host the request was made to, for building absolute links.
+func requestBaseURL(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host
+}
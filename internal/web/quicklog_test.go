@@ -0,0 +1,118 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	totCore "tot-tally/internal/core"
+)
+
+func TestQuickLogHandler(t *testing.T) {
+	s := setupServer(t)
+	mux := newMux(s)
+	listener := &recordingListener{}
+	s.core.AddListener(listener)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	post := func(form url.Values) {
+		req := httptest.NewRequest("POST", "/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		if _, err := s.updateTotHandler(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("updateTotHandler failed: %v", err)
+		}
+	}
+	get := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	post(url.Values{"add_quicklog": {"11"}})
+	data, _ := s.getTotPageData(id, "", "")
+	if len(data.QuickLogs) != 1 || data.QuickLogs[0].Kind != "🚽" || strings.Contains(data.QuickLogs[0].Path, id) {
+		t.Fatalf("unexpected quick-log links: %+v", data.QuickLogs)
+	}
+	for _, k := range data.QuickLogKinds {
+		if k.Key == 11 {
+			t.Error("expected a kind with a link not to be offered again")
+		}
+	}
+	path := data.QuickLogs[0].Path
+
+	rr := get("GET", path)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Logged at") || rr.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Fatalf("expected confirmation page, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("POST", path); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Already logged") {
+		t.Errorf("expected repeat to be debounced, got %d %s", rr.Code, rr.Body.String())
+	}
+	tot, _ := s.store.LoadTot(id)
+	if len(tot.Tallies) != 1 || tot.Tallies[0].Kind != "🚽" {
+		t.Errorf("expected one pee tally, got %+v", tot.Tallies)
+	}
+	if len(listener.events) != 2 || listener.events[1].Type != totCore.EventTallyAdded {
+		t.Errorf("expected a tally.added event after the settings event, got %+v", listener.events)
+	}
+
+	ref := tot.QuickLog.Ref
+	for _, bad := range []string{
+		strings.Replace(path, "/11/", "/12/", 1),
+		path[:len(path)-2] + "xx",
+		"/q/nope/11/" + path[strings.LastIndex(path, "/")+1:],
+		"/q/" + ref + "/abc/sig",
+	} {
+		if rr := get("GET", bad); rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "not valid") {
+			t.Errorf("%s: expected 404 page, got %d", bad, rr.Code)
+		}
+	}
+
+	post(url.Values{"revoke_quicklog": {"11"}})
+	if rr := get("GET", path); rr.Code != http.StatusNotFound {
+		t.Errorf("expected revoked link to 404, got %d", rr.Code)
+	}
+
+	post(url.Values{"add_quicklog": {"12"}})
+	post(url.Values{"revoke_all_quicklogs": {"true"}})
+	if _, err := s.store.LoadLink(ref); err == nil {
+		t.Error("expected revoke all to delete the reference")
+	}
+}
+
+func TestQuickLogHandler_DeletedTot(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	s.core.CreateQuickLog(tot, 14)
+	s.store.SaveTot(tot)
+	path := quickLogPath(tot, 14)
+
+	req := httptest.NewRequest("POST", "/"+id, strings.NewReader("delete_tot=true&confirm_delete=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", id)
+	if _, err := s.updateTotHandler(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("updateTotHandler failed: %v", err)
+	}
+
+	if _, err := s.store.LoadLink(tot.QuickLog.Ref); err == nil {
+		t.Error("expected deleting the tot to delete its reference")
+	}
+	rr := httptest.NewRecorder()
+	newMux(s).ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after tot deletion, got %d", rr.Code)
+	}
+}
+
+func TestRequestBaseURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://tots.example.com/abc", nil)
+	if got := requestBaseURL(req); got != "http://tots.example.com" {
+		t.Errorf("expected http base URL, got %s", got)
+	}
+	req.Header.Set("X-Forwarded-Proto", "https")
+	if got := requestBaseURL(req); got != "https://tots.example.com" {
+		t.Errorf("expected https base URL, got %s", got)
+	}
+}
@@ -0,0 +1,186 @@
+// summary.go renders the printable visit summary, e.g. /{id}/summary?from=2023-10-01&to=2023-10-27,
+// so numbers can be handed to a pediatrician instead of copied from the dashboard.
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+	totStats "tot-tally/internal/stats"
+)
+
+func (s *Server) summaryHandler(w http.ResponseWriter, req *http.Request) (string, error) {
+	totID := req.PathValue("id")
+	if !isValidID(totID) {
+		return totID, errors.New("invalid tot id")
+	}
+
+	tot, err := s.store.LoadTot(totID)
+	if err != nil {
+		return totID, err
+	}
+
+	tz, _ := time.LoadLocation(tot.Timezone)
+	now := time.Now().In(tz)
+	// A date names the day starting at the tot's day start hour, not midnight.
+	parseDate := func(name string, fallback time.Time) (time.Time, bool) {
+		v := req.URL.Query().Get(name)
+		if v == "" {
+			return fallback, true
+		}
+		d, err := time.ParseInLocation(time.DateOnly, v, tz)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(), tot.DayStartsAt, 0, 0, 0, tz), true
+	}
+	// Dates are typed into the summary's form, so bad ones are explained rather than sent home
+	// with an unexpected error.
+	from, fromOK := parseDate("from", now.AddDate(0, 0, -6))
+	to, toOK := parseDate("to", now)
+	if !fromOK || !toOK {
+		http.Error(w, "Summary dates must look like 2023-10-27.", http.StatusBadRequest)
+		return totID, nil
+	}
+	if to.After(now) {
+		to = now
+	}
+	if from.After(to) || to.Sub(from) >= time.Duration(s.config.MaxSummaryDays)*24*time.Hour {
+		http.Error(w, fmt.Sprintf("A summary covers at most %d days, and its To date can't be before its From date.",
+			s.config.MaxSummaryDays), http.StatusBadRequest)
+		return totID, nil
+	}
+
+	categories := append([]totStats.Category{totStats.FeedCategory}, reportCategories(tot.MilkSetting)...)
+	report, err := s.stats.RangeReport(tot, tz, from, to, now, categories)
+	if err != nil {
+		return totID, err
+	}
+
+	first, last := report.Days[len(report.Days)-1].Start, report.Days[0].Start
+	display := totDisplay(tot)
+	dateLayout, dayLayout := display.DateLayout(false, true), display.DateLayout(true, false)
+	data := totModels.SummaryPageData{
+		ID: tot.ID, Name: tot.Name, Timezone: tot.Timezone,
+		From: first.Format(time.DateOnly), To: last.Format(time.DateOnly),
+		Title:       "Summary " + display.Format(first, dateLayout) + " – " + display.Format(last, dateLayout),
+		DayStartsAt: display.Hour(tot.DayStartsAt), GeneratedAt: display.Format(now, dateLayout+" "+display.ClockTime),
+		MaxTallies: s.config.MaxTallies, CoveredFrom: s.coveredFrom(tot, tz, report, display, dayLayout),
+	}
+
+	// Sleep comes from the journal and is summarized like a category, in hours and minutes.
+	journal := s.stats.JournalReport(tot, report, now)
+	type summaryColumn struct {
+		rc     totStats.ReportCategory
+		days   []totStats.ReportDay
+		value  int
+		format func(int) string
+	}
+	var columns []summaryColumn
+	for c, rc := range report.Categories {
+		columns = append(columns, summaryColumn{rc: rc, days: report.Days, value: c, format: strconv.Itoa})
+	}
+	columns = append(columns, summaryColumn{rc: journal.Sleep, days: journal.Days, format: func(minutes int) string {
+		return totStats.FormatGap(time.Duration(minutes) * time.Minute)
+	}})
+
+	for _, col := range columns {
+		// Summaries are read by people outside the app, so categories are named in words.
+		category, unit := col.rc.Category, col.rc.Category.Unit
+		if category.Name == totStats.SleepCategory.Name {
+			unit = ""
+		}
+		label := category.Label
+		if unit != "" {
+			label += " (" + unit + ")"
+		}
+		total := totModels.SummaryTotal{Label: label, Total: "---", Avg: "---", Min: "---", Max: "---"}
+		sum, hasData := 0, false
+		for _, day := range col.days {
+			if day.HasData {
+				sum, hasData = sum+day.Values[col.value], true
+			}
+		}
+		if hasData {
+			total.Total = col.format(sum)
+		}
+		if rc := col.rc; rc.Valid {
+			total.Avg = col.format(rc.Avg)
+			total.Min = fmt.Sprintf("%s (%s)", col.format(rc.Min), display.Format(rc.MinDay, dayLayout))
+			total.Max = fmt.Sprintf("%s (%s)", col.format(rc.Max), display.Format(rc.MaxDay, dayLayout))
+		}
+		data.Totals = append(data.Totals, total)
+
+		column := totModels.ReportColumn{Label: category.Emoji, Unit: unit}
+		if category.Name == totStats.FeedCategory.Name {
+			column.Label = "🍼🤱"
+		}
+		data.Columns = append(data.Columns, column)
+	}
+
+	// Summaries read oldest first, like a log.
+	for i := len(report.Days) - 1; i >= 0; i-- {
+		day := report.Days[i]
+		row := totModels.ReportRow{Date: display.Format(day.Start, dayLayout), Partial: day.Partial}
+		for _, col := range columns {
+			value := "---"
+			if d := col.days[i]; d.HasData {
+				value = col.format(d.Values[col.value])
+			}
+			row.Values = append(row.Values, value)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	timeLayout := dayLayout + " " + display.ClockTime
+	measure := func(v float64) string {
+		if v == 0 {
+			return "---"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, entry := range journal.Entries {
+		at := entry.Time.In(tz)
+		switch entry.Kind {
+		case totConfig.JournalMedication:
+			data.Medications = append(data.Medications, totModels.SummaryEntry{Time: display.Format(at, timeLayout), Text: entry.Name, Detail: entry.Dose})
+		case totConfig.JournalGrowth:
+			data.Growth = append(data.Growth, totModels.SummaryGrowth{
+				Date: display.Format(at, dateLayout), Weight: measure(entry.WeightKg), Length: measure(entry.LengthCm), Head: measure(entry.HeadCm),
+			})
+		case totConfig.JournalNote:
+			data.Notes = append(data.Notes, totModels.SummaryEntry{Time: display.Format(at, timeLayout), Text: entry.Text})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return totID, s.templateSummary.Execute(w, data)
+}
+
+// coveredFrom names the first day of report with saved tallies when older ones were dropped past
+// MaxTallies, so the days before it show --- for lack of data rather than of activity. It is empty
+// when the whole report is covered or no tallies were dropped.
+func (s *Server) coveredFrom(tot *totModels.Tot, tz *time.Location, report totStats.Report, display *totI18n.Locale, layout string) string {
+	if len(tot.Tallies) < s.config.MaxTallies || len(report.Days) == 0 || report.Days[len(report.Days)-1].HasData {
+		return ""
+	}
+	for _, day := range slices.Backward(report.Days) {
+		if day.HasData {
+			return display.Format(day.Start, layout)
+		}
+	}
+	// The whole report is older than the saved tallies.
+	oldest := *tot.Tallies[0].Time
+	for _, tally := range tot.Tallies {
+		if tally.Time.Before(oldest) {
+			oldest = *tally.Time
+		}
+	}
+	return display.Format(oldest.In(tz), layout)
+}
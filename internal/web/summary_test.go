@@ -0,0 +1,112 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	totModels "tot-tally/internal/models"
+)
+
+func TestSummaryHandler(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+	tot, _ := s.store.LoadTot(id)
+	day := func(daysAgo, hour int) *time.Time {
+		t := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -daysAgo).Add(time.Duration(hour) * time.Hour)
+		return &t
+	}
+	tot.Tallies = []totModels.Tally{
+		{Time: day(2, 9), Kind: "🤱L"},
+		{Time: day(2, 8), Kind: "🍼4"},
+		{Time: day(3, 8), Kind: "🍼3"},
+		{Time: day(3, 7), Kind: "💩"},
+		{Time: day(20, 7), Kind: "🍼1"},
+	}
+	tot.Journal = []totModels.JournalEntry{
+		{Kind: "note", Time: *day(2, 12), Text: "Teething, fussy at night"},
+		{Kind: "sleep", Time: *day(3, 22), End: day(2, 4)},
+		{Kind: "medication", Time: *day(3, 10), Name: "Vitamin D", Dose: "1 drop"},
+		{Kind: "growth", Time: *day(3, 9), WeightKg: 5.2, HeadCm: 38.5},
+		{Kind: "note", Time: *day(20, 9), Text: "Before the range"},
+	}
+	s.store.SaveTot(tot)
+
+	get := func(query string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest("GET", "/"+id+"/summary"+query, nil)
+		req.SetPathValue("id", id)
+		req.SetPathValue("page", "summary")
+		rr := httptest.NewRecorder()
+		_, err := s.totSubpageHandler(rr, req)
+		return rr, err
+	}
+
+	from, to := day(3, 0).Format(time.DateOnly), day(2, 0).Format(time.DateOnly)
+	rr, err := get("?from=" + from + "&to=" + to)
+	if err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("summaryHandler failed: %v %d", err, rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`<tr><th>Feeds</th><td class="mono">3</td><td class="mono">1</td>`,
+		`<tr><th>Bottle (oz)</th><td class="mono">7</td><td class="mono">3</td>`,
+		`<tr><th>Dirty diapers</th><td class="mono">1</td>`,
+		`<tr><th>Sleep</th><td class="mono">6h 0m</td><td class="mono">3h 0m</td>`,
+		`<td>Vitamin D</td><td>1 drop</td>`,
+		`<td class="mono">5.2</td><td class="mono">---</td><td class="mono">38.5</td>`,
+		`<td class="note">Teething, fussy at night</td>`,
+		`value="` + from + `"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in summary", want)
+		}
+	}
+	if strings.Contains(body, "Before the range") {
+		t.Error("expected journal entries outside the range to be left out")
+	}
+	// Days are listed oldest first.
+	if strings.Index(body, day(3, 0).Format("Mon 02 Jan")) > strings.Index(body, day(2, 0).Format("Mon 02 Jan")) {
+		t.Error("expected days oldest first")
+	}
+
+	if strings.Contains(body, "aren't covered") {
+		t.Error("expected no coverage note while no tallies were dropped")
+	}
+
+	// At the tally limit, days before the oldest saved tally are called out.
+	limit := s.config.MaxTallies
+	s.config.MaxTallies = len(tot.Tallies)
+	for query, want := range map[string]string{
+		"?from=" + day(30, 0).Format(time.DateOnly) + "&to=" + to:                               day(20, 0).Format("Mon 02 Jan"),
+		"?from=" + day(40, 0).Format(time.DateOnly) + "&to=" + day(30, 0).Format(time.DateOnly): day(20, 0).Format("Mon 02 Jan"),
+	} {
+		rr, err := get(query)
+		if err != nil || !strings.Contains(rr.Body.String(), "tallies before "+want+" aren't covered") {
+			t.Errorf("%s: expected a note that days before %s aren't covered", query, want)
+		}
+	}
+	if rr, _ := get("?from=" + from + "&to=" + to); strings.Contains(rr.Body.String(), "aren't covered") {
+		t.Error("expected no coverage note for a range after the oldest saved tally")
+	}
+	s.config.MaxTallies = limit
+
+	if rr, err := get(""); err != nil || !strings.Contains(rr.Body.String(), "(so far)") {
+		t.Errorf("expected default range to end today: %v", err)
+	}
+
+	for query, want := range map[string]string{
+		"?from=yesterday":                            "must look like 2023-10-27",
+		"?from=" + to + "&to=" + from:                "can't be before its From date",
+		"?from=" + day(200, 0).Format(time.DateOnly): "at most 92 days",
+		"?from=" + day(92, 0).Format(time.DateOnly) + "&to=" + day(0, 0).Format(time.DateOnly): "at most 92 days",
+	} {
+		rr, err := get(query)
+		if err != nil || rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("%s: expected 400 with %q, got %d %v", query, want, rr.Code, err)
+		}
+	}
+	if rr, err := get("?from=" + day(91, 0).Format(time.DateOnly) + "&to=" + day(0, 0).Format(time.DateOnly)); err != nil || rr.Code != http.StatusOK {
+		t.Errorf("expected a %d-day range to be allowed: %d %v", s.config.MaxSummaryDays, rr.Code, err)
+	}
+}
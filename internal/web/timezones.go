@@ -0,0 +1,121 @@
+// timezones.go builds the grouped timezone selectors, suggests a timezone for new tots and
+// presents timezone switches made while travelling.
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totI18n "tot-tally/internal/i18n"
+	totModels "tot-tally/internal/models"
+)
+
+// timezoneCookie remembers the timezone of the last tot created in this browser.
+const timezoneCookie = "last_timezone"
+
+// timezoneLocations loads every offered zone once; offsets are computed per request for DST.
+var timezoneLocations = sync.OnceValue(func() map[string]*time.Location {
+	locs := make(map[string]*time.Location, len(totConfig.Timezones))
+	for _, name := range totConfig.Timezones {
+		if loc, err := time.LoadLocation(name); err == nil {
+			locs[name] = loc
+		}
+	}
+	return locs
+})
+
+// timezoneGroups groups the offered zones by region, e.g. "America/Argentina/Buenos_Aires" becomes
+// "Argentina/Buenos Aires (GMT-3:00)" under "America". A selected zone that is not offered, such as
+// a link like "US/Eastern" set through the API, is listed first so the selector keeps it.
+func timezoneGroups(selected string, now time.Time) []totModels.TimezoneGroup {
+	locs := timezoneLocations()
+	var groups []totModels.TimezoneGroup
+	if _, ok := locs[selected]; !ok && totConfig.ValidTimezone(selected) {
+		loc, _ := time.LoadLocation(selected)
+		groups = append(groups, totModels.TimezoneGroup{Region: "Current", Options: []totModels.TimezoneOption{
+			{Value: selected, Label: timezoneLabel(selected, now.In(loc)), Selected: true},
+		}})
+	}
+
+	for _, name := range totConfig.Timezones {
+		loc, ok := locs[name]
+		if !ok {
+			continue
+		}
+		region, city, found := strings.Cut(name, "/")
+		if !found {
+			city = name
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Region != region {
+			groups = append(groups, totModels.TimezoneGroup{Region: region})
+		}
+		group := &groups[len(groups)-1]
+		group.Options = append(group.Options, totModels.TimezoneOption{
+			Value: name, Label: timezoneLabel(city, now.In(loc)), Selected: name == selected,
+		})
+	}
+	return groups
+}
+
+func timezoneLabel(city string, local time.Time) string {
+	_, offset := local.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s (GMT%s%d:%02d)", strings.ReplaceAll(city, "_", " "), sign, offset/3600, offset%3600/60)
+}
+
+// suggestTimezone picks the zone to preselect for a new tot: the timezone of the last tot created
+// in this browser, then the country of the first preferred language that names one, e.g. "en-GB",
+// then DefaultTimezone.
+func suggestTimezone(req *http.Request) string {
+	if cookie, err := req.Cookie(timezoneCookie); err == nil && totConfig.ValidTimezone(cookie.Value) {
+		return cookie.Value
+	}
+	for part := range strings.SplitSeq(req.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		subtags := strings.Split(tag, "-")
+		for _, sub := range subtags[1:] {
+			if zone, ok := totConfig.CountryTimezones[strings.ToUpper(sub)]; ok && len(sub) == 2 {
+				return zone
+			}
+		}
+	}
+	return totConfig.DefaultTimezone
+}
+
+// timezoneSinceLayout is the value of a datetime-local input.
+const timezoneSinceLayout = "2006-01-02T15:04"
+
+// parseTimezoneSince reads when a timezone switch took effect, as local time in the new zone, e.g.
+// when the flight landed. Empty means now.
+func parseTimezoneSince(value, tz string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(timezoneSinceLayout, value, loc)
+}
+
+// timezoneChanges lists the recorded switches newest first, each time shown in the zone switched to.
+func timezoneChanges(l *totI18n.Locale, tot *totModels.Tot, layout string) []totModels.TotPageTimezoneChange {
+	changes := make([]totModels.TotPageTimezoneChange, 0, len(tot.TimezoneChanges))
+	for _, c := range slices.Backward(tot.TimezoneChanges) {
+		at := c.At
+		if loc, err := time.LoadLocation(c.To); err == nil {
+			at = at.In(loc)
+		}
+		changes = append(changes, totModels.TotPageTimezoneChange{
+			At: l.Format(at, layout) + " " + at.Format("MST"), From: c.From, To: c.To,
+		})
+	}
+	return changes
+}
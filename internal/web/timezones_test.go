@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimezoneGroups(t *testing.T) {
+	summer := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	groups := timezoneGroups("America/Argentina/Buenos_Aires", summer)
+
+	labels := map[string]string{}
+	var selected []string
+	for i, g := range groups {
+		if i > 0 && groups[i-1].Region == g.Region {
+			t.Errorf("region %s is split", g.Region)
+		}
+		for _, o := range g.Options {
+			labels[o.Value] = g.Region + " " + o.Label
+			if o.Selected {
+				selected = append(selected, o.Value)
+			}
+		}
+	}
+	for zone, want := range map[string]string{
+		"America/Argentina/Buenos_Aires": "America Argentina/Buenos Aires (GMT-3:00)",
+		"America/Chicago":                "America Chicago (GMT-5:00)",
+		"Asia/Kolkata":                   "Asia Kolkata (GMT+5:30)",
+		"Europe/Oslo":                    "Europe Oslo (GMT+2:00)",
+		"UTC":                            "UTC UTC (GMT+0:00)",
+	} {
+		if labels[zone] != want {
+			t.Errorf("%s: expected %q, got %q", zone, want, labels[zone])
+		}
+	}
+	if len(selected) != 1 || selected[0] != "America/Argentina/Buenos_Aires" {
+		t.Errorf("expected one selected zone, got %v", selected)
+	}
+
+	// Valid zones that are not offered stay selectable.
+	groups = timezoneGroups("US/Eastern", summer)
+	if g := groups[0]; g.Region != "Current" || g.Options[0].Value != "US/Eastern" || !g.Options[0].Selected {
+		t.Errorf("expected the current zone first, got %+v", g)
+	}
+}
+
+func TestSuggestTimezone(t *testing.T) {
+	tests := []struct {
+		name, cookie, language, want string
+	}{
+		{"default", "", "", "America/Chicago"},
+		{"language region", "", "en-GB,en;q=0.9", "Europe/London"},
+		{"script and region", "", "zh-Hant-TW", "Asia/Taipei"},
+		{"later language", "", "en, de-AT;q=0.8", "Europe/Vienna"},
+		{"multi-zone country", "", "pt-BR", "America/Sao_Paulo"},
+		{"previous choice", "Asia/Tokyo", "en-GB", "Asia/Tokyo"},
+		{"invalid cookie", "Nowhere/Town", "", "America/Chicago"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", tc.language)
+		if tc.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: timezoneCookie, Value: tc.cookie})
+		}
+		if got := suggestTimezone(req); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestCreateTotHandler_RemembersTimezone(t *testing.T) {
+	s := setupServer(t)
+	form := url.Values{"name": {"👶"}, "timezone": {"Europe/Oslo"}, "milk_setting": {"both"}}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	if _, err := s.createTotHandler(rr, req); err != nil {
+		t.Fatalf("createTotHandler failed: %v", err)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == timezoneCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "Europe/Oslo" {
+		t.Fatalf("expected the timezone to be remembered, got %+v", cookie)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	s.homeHandler(rr, req)
+	if !strings.Contains(rr.Body.String(), `<option value="Europe/Oslo" selected>Oslo (GMT`) {
+		t.Errorf("expected the remembered timezone to be preselected")
+	}
+}
@@ -11,19 +11,22 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	totAlerts "tot-tally/internal/alerts"
+	totBackup "tot-tally/internal/backup"
 	totConfig "tot-tally/internal/config"
 	totCore "tot-tally/internal/core"
+	totDigest "tot-tally/internal/digest"
+	totMQTT "tot-tally/internal/mqtt"
+	totNotify "tot-tally/internal/notify"
 	totShards "tot-tally/internal/shards"
 	totStats "tot-tally/internal/stats"
 	totStorage "tot-tally/internal/storage"
+	totWebhooks "tot-tally/internal/webhooks"
 )
 
 // Start initializes all application layers and runs the web server.
-func Start() {
-	// 1. Setup Configuration.
-	cfg := totConfig.NewDefaultConfig()
-
-	// 2. Initialize Infrastructure.
+func Start(cfg *totConfig.Config) {
+	// 1. Initialize Infrastructure.
 	if err := os.MkdirAll(cfg.TotDirectory, 0755); err != nil {
 		slog.Error("failed to create tot directory", "err", err)
 		os.Exit(1)
@@ -32,36 +35,49 @@ func Start() {
 		slog.Error("failed to create limit directory", "err", err)
 		os.Exit(1)
 	}
+	if err := os.MkdirAll(cfg.LinkDirectory, 0755); err != nil {
+		slog.Error("failed to create link directory", "err", err)
+		os.Exit(1)
+	}
 
-	// 3. Instantiate Dependency Graph.
+	// 2. Instantiate Dependency Graph.
 	pool := totShards.NewPool(cfg.NumShards)
 	repo := totStorage.NewRepository(cfg, pool)
 	engine := totStats.NewEngine(cfg)
 	service := totCore.NewService(cfg, repo, engine)
-	cleaner := NewCleaner(cfg, repo)
+	cleaner := NewCleaner(cfg, repo, pool, service)
+	evaluator := totAlerts.NewEvaluator(cfg, repo, pool, totNotify.NewNotifiers(cfg))
+	dispatcher := totWebhooks.NewDispatcher(cfg, repo, pool)
+	service.AddListener(dispatcher)
+	var publisher *totMQTT.Publisher
+	if cfg.MQTTAddr != "" {
+		publisher = totMQTT.NewPublisher(cfg, repo, pool, engine)
+		service.AddListener(publisher)
+	}
 	router := NewServer(cfg, service, repo, engine, pool)
 
-	// 4. Define Lifecycle Context.
+	// 3. Define Lifecycle Context.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// 5. Start Background Workers.
+	// 4. Start Background Workers.
 	cleaner.StartBackgroundCleaner(ctx)
+	evaluator.StartBackgroundEvaluator(ctx)
+	if cfg.SMTPAddr != "" {
+		totDigest.NewScheduler(cfg, repo, pool, engine, totNotify.NewMailer(cfg)).StartBackgroundDigest(ctx)
+	}
+	dispatcher.StartBackgroundDispatcher(ctx)
+	if publisher != nil {
+		publisher.StartBackgroundPublisher(ctx)
+	}
+	if cfg.BackupDirectory != "" {
+		totBackup.NewWorker(cfg, pool).StartBackgroundBackup(ctx)
+	}
 
-	// 6. Setup Routing.
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /", handlerWrapper(router.homeHandler))
-	mux.HandleFunc("GET /manifest.json", handlerWrapper(router.manifestHandler))
-	mux.HandleFunc("GET /{id}", handlerWrapper(router.getTotHandler))
-	mux.HandleFunc("POST /", handlerWrapper(router.createTotHandler))
-	mux.HandleFunc("POST /{id}", handlerWrapper(router.updateTotHandler))
-	mux.HandleFunc("GET /export/{id}", handlerWrapper(router.exportTotHandler))
-
-	fileServer := http.FileServer(http.Dir("assets/static/"))
-	mux.Handle("GET /favicon.ico", http.StripPrefix("", fileServer))
-	mux.Handle("GET /static/", http.StripPrefix("/static/", fileServer))
+	// 5. Setup Routing.
+	mux := newMux(router)
 
-	// 7. Start HTTP Server.
+	// 6. Start HTTP Server.
 	server := &http.Server{
 		Addr:         cfg.Port,
 		Handler:      mux,
@@ -77,7 +93,7 @@ func Start() {
 		}
 	}()
 
-	// 8. Graceful Shutdown.
+	// 7. Graceful Shutdown.
 	<-ctx.Done()
 	slog.Info("shutting down server")
 
@@ -90,3 +106,54 @@ func Start() {
 	}
 	slog.Info("server exited cleanly")
 }
+
+// newMux registers every route on a new ServeMux.
+func newMux(router *Server) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", handlerWrapper(router.homeHandler))
+	mux.HandleFunc("GET /manifest.json", handlerWrapper(router.manifestHandler))
+	mux.HandleFunc("GET /{id}", handlerWrapper(router.getTotHandler))
+	mux.HandleFunc("POST /", handlerWrapper(router.createTotHandler))
+	mux.HandleFunc("POST /{id}", handlerWrapper(router.updateTotHandler))
+	mux.HandleFunc("GET /export/{id}", handlerWrapper(router.exportTotHandler))
+	mux.HandleFunc("GET /{id}/{page...}", handlerWrapper(router.totSubpageHandler))
+	mux.HandleFunc("GET /q/{ref}/{key}/{sig}", handlerWrapper(router.quickLogHandler))
+	mux.HandleFunc("POST /q/{ref}/{key}/{sig}", handlerWrapper(router.quickLogHandler))
+	mux.HandleFunc("GET /m/{ref}/{purpose}/{action}/{sig}", handlerWrapper(router.mailLinkHandler))
+	mux.HandleFunc("POST /m/{ref}/{purpose}/{action}/{sig}", handlerWrapper(router.mailLinkHandler))
+
+	for _, route := range apiRoutes(router) {
+		mux.HandleFunc(route.method+" "+apiPrefix+route.path, apiWrapper(route.handler))
+	}
+	mux.HandleFunc("GET /api/openapi.json", apiWrapper(router.openAPIHandler))
+	// Keep unknown API paths out of the HTML handlers, which redirect home.
+	for _, method := range []string{"GET", "POST", "PATCH", "DELETE"} {
+		mux.HandleFunc(method+" /api/", apiWrapper(router.apiNotFoundHandler))
+	}
+
+	mux.Handle("GET /favicon.ico", router.static)
+	mux.Handle("GET /static/{file...}", router.static)
+	return mux
+}
+
+// apiPrefix is the base path of the current API version.
+const apiPrefix = "/api/v1"
+
+type apiRoute struct {
+	method  string
+	path    string
+	handler APIHandlerE
+}
+
+// apiRoutes lists the JSON API endpoints. Each one is documented in assets/openapi.json.
+func apiRoutes(router *Server) []apiRoute {
+	return []apiRoute{
+		{"GET", "/tots/{id}", router.apiGetTotHandler},
+		{"PATCH", "/tots/{id}", router.apiUpdateTotHandler},
+		{"GET", "/tots/{id}/tallies", router.apiListTalliesHandler},
+		{"POST", "/tots/{id}/tallies", router.apiAddTallyHandler},
+		{"PATCH", "/tots/{id}/tallies/{tally}", router.apiEditTallyHandler},
+		{"DELETE", "/tots/{id}/tallies/{tally}", router.apiDeleteTallyHandler},
+		{"GET", "/tots/{id}/stats", router.apiStatsHandler},
+	}
+}
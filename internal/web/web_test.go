@@ -0,0 +1,34 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewMux(t *testing.T) {
+	s := setupServer(t)
+	id, _ := s.core.CreateTot("👶", "UTC", "both")
+
+	// Registering conflicting patterns panics, so building the mux is itself the first check.
+	mux := newMux(s)
+
+	tests := []struct {
+		method, path string
+		expected     int
+	}{
+		{"GET", "/" + id, http.StatusOK},
+		{"GET", "/" + id + "/report", http.StatusOK},
+		{"GET", "/export/" + id, http.StatusOK},
+		{"GET", "/manifest.json", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != tt.expected {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.expected, rr.Code)
+		}
+	}
+}
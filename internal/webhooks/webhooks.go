@@ -0,0 +1,248 @@
+// webhooks.go delivers signed tot change events to subscribed URLs in the background.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	totShards "tot-tally/internal/shards"
+	totStorage "tot-tally/internal/storage"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body, keyed by the webhook secret.
+	SignatureHeader = "X-Tot-Tally-Signature"
+	EventHeader     = "X-Tot-Tally-Event"
+	DeliveryHeader  = "X-Tot-Tally-Delivery"
+
+	// maxConcurrentDeliveries bounds the deliveries in flight, including those waiting to retry.
+	maxConcurrentDeliveries = 8
+)
+
+// Payload is the JSON body POSTed to a webhook.
+type Payload struct {
+	ID      string                   `json:"id"` // Unique per event and webhook; repeated on retries.
+	Event   string                   `json:"event"`
+	At      time.Time                `json:"at"`
+	Tot     PayloadTot               `json:"tot"`
+	Tallies []totModels.Tally        `json:"tallies,omitempty"`
+	Entries []totModels.JournalEntry `json:"entries,omitempty"`
+	Setting string                   `json:"setting,omitempty"`
+}
+
+type PayloadTot struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Sign returns the signature header value for a payload body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// New creates a webhook subscription, generating a secret when none is given. The URL must be
+// public unless allowPrivate is set; deliveries are checked again when connecting.
+func New(rawURL, secret string, allowPrivate bool) (totModels.Webhook, error) {
+	if len(rawURL) > 2048 {
+		return totModels.Webhook{}, errors.New("webhooks: invalid URL")
+	}
+	if err := totNotify.CheckURL(rawURL, allowPrivate); err != nil {
+		return totModels.Webhook{}, fmt.Errorf("webhooks: invalid URL: %w", err)
+	}
+	if len(secret) > 256 {
+		return totModels.Webhook{}, errors.New("webhooks: secret too long")
+	}
+	if secret == "" {
+		secret = rand.Text()
+	}
+	return totModels.Webhook{ID: rand.Text()[:10], URL: rawURL, Secret: secret}, nil
+}
+
+type job struct {
+	totID   string
+	webhook totModels.Webhook
+	payload Payload
+	body    []byte
+}
+
+// Dispatcher queues events from core.Service and delivers them with retries.
+type Dispatcher struct {
+	config *totConfig.Config
+	store  *totStorage.Repository
+	pool   *totShards.Pool
+	client *http.Client
+	queue  chan job
+}
+
+// NewDispatcher initializes the webhook delivery service.
+func NewDispatcher(cfg *totConfig.Config, store *totStorage.Repository, pool *totShards.Pool) *Dispatcher {
+	return &Dispatcher{
+		config: cfg,
+		store:  store,
+		pool:   pool,
+		client: totNotify.NewClient(cfg.NotifyTimeout, cfg.AllowPrivateTargets),
+		queue:  make(chan job, cfg.WebhookQueue),
+	}
+}
+
+// TotChanged implements core.Listener by queueing a delivery for each of the tot's webhooks.
+// Events are dropped rather than blocking the request when the queue is full.
+func (d *Dispatcher) TotChanged(tot *totModels.Tot, event totCore.Event) {
+	for _, webhook := range tot.Webhooks {
+		payload := Payload{
+			ID:      rand.Text(),
+			Event:   string(event.Type),
+			At:      event.At,
+			Tot:     PayloadTot{ID: tot.ID, Name: tot.Name},
+			Tallies: event.Tallies,
+			Entries: event.Entries,
+			Setting: event.Setting,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			slog.Error("webhook payload encoding failed", "id", tot.ID, "err", err)
+			continue
+		}
+
+		select {
+		case d.queue <- job{totID: tot.ID, webhook: webhook, payload: payload, body: body}:
+		default:
+			slog.Warn("webhook queue full, dropping event", "id", tot.ID, "event", event.Type)
+		}
+	}
+}
+
+// StartBackgroundDispatcher initiates a goroutine that delivers queued events until ctx is done.
+// Deliveries still waiting to retry at shutdown are abandoned and logged as failed.
+func (d *Dispatcher) StartBackgroundDispatcher(ctx context.Context) {
+	go func() {
+		slots := make(chan struct{}, maxConcurrentDeliveries)
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				slog.Info("background webhook dispatcher stopping")
+				return
+			}
+
+			select {
+			case j := <-d.queue:
+				go func() {
+					defer func() { <-slots }()
+					d.deliver(ctx, j)
+				}()
+			case <-ctx.Done():
+				slog.Info("background webhook dispatcher stopping")
+				return
+			}
+		}
+	}()
+}
+
+// deliver POSTs a job, retrying with exponential backoff, and logs the outcome on the tot.
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	var status, attempts int
+	var err error
+	backoff := d.config.WebhookBackoff
+
+	for attempts = 1; ; attempts++ {
+		status, err = d.send(ctx, j)
+		// A refused private address won't become public, so it isn't retried.
+		if err == nil || attempts > d.config.WebhookRetries || !retryable(status) || errors.Is(err, totNotify.ErrPrivateAddress) {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			err = fmt.Errorf("webhooks: abandoned at shutdown: %w", err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		slog.Warn("webhook delivery failed", "id", j.totID, "webhook", j.webhook.ID, "attempts", attempts, "err", err)
+	}
+	if err := d.record(j, attempts, status, err); err != nil {
+		slog.Warn("webhook delivery log failed", "id", j.totID, "err", err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, j job) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.webhook.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, fmt.Errorf("webhooks: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tot-tally")
+	req.Header.Set(EventHeader, j.payload.Event)
+	req.Header.Set(DeliveryHeader, j.payload.ID)
+	req.Header.Set(SignatureHeader, Sign(j.webhook.Secret, j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhooks: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhooks: unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed later. Client errors other than
+// timeouts and rate limits will not.
+func retryable(status int) bool {
+	if status == http.StatusRequestTimeout || status == http.StatusTooManyRequests {
+		return true
+	}
+	return status < 400 || status > 499
+}
+
+// record adds a delivery to the tot's log without counting as activity.
+func (d *Dispatcher) record(j job, attempts, status int, deliveryErr error) error {
+	mut := d.pool.GetShardMutex(j.totID)
+	mut.Lock()
+	defer mut.Unlock()
+
+	tot, err := d.store.LoadTot(j.totID)
+	if err != nil {
+		return err
+	}
+
+	entry := totModels.WebhookDelivery{
+		WebhookID: j.webhook.ID,
+		Event:     j.payload.Event,
+		At:        j.payload.At,
+		Attempts:  attempts,
+		Status:    status,
+	}
+	if deliveryErr != nil {
+		entry.Error = deliveryErr.Error()
+	}
+	tot.WebhookLog = append([]totModels.WebhookDelivery{entry}, tot.WebhookLog...)
+	if len(tot.WebhookLog) > d.config.WebhookLogSize {
+		tot.WebhookLog = tot.WebhookLog[:d.config.WebhookLogSize]
+	}
+	return d.store.SaveTotWithoutActivity(tot)
+}
@@ -0,0 +1,253 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	totConfig "tot-tally/internal/config"
+	totCore "tot-tally/internal/core"
+	totModels "tot-tally/internal/models"
+	totNotify "tot-tally/internal/notify"
+	totShards "tot-tally/internal/shards"
+	totStorage "tot-tally/internal/storage"
+)
+
+func setupDispatcher(t *testing.T) (*Dispatcher, *totStorage.Repository) {
+	cfg := totConfig.NewDefaultConfig()
+	cfg.TotDirectory = t.TempDir()
+	cfg.WebhookBackoff = time.Millisecond
+	cfg.WebhookLogSize = 3
+	cfg.WebhookQueue = 2
+	cfg.AllowPrivateTargets = true // Deliveries go to test servers on loopback.
+	pool := totShards.NewPool(4)
+	repo := totStorage.NewRepository(cfg, pool)
+	return NewDispatcher(cfg, repo, pool), repo
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+	if got := Sign("secret", []byte("{}")); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	w, err := New("https://example.com/hook", "", false)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if w.ID == "" || len(w.Secret) < 20 {
+		t.Errorf("expected generated ID and secret, got %+v", w)
+	}
+	if w, _ := New("http://192.168.1.5:8123/hook", "shh", true); w.Secret != "shh" {
+		t.Errorf("expected given secret to be kept, got %s", w.Secret)
+	}
+
+	for _, bad := range []string{"", "ftp://example.com", "https://", "example.com/hook"} {
+		if _, err := New(bad, "", false); err == nil {
+			t.Errorf("expected error for URL %q, got nil", bad)
+		}
+	}
+	for _, private := range []string{"http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://10.0.0.8/hook", "http://169.254.169.254/latest", "http://192.168.1.5:8123/hook"} {
+		if _, err := New(private, "", false); !errors.Is(err, totNotify.ErrPrivateAddress) {
+			t.Errorf("expected %s refused as private, got %v", private, err)
+		}
+	}
+	if _, err := New("https://example.com", strings.Repeat("x", 257), false); err == nil {
+		t.Error("expected error for long secret, got nil")
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	var calls atomic.Int32
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	now := time.Now().UTC()
+	tot := &totModels.Tot{ID: "tot", Name: "👶", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL, Secret: "shh"}}}
+	repo.SaveTot(tot)
+
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventTallyAdded, At: now, Tallies: []totModels.Tally{{Time: &now, Kind: "🚽"}}})
+	d.deliver(context.Background(), <-d.queue)
+
+	if calls.Load() != 3 {
+		t.Errorf("expected 2 retries before success, got %d calls", calls.Load())
+	}
+	if header.Get(SignatureHeader) != Sign("shh", body) || header.Get(EventHeader) != "tally.added" {
+		t.Errorf("unexpected headers: %v", header)
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Tot.ID != "tot" || len(payload.Tallies) != 1 || payload.Tallies[0].Kind != "🚽" || payload.ID != header.Get(DeliveryHeader) {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+
+	loaded, _ := repo.LoadTot("tot")
+	if len(loaded.WebhookLog) != 1 || loaded.WebhookLog[0].Attempts != 3 || loaded.WebhookLog[0].Status != 200 || loaded.WebhookLog[0].Error != "" {
+		t.Errorf("unexpected delivery log: %+v", loaded.WebhookLog)
+	}
+}
+
+func TestDispatcher_DeliverFailure(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	tot := &totModels.Tot{ID: "tot", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL}}}
+	repo.SaveTot(tot)
+
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventSettingsUpdated, Setting: "timezone"})
+	d.deliver(context.Background(), <-d.queue)
+	if int(calls.Load()) != 1+d.config.WebhookRetries {
+		t.Errorf("expected %d attempts, got %d", 1+d.config.WebhookRetries, calls.Load())
+	}
+
+	// Client errors are not retried.
+	calls.Store(0)
+	status = http.StatusGone
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventSettingsUpdated, Setting: "timezone"})
+	d.deliver(context.Background(), <-d.queue)
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt for 410, got %d", calls.Load())
+	}
+
+	loaded, _ := repo.LoadTot("tot")
+	if len(loaded.WebhookLog) != 2 || loaded.WebhookLog[0].Status != 410 || loaded.WebhookLog[1].Status != 500 || loaded.WebhookLog[0].Error == "" {
+		t.Errorf("unexpected delivery log: %+v", loaded.WebhookLog)
+	}
+}
+
+func TestDispatcher_RefusesPrivateAddress(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	d.config.AllowPrivateTargets = false
+	d.client = totNotify.NewClient(time.Second, false)
+	// Saved directly, as a URL whose host name later resolved to loopback would be.
+	tot := &totModels.Tot{ID: "tot", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL}}}
+	repo.SaveTot(tot)
+
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventSettingsUpdated, Setting: "timezone"})
+	d.deliver(context.Background(), <-d.queue)
+	if calls.Load() != 0 {
+		t.Errorf("expected no request to reach the private server, got %d", calls.Load())
+	}
+	loaded, _ := repo.LoadTot("tot")
+	if len(loaded.WebhookLog) != 1 || loaded.WebhookLog[0].Attempts != 1 || !strings.Contains(loaded.WebhookLog[0].Error, "private address") {
+		t.Errorf("expected one refused attempt, got %+v", loaded.WebhookLog)
+	}
+}
+
+func TestDispatcher_LogSizeAndQueue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	tot := &totModels.Tot{ID: "tot", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL}}}
+	repo.SaveTot(tot)
+
+	for range 5 {
+		d.TotChanged(tot, totCore.Event{Type: totCore.EventTallyUndone})
+	}
+	if len(d.queue) != 2 {
+		t.Fatalf("expected events beyond the queue size to be dropped, got %d queued", len(d.queue))
+	}
+
+	for range 2 {
+		d.deliver(context.Background(), <-d.queue)
+	}
+	for range 2 {
+		d.TotChanged(tot, totCore.Event{Type: totCore.EventTallyUndone})
+		d.deliver(context.Background(), <-d.queue)
+	}
+	loaded, _ := repo.LoadTot("tot")
+	if len(loaded.WebhookLog) != d.config.WebhookLogSize {
+		t.Errorf("expected log trimmed to %d, got %d", d.config.WebhookLogSize, len(loaded.WebhookLog))
+	}
+}
+
+func TestStartBackgroundDispatcher(t *testing.T) {
+	delivered := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.Header.Get(EventHeader)
+	}))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	tot := &totModels.Tot{ID: "tot", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL}}}
+	repo.SaveTot(tot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.StartBackgroundDispatcher(ctx)
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventTallyAdded})
+
+	select {
+	case event := <-delivered:
+		if event != "tally.added" {
+			t.Errorf("expected tally.added, got %s", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+
+	// Wait for the delivery to be logged before the temp directory is removed.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if loaded, _ := repo.LoadTot("tot"); len(loaded.WebhookLog) == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected the delivery to be logged")
+}
+
+func TestDeliver_Shutdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	d, repo := setupDispatcher(t)
+	d.config.WebhookBackoff = time.Hour
+	tot := &totModels.Tot{ID: "tot", Webhooks: []totModels.Webhook{{ID: "w1", URL: srv.URL}}}
+	repo.SaveTot(tot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.TotChanged(tot, totCore.Event{Type: totCore.EventTallyAdded})
+	j := <-d.queue
+	time.AfterFunc(20*time.Millisecond, cancel)
+	d.deliver(ctx, j)
+
+	loaded, _ := repo.LoadTot("tot")
+	if len(loaded.WebhookLog) != 1 || !strings.Contains(loaded.WebhookLog[0].Error, "shutdown") {
+		t.Errorf("expected delivery abandoned at shutdown, got %+v", loaded.WebhookLog)
+	}
+}
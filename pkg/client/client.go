@@ -0,0 +1,224 @@
+// Package client is a Go client for the tot-tally JSON API described at /api/openapi.json.
+//
+// A Client is bound to one tot, since the tot ID is the API credential:
+//
+//	c := client.New("https://tots.example.com", totID)
+//	added, err := c.AddTally(ctx, client.KeyPeeAndPoo)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tally keys, the numbers in the server's config.TallyKindMap. Milk keys 1 to 8 are ounces.
+const (
+	KeyMilk1      int64 = 1
+	KeyMilk8      int64 = 8
+	KeySnack      int64 = 9
+	KeyMeal       int64 = 10
+	KeyPee        int64 = 11
+	KeyPoo        int64 = 12
+	KeyPeeAndPoo  int64 = 13 // Recorded as two tallies.
+	KeyBath       int64 = 14
+	KeyBrush      int64 = 15
+	KeyNurseLeft  int64 = 16
+	KeyNurseRight int64 = 17
+	LatestTallyID       = "latest"
+)
+
+type Tot struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Timezone    string    `json:"timezone"`
+	MilkSetting string    `json:"milkSetting"`
+	DayStartsAt int       `json:"dayStartsAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TotUpdate changes tot settings. Nil fields are left unchanged.
+type TotUpdate struct {
+	Timezone    *string `json:"timezone,omitempty"`
+	MilkSetting *string `json:"milkSetting,omitempty"`
+	DayStartsAt *int    `json:"dayStartsAt,omitempty"`
+}
+
+type Tally struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Key  int64     `json:"key"`
+}
+
+// TallyEdit changes a tally. Nil fields are left unchanged.
+type TallyEdit struct {
+	Key  *int64     `json:"key,omitempty"`
+	Time *time.Time `json:"time,omitempty"`
+}
+
+type Stats struct {
+	Latest LatestActivity `json:"latest"`
+	// Summary holds display values such as "todayMilk": "12", with "---" for no data.
+	Summary     map[string]string     `json:"summary"`
+	Predictions map[string]Prediction `json:"predictions"`
+	Alerts      []string              `json:"alerts"`
+}
+
+type LatestActivity struct {
+	LastMilk      *time.Time `json:"lastMilk"`
+	LastNurse     *time.Time `json:"lastNurse"`
+	LastNurseSide string     `json:"lastNurseSide"`
+	LastSnack     *time.Time `json:"lastSnack"`
+	LastMeal      *time.Time `json:"lastMeal"`
+	LastPee       *time.Time `json:"lastPee"`
+	LastPoo       *time.Time `json:"lastPoo"`
+	LastBath      *time.Time `json:"lastBath"`
+	LastBrush     *time.Time `json:"lastBrush"`
+}
+
+// Prediction is the expected next "feed" or "diaper". Next is nil without enough history.
+type Prediction struct {
+	Next               *time.Time `json:"next"`
+	ExpectedGapMinutes int        `json:"expectedGapMinutes"`
+	Unusual            bool       `json:"unusual"`
+}
+
+// Error is returned for API error responses.
+type Error struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client calls the API for a single tot.
+type Client struct {
+	BaseURL    string
+	TotID      string
+	HTTPClient *http.Client
+}
+
+// New returns a client for the tot with the given ID on the server at baseURL.
+func New(baseURL, totID string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		TotID:      totID,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Tot fetches the tot.
+func (c *Client) Tot(ctx context.Context) (*Tot, error) {
+	return call[*Tot](ctx, c, http.MethodGet, "", nil)
+}
+
+// UpdateTot changes the tot's settings.
+func (c *Client) UpdateTot(ctx context.Context, update TotUpdate) (*Tot, error) {
+	return call[*Tot](ctx, c, http.MethodPatch, "", update)
+}
+
+// Tallies lists up to limit tallies, newest first. A limit of 0 lists them all.
+func (c *Client) Tallies(ctx context.Context, limit int) ([]Tally, error) {
+	path := "/tallies"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+	list, err := call[tallyList](ctx, c, http.MethodGet, path, nil)
+	return list.Tallies, err
+}
+
+// AddTally records a tally of the given key now and returns the tallies added.
+func (c *Client) AddTally(ctx context.Context, key int64) ([]Tally, error) {
+	list, err := call[tallyList](ctx, c, http.MethodPost, "/tallies", TallyEdit{Key: &key})
+	return list.Tallies, err
+}
+
+// EditTally changes a tally. The edited tally has a new ID.
+func (c *Client) EditTally(ctx context.Context, id string, edit TallyEdit) (*Tally, error) {
+	return call[*Tally](ctx, c, http.MethodPatch, "/tallies/"+url.PathEscape(id), edit)
+}
+
+// DeleteTally deletes a tally and returns it.
+func (c *Client) DeleteTally(ctx context.Context, id string) (*Tally, error) {
+	return call[*Tally](ctx, c, http.MethodDelete, "/tallies/"+url.PathEscape(id), nil)
+}
+
+// Undo deletes the most recent tally and returns it.
+func (c *Client) Undo(ctx context.Context) (*Tally, error) {
+	return c.DeleteTally(ctx, LatestTallyID)
+}
+
+// Stats fetches the tot's computed stats.
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	return call[*Stats](ctx, c, http.MethodGet, "/stats", nil)
+}
+
+type tallyList struct {
+	Tallies []Tally `json:"tallies"`
+}
+
+// call performs a request and decodes the response into a T, returning the zero T on error.
+func call[T any](ctx context.Context, c *Client, method, path string, in any) (T, error) {
+	var out T
+	if err := c.do(ctx, method, path, in, &out); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("client: failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	endpoint := c.BaseURL + "/api/v1/tots/" + url.PathEscape(c.TotID) + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("client: failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("client: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var wrapped struct {
+			Error *Error `json:"error"`
+		}
+		wrapped.Error = apiErr
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&wrapped); err != nil || apiErr.Code == "" {
+			apiErr.Code, apiErr.Message = "unknown", http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return nil
+}
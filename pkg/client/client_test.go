@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Request(t *testing.T) {
+	var method, path, contentType string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(b, &body)
+		w.Write([]byte(`{"tallies": [{"id": "1-11", "kind": "🚽", "key": 11}]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "abc")
+	tallies, err := c.AddTally(context.Background(), KeyPee)
+	if err != nil || len(tallies) != 1 || tallies[0].Key != KeyPee {
+		t.Fatalf("AddTally failed: %v %+v", err, tallies)
+	}
+	if method != "POST" || path != "/api/v1/tots/abc/tallies" || contentType != "application/json" || body["key"] != float64(11) {
+		t.Errorf("unexpected request: %s %s %s %v", method, path, contentType, body)
+	}
+
+	c.Tallies(context.Background(), 5)
+	if method != "GET" || path != "/api/v1/tots/abc/tallies?limit=5" || contentType != "" {
+		t.Errorf("unexpected request: %s %s %s", method, path, contentType)
+	}
+
+	c.DeleteTally(context.Background(), "a/b")
+	if method != "DELETE" || path != "/api/v1/tots/abc/tallies/a%2Fb" {
+		t.Errorf("expected escaped tally ID, got %s %s", method, path)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/tots/abc":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "not_found", "message": "tot not found"}}`))
+		case "/api/v1/tots/abc/stats":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		default:
+			w.Write([]byte(`not json`))
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "abc")
+
+	tot, err := c.Tot(context.Background())
+	var apiErr *Error
+	if tot != nil || !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || apiErr.Code != "not_found" || apiErr.Message != "tot not found" {
+		t.Errorf("expected not_found error, got %v %v", tot, err)
+	}
+
+	_, err = c.Stats(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 502 || apiErr.Code != "unknown" {
+		t.Errorf("expected unknown error for non-JSON body, got %v", err)
+	}
+
+	if _, err := c.Undo(context.Background()); err == nil || errors.As(err, &apiErr) {
+		t.Errorf("expected decode error, got %v", err)
+	}
+
+	c.BaseURL = "http://127.0.0.1:1"
+	if _, err := c.Tot(context.Background()); err == nil {
+		t.Error("expected connection error, got nil")
+	}
+}